@@ -0,0 +1,135 @@
+package clamav
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/configs"
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+)
+
+const (
+	// chunkSize stays well below the default StreamMaxLength of clamd.
+	chunkSize = 64 * 1024
+
+	replyOK    = "OK"
+	suffixHit  = " FOUND"
+	suffixFail = " ERROR"
+
+	// failSizeLimit is the error clamd reports for streams over its
+	// StreamMaxLength.
+	failSizeLimit = "INSTREAM size limit exceeded."
+)
+
+// Scanner streams content to a clamd daemon with the INSTREAM command.
+type Scanner struct {
+	address string
+	timeout time.Duration
+}
+
+// NewScanner returns nil when no clamd address is configured, which disables
+// scanning.
+func NewScanner(cfg *configs.Config) ports.Scanner {
+	if cfg.ClamAV.Address == "" {
+		return nil
+	}
+
+	return &Scanner{
+		address: cfg.ClamAV.Address,
+		timeout: cfg.ClamAV.Timeout,
+	}
+}
+
+func (s *Scanner) Scan(ctx context.Context, content io.Reader) (*domain.ScanResult, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok && s.timeout > 0 {
+		deadline = time.Now().Add(s.timeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set clamd deadline: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	sendErr := stream(conn, content)
+
+	// clamd answers and hangs up as soon as the stream goes over its size
+	// limit, so a reply is looked for even when sending failed.
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		if sendErr != nil {
+			return nil, sendErr
+		}
+		return nil, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+
+	return parseReply(reply)
+}
+
+// stream sends content as length-prefixed chunks, ended by an empty chunk.
+func stream(conn net.Conn, content io.Reader) error {
+	if _, err := io.WriteString(conn, "zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("failed to send clamd command: %w", err)
+	}
+
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, err := io.ReadFull(content, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				return fmt.Errorf("failed to send content to clamd: %w", err)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read content: %w", err)
+		}
+	}
+
+	if _, err := conn.Write(make([]byte, 4)); err != nil {
+		return fmt.Errorf("failed to send content to clamd: %w", err)
+	}
+	return nil
+}
+
+// parseReply reads replies such as "stream: OK" and
+// "stream: Eicar-Test-Signature FOUND". Content over the size limit is
+// refused rather than failed, as sending it again cannot succeed.
+func parseReply(reply string) (*domain.ScanResult, error) {
+	reply = strings.TrimRight(reply, "\x00\n")
+	_, verdict, ok := strings.Cut(reply, ": ")
+	if !ok {
+		verdict = reply
+	}
+
+	switch {
+	case verdict == replyOK:
+		return &domain.ScanResult{}, nil
+	case strings.HasSuffix(verdict, suffixHit):
+		return &domain.ScanResult{Infected: true, Signature: strings.TrimSuffix(verdict, suffixHit)}, nil
+	case verdict == failSizeLimit+suffixFail:
+		return &domain.ScanResult{Refused: failSizeLimit}, nil
+	case strings.HasSuffix(verdict, suffixFail):
+		return nil, fmt.Errorf("clamd failed to scan content: %s", strings.TrimSuffix(verdict, suffixFail))
+	default:
+		return nil, fmt.Errorf("unexpected clamd reply %q", reply)
+	}
+}
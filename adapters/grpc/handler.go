@@ -2,9 +2,14 @@ package grpc
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/gruzdev-dev/codex-files/core/domain"
 	"github.com/gruzdev-dev/codex-files/core/services"
+	"github.com/gruzdev-dev/codex-files/pkg/identity"
 	"github.com/gruzdev-dev/codex-files/proto"
 )
 
@@ -30,18 +35,51 @@ func (h *FilesHandler) GeneratePresignedUrls(ctx context.Context, req *proto.Gen
 		return nil, fmt.Errorf("size must be positive")
 	}
 
-	result, err := h.fileService.GenerateUploadURL(ctx, req.UserId, req.ContentType, req.Size)
+	checksum, err := requestChecksum(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := h.fileService.GenerateUploadURL(ctx, req.UserId, req.ContentType, req.Size, checksum)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate upload URL: %w", err)
 	}
 
+	headers := make([]*proto.UploadHeader, 0, len(result.UploadHeaders))
+	for name, value := range result.UploadHeaders {
+		headers = append(headers, &proto.UploadHeader{Name: name, Value: value})
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+
 	return &proto.GeneratePresignedUrlsResponse{
-		FileId:      result.FileID,
-		UploadUrl:   result.UploadURL,
-		DownloadUrl: result.DownloadURL,
+		FileId:        result.FileID,
+		UploadUrl:     result.UploadURL,
+		DownloadUrl:   result.DownloadURL,
+		UploadHeaders: headers,
 	}, nil
 }
 
+func requestChecksum(req *proto.GeneratePresignedUrlsRequest) (domain.Checksum, error) {
+	if req.Sha256 != "" && req.Sha1 != "" {
+		return domain.Checksum{}, fmt.Errorf("only one of sha256 and sha1 may be set")
+	}
+
+	algorithm, encoded := domain.ChecksumSHA256, req.Sha256
+	if req.Sha1 != "" {
+		algorithm, encoded = domain.ChecksumSHA1, req.Sha1
+	}
+	if encoded == "" {
+		return domain.Checksum{}, nil
+	}
+
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return domain.Checksum{}, fmt.Errorf("%s must be base64 encoded", algorithm)
+	}
+
+	return domain.Checksum{Algorithm: algorithm, Value: value}, nil
+}
+
 func (h *FilesHandler) DeleteFile(ctx context.Context, req *proto.DeleteFileRequest) (*proto.DeleteFileResponse, error) {
 	if req.FileId == "" {
 		return nil, fmt.Errorf("file_id is required")
@@ -54,3 +92,217 @@ func (h *FilesHandler) DeleteFile(ctx context.Context, req *proto.DeleteFileRequ
 
 	return &proto.DeleteFileResponse{}, nil
 }
+
+func (h *FilesHandler) RestoreFile(ctx context.Context, req *proto.RestoreFileRequest) (*proto.RestoreFileResponse, error) {
+	if req.FileId == "" {
+		return nil, fmt.Errorf("file_id is required")
+	}
+	if req.UserId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	ctx = identity.WithCtx(ctx, domain.Identity{UserID: req.UserId, Scopes: req.Scopes})
+	file, err := h.fileService.RestoreFile(ctx, req.FileId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore file: %w", err)
+	}
+
+	return &proto.RestoreFileResponse{
+		FileId:      file.ID,
+		Status:      string(file.Status),
+		DownloadUrl: fmt.Sprintf("/files/%s/download", file.ID),
+	}, nil
+}
+
+func (h *FilesHandler) GrantAccess(ctx context.Context, req *proto.GrantAccessRequest) (*proto.GrantAccessResponse, error) {
+	if req.FileId == "" {
+		return nil, fmt.Errorf("file_id is required")
+	}
+	if req.UserId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	grant := domain.FileGrant{
+		FileID:     req.FileId,
+		Grantee:    domain.Grantee{Type: domain.GranteeType(req.GranteeType), ID: req.GranteeId},
+		Permission: domain.Permission(req.Permission),
+	}
+	if req.ExpiresAt != 0 {
+		grant.ExpiresAt = time.Unix(req.ExpiresAt, 0)
+	}
+
+	ctx = identity.WithCtx(ctx, domain.Identity{UserID: req.UserId})
+	created, err := h.fileService.GrantAccess(ctx, grant)
+	if err != nil {
+		return nil, fmt.Errorf("failed to grant access: %w", err)
+	}
+
+	return &proto.GrantAccessResponse{Grant: newProtoGrant(created)}, nil
+}
+
+func (h *FilesHandler) RevokeAccess(ctx context.Context, req *proto.RevokeAccessRequest) (*proto.RevokeAccessResponse, error) {
+	if req.FileId == "" {
+		return nil, fmt.Errorf("file_id is required")
+	}
+	if req.UserId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	ctx = identity.WithCtx(ctx, domain.Identity{UserID: req.UserId})
+	grantee := domain.Grantee{Type: domain.GranteeType(req.GranteeType), ID: req.GranteeId}
+	if err := h.fileService.RevokeAccess(ctx, req.FileId, grantee, domain.Permission(req.Permission)); err != nil {
+		return nil, fmt.Errorf("failed to revoke access: %w", err)
+	}
+
+	return &proto.RevokeAccessResponse{}, nil
+}
+
+func (h *FilesHandler) ListGrants(ctx context.Context, req *proto.ListGrantsRequest) (*proto.ListGrantsResponse, error) {
+	if req.FileId == "" {
+		return nil, fmt.Errorf("file_id is required")
+	}
+	if req.UserId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	ctx = identity.WithCtx(ctx, domain.Identity{UserID: req.UserId})
+	grants, err := h.fileService.ListGrants(ctx, req.FileId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list grants: %w", err)
+	}
+
+	resp := &proto.ListGrantsResponse{Grants: make([]*proto.FileGrant, 0, len(grants))}
+	for _, grant := range grants {
+		resp.Grants = append(resp.Grants, newProtoGrant(grant))
+	}
+
+	return resp, nil
+}
+
+func newProtoGrant(grant *domain.FileGrant) *proto.FileGrant {
+	resp := &proto.FileGrant{
+		GranteeType: string(grant.Grantee.Type),
+		GranteeId:   grant.Grantee.ID,
+		Permission:  string(grant.Permission),
+		GrantedBy:   grant.GrantedBy,
+		CreatedAt:   grant.CreatedAt.Unix(),
+	}
+	if !grant.ExpiresAt.IsZero() {
+		resp.ExpiresAt = grant.ExpiresAt.Unix()
+	}
+	return resp
+}
+
+func (h *FilesHandler) GetUsage(ctx context.Context, req *proto.GetUsageRequest) (*proto.GetUsageResponse, error) {
+	if req.UserId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+
+	usages, err := h.fileService.GetUsage(ctx, req.UserId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+
+	resp := &proto.GetUsageResponse{Usage: make([]*proto.QuotaUsage, 0, len(usages))}
+	for _, usage := range usages {
+		resp.Usage = append(resp.Usage, &proto.QuotaUsage{
+			Scope:         string(usage.Quota.Scope),
+			TenantId:      usage.Quota.TenantID,
+			OwnerId:       usage.Quota.OwnerID,
+			MaxBytes:      usage.Quota.MaxBytes,
+			MaxFiles:      usage.Quota.MaxFiles,
+			Bytes:         usage.Usage.Bytes,
+			Files:         usage.Usage.Files,
+			ReservedBytes: usage.Usage.ReservedBytes,
+			ReservedFiles: usage.Usage.ReservedFiles,
+		})
+	}
+
+	return resp, nil
+}
+
+func (h *FilesHandler) InitiateMultipartUpload(ctx context.Context, req *proto.InitiateMultipartUploadRequest) (*proto.InitiateMultipartUploadResponse, error) {
+	if req.UserId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if req.ContentType == "" {
+		return nil, fmt.Errorf("content_type is required")
+	}
+	if req.Size <= 0 {
+		return nil, fmt.Errorf("size must be positive")
+	}
+
+	result, err := h.fileService.InitiateMultipartUpload(ctx, req.UserId, req.ContentType, req.Size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initiate multipart upload: %w", err)
+	}
+
+	return &proto.InitiateMultipartUploadResponse{
+		FileId:      result.FileID,
+		PartSize:    result.PartSize,
+		PartCount:   int32(result.PartCount),
+		DownloadUrl: result.DownloadURL,
+	}, nil
+}
+
+func (h *FilesHandler) GetUploadPartUrls(ctx context.Context, req *proto.GetUploadPartUrlsRequest) (*proto.GetUploadPartUrlsResponse, error) {
+	if req.UserId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if req.FileId == "" {
+		return nil, fmt.Errorf("file_id is required")
+	}
+
+	urls, err := h.fileService.GetPartUploadURLs(ctx, req.UserId, req.FileId, int(req.FirstPart), int(req.Count))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate part upload URLs: %w", err)
+	}
+
+	parts := make([]*proto.UploadPartUrl, 0, len(urls))
+	for _, url := range urls {
+		parts = append(parts, &proto.UploadPartUrl{
+			PartNumber: int32(url.PartNumber),
+			UploadUrl:  url.UploadURL,
+		})
+	}
+
+	return &proto.GetUploadPartUrlsResponse{Parts: parts}, nil
+}
+
+func (h *FilesHandler) CompleteMultipartUpload(ctx context.Context, req *proto.CompleteMultipartUploadRequest) (*proto.CompleteMultipartUploadResponse, error) {
+	if req.UserId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if req.FileId == "" {
+		return nil, fmt.Errorf("file_id is required")
+	}
+
+	parts := make([]domain.UploadPart, 0, len(req.Parts))
+	for _, part := range req.Parts {
+		parts = append(parts, domain.UploadPart{
+			PartNumber: int(part.PartNumber),
+			ETag:       part.Etag,
+		})
+	}
+
+	if err := h.fileService.CompleteMultipartUpload(ctx, req.UserId, req.FileId, parts); err != nil {
+		return nil, fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	return &proto.CompleteMultipartUploadResponse{}, nil
+}
+
+func (h *FilesHandler) AbortMultipartUpload(ctx context.Context, req *proto.AbortMultipartUploadRequest) (*proto.AbortMultipartUploadResponse, error) {
+	if req.UserId == "" {
+		return nil, fmt.Errorf("user_id is required")
+	}
+	if req.FileId == "" {
+		return nil, fmt.Errorf("file_id is required")
+	}
+
+	if err := h.fileService.AbortMultipartUpload(ctx, req.UserId, req.FileId); err != nil {
+		return nil, fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	return &proto.AbortMultipartUploadResponse{}, nil
+}
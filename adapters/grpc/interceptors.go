@@ -2,6 +2,10 @@ package grpc
 
 import (
 	"context"
+	"errors"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -30,6 +34,42 @@ func AuthInterceptor(secret string) grpc.UnaryServerInterceptor {
 			return nil, status.Error(codes.Unauthenticated, "invalid internal token")
 		}
 
-		return handler(ctx, req)
+		tenantID := domain.DefaultTenantID
+		if values := md.Get("x-tenant-id"); len(values) > 0 && values[0] != "" {
+			tenantID = values[0]
+		}
+		if err := domain.ValidateTenantID(tenantID); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid tenant id")
+		}
+
+		return handler(tenant.WithCtx(ctx, tenantID), req)
+	}
+}
+
+// ErrorInterceptor gives the domain errors that callers act on a status code
+// of their own. Other errors are returned as they are.
+func ErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		return resp, nil
+	}
+}
+
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, domain.ErrContentTypeNotAllowed), errors.Is(err, domain.ErrContentMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return err
 	}
 }
@@ -0,0 +1,205 @@
+package http
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	fhirVersion          = "4.0.1"
+	fhirPathSegment      = "/fhir/"
+	restfulSecurityCodes = "http://terminology.hl7.org/CodeSystem/restful-security-service"
+	oauthURIsExtension   = "http://fhir-registry.smarthealthit.org/StructureDefinition/oauth-uris"
+)
+
+type capabilityStatement struct {
+	ResourceType string             `json:"resourceType"`
+	Status       string             `json:"status"`
+	Date         string             `json:"date"`
+	Kind         string             `json:"kind"`
+	Software     capabilitySoftware `json:"software"`
+	FHIRVersion  string             `json:"fhirVersion"`
+	Format       []string           `json:"format"`
+	Rest         []capabilityRest   `json:"rest"`
+}
+
+type capabilitySoftware struct {
+	Name string `json:"name"`
+}
+
+type capabilityRest struct {
+	Mode     string               `json:"mode"`
+	Security *capabilitySecurity  `json:"security,omitempty"`
+	Resource []capabilityResource `json:"resource"`
+}
+
+type capabilitySecurity struct {
+	Extension []extension       `json:"extension,omitempty"`
+	Service   []codeableConcept `json:"service"`
+}
+
+type capabilityResource struct {
+	Type        string                  `json:"type"`
+	Interaction []capabilityInteraction `json:"interaction"`
+}
+
+type capabilityInteraction struct {
+	Code string `json:"code"`
+}
+
+type extension struct {
+	URL       string      `json:"url"`
+	ValueURI  string      `json:"valueUri,omitempty"`
+	Extension []extension `json:"extension,omitempty"`
+}
+
+type codeableConcept struct {
+	Coding []coding `json:"coding"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type coding struct {
+	System string `json:"system"`
+	Code   string `json:"code"`
+}
+
+var (
+	typeInteractions = map[string]string{
+		http.MethodPost: "create",
+		http.MethodGet:  "search-type",
+	}
+	instanceInteractions = map[string]string{
+		http.MethodGet:    "read",
+		http.MethodPut:    "update",
+		http.MethodPatch:  "patch",
+		http.MethodDelete: "delete",
+	}
+	interactionOrder = []string{"read", "update", "patch", "delete", "create", "search-type"}
+)
+
+func (h *Handler) GetCapabilityStatement(w http.ResponseWriter, r *http.Request) {
+	resources, err := h.collectFHIRResources()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, fhirJSONMediaType, http.StatusOK, capabilityStatement{
+		ResourceType: "CapabilityStatement",
+		Status:       "active",
+		Date:         h.startedAt.UTC().Format(time.RFC3339),
+		Kind:         "instance",
+		Software:     capabilitySoftware{Name: "codex-files"},
+		FHIRVersion:  fhirVersion,
+		Format:       []string{"json", fhirJSONMediaType},
+		Rest: []capabilityRest{
+			{
+				Mode:     "server",
+				Security: h.capabilitySecurity(),
+				Resource: resources,
+			},
+		},
+	})
+}
+
+func (h *Handler) collectFHIRResources() ([]capabilityResource, error) {
+	interactions := make(map[string][]string)
+	var resourceTypes []string
+
+	err := h.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		idx := strings.Index(template, fhirPathSegment)
+		if idx < 0 {
+			return nil
+		}
+		segments := strings.Split(template[idx+len(fhirPathSegment):], "/")
+		resourceType := segments[0]
+		if resourceType == "" || resourceType[0] < 'A' || resourceType[0] > 'Z' {
+			return nil
+		}
+
+		var byMethod map[string]string
+		switch {
+		case len(segments) == 1:
+			byMethod = typeInteractions
+		case len(segments) == 2 && strings.HasPrefix(segments[1], "{"):
+			byMethod = instanceInteractions
+		default:
+			return nil
+		}
+
+		if _, ok := interactions[resourceType]; !ok {
+			resourceTypes = append(resourceTypes, resourceType)
+		}
+		for _, method := range methods {
+			if code, ok := byMethod[method]; ok && !slices.Contains(interactions[resourceType], code) {
+				interactions[resourceType] = append(interactions[resourceType], code)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Sort(resourceTypes)
+	resources := make([]capabilityResource, 0, len(resourceTypes))
+	for _, resourceType := range resourceTypes {
+		codes := interactions[resourceType]
+		slices.SortFunc(codes, func(a, b string) int {
+			return slices.Index(interactionOrder, a) - slices.Index(interactionOrder, b)
+		})
+
+		resource := capabilityResource{Type: resourceType}
+		for _, code := range codes {
+			resource.Interaction = append(resource.Interaction, capabilityInteraction{Code: code})
+		}
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+func (h *Handler) capabilitySecurity() *capabilitySecurity {
+	smart := h.cfg.Auth.SMART
+	if smart.AuthorizeURL == "" || smart.TokenURL == "" {
+		return &capabilitySecurity{
+			Service: []codeableConcept{
+				{
+					Coding: []coding{{System: restfulSecurityCodes, Code: "OAuth"}},
+					Text:   "Bearer JWT",
+				},
+			},
+		}
+	}
+
+	uris := []extension{
+		{URL: "authorize", ValueURI: smart.AuthorizeURL},
+		{URL: "token", ValueURI: smart.TokenURL},
+	}
+	if smart.RevokeURL != "" {
+		uris = append(uris, extension{URL: "revoke", ValueURI: smart.RevokeURL})
+	}
+
+	return &capabilitySecurity{
+		Extension: []extension{{URL: oauthURIsExtension, Extension: uris}},
+		Service: []codeableConcept{
+			{
+				Coding: []coding{{System: restfulSecurityCodes, Code: "SMART-on-FHIR"}},
+				Text:   "OAuth2 using SMART-on-FHIR profile",
+			},
+		},
+	}
+}
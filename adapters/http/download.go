@@ -0,0 +1,128 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+)
+
+func (h *Handler) serveBinary(w http.ResponseWriter, r *http.Request, fileID string, preferResource bool) {
+	file, content, err := h.fileService.OpenFile(r.Context(), fileID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	offers := []string{file.ContentType, fhirJSONMediaType, jsonMediaType}
+	if preferResource {
+		offers = []string{fhirJSONMediaType, jsonMediaType, file.ContentType}
+	}
+
+	negotiated := negotiate(r, offers...)
+	w.Header().Add("Vary", "Accept")
+	switch {
+	case negotiated == "":
+		writeError(w, r, errNotAcceptable)
+	case negotiated == file.ContentType && !(preferResource && isFHIRJSON(negotiated)):
+		writeRawContent(w, r, file, content)
+	default:
+		// The envelope is a different representation of the same content,
+		// so it only gets a weak validator and no byte ranges.
+		w.Header().Set("ETag", "W/"+fileETag(file))
+		w.Header().Set("Last-Modified", file.UpdatedAt.UTC().Format(http.TimeFormat))
+		if notModified(r, "W/"+fileETag(file), file.UpdatedAt) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if err := writeBinaryResourceStream(w, r, file, content); err != nil {
+			log.Printf("failed to stream Binary resource for file %s: %v", file.ID, err)
+		}
+	}
+}
+
+// writeRawContent leaves Range, If-Range, If-None-Match, If-Modified-Since
+// and HEAD handling to http.ServeContent, which seeks within the object
+// instead of reading it from the start.
+func writeRawContent(w http.ResponseWriter, r *http.Request, file *domain.File, content io.ReadSeeker) {
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("ETag", fileETag(file))
+	http.ServeContent(w, r, "", file.UpdatedAt, content)
+}
+
+// fileETag prefers the content checksum and falls back to the last update
+// time, which changes whenever the content is replaced.
+func fileETag(file *domain.File) string {
+	if len(file.Checksum.Value) > 0 {
+		return fmt.Sprintf(`"%s-%s"`, file.Checksum.Algorithm, hex.EncodeToString(file.Checksum.Value))
+	}
+	return fmt.Sprintf(`"%s-%x"`, file.ID, file.UpdatedAt.UnixNano())
+}
+
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// writeBinaryResourceStream encodes the content into the envelope as it is
+// read. A HEAD request gets the headers only, without the object being read.
+func writeBinaryResourceStream(w http.ResponseWriter, r *http.Request, file *domain.File, content io.Reader) error {
+	envelope, err := json.Marshal(newBinaryResource(file))
+	if err != nil {
+		return fmt.Errorf("failed to encode Binary resource: %w", err)
+	}
+
+	w.Header().Set("Content-Type", fhirJSONMediaType)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return nil
+	}
+
+	if _, err := w.Write(envelope[:len(envelope)-1]); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, `,"data":"`); err != nil {
+		return err
+	}
+
+	encoder := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(encoder, content); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\"}\n")
+	return err
+}
+
+func isFHIRJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == fhirJSONMediaType || mediaType == jsonMediaType
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+)
+
+const (
+	problemJSONMediaType = "application/problem+json"
+	textMediaType        = "text/plain"
+)
+
+var (
+	errNotAcceptable        = errors.New("requested media type is not available")
+	errMethodNotAllowed     = errors.New("method not allowed")
+	errPayloadTooLarge      = errors.New("request body is too large")
+	errUnsupportedMediaType = errors.New("unsupported media type")
+	errUnsupportedVersion   = errors.New("unsupported protocol version")
+)
+
+type operationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []operationOutcomeIssue `json:"issue"`
+}
+
+type operationOutcomeIssue struct {
+	Severity    string `json:"severity"`
+	Code        string `json:"code"`
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
+
+type problemDetails struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code,omitempty"`
+}
+
+type errorMapping struct {
+	status int
+	code   string
+}
+
+func mapError(err error) errorMapping {
+	switch {
+	case errors.Is(err, domain.ErrFileNotFound), errors.Is(err, domain.ErrGrantNotFound), errors.Is(err, domain.ErrShareNotFound), errors.Is(err, domain.ErrThumbnailNotFound):
+		return errorMapping{status: http.StatusNotFound, code: "not-found"}
+	case errors.Is(err, domain.ErrFileIDRequired):
+		return errorMapping{status: http.StatusBadRequest, code: "required"}
+	case errors.Is(err, domain.ErrUnauthenticated):
+		return errorMapping{status: http.StatusUnauthorized, code: "login"}
+	case errors.Is(err, domain.ErrAccessDenied):
+		return errorMapping{status: http.StatusForbidden, code: "forbidden"}
+	case errors.Is(err, domain.ErrInvalidInput):
+		return errorMapping{status: http.StatusBadRequest, code: "invalid"}
+	case errors.Is(err, domain.ErrChecksumMismatch):
+		return errorMapping{status: http.StatusBadRequest, code: "invalid"}
+	case errors.Is(err, domain.ErrUploadConflict):
+		return errorMapping{status: http.StatusConflict, code: "conflict"}
+	case errors.Is(err, domain.ErrUploadExpired), errors.Is(err, domain.ErrRestoreExpired), errors.Is(err, domain.ErrShareExpired):
+		return errorMapping{status: http.StatusGone, code: "expired"}
+	case errors.Is(err, errNotAcceptable):
+		return errorMapping{status: http.StatusNotAcceptable, code: "not-supported"}
+	case errors.Is(err, errMethodNotAllowed):
+		return errorMapping{status: http.StatusMethodNotAllowed, code: "not-supported"}
+	case errors.Is(err, errPayloadTooLarge), errors.Is(err, domain.ErrFileTooLarge):
+		return errorMapping{status: http.StatusRequestEntityTooLarge, code: "too-costly"}
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		return errorMapping{status: http.StatusInsufficientStorage, code: "too-costly"}
+	case errors.Is(err, errUnsupportedMediaType), errors.Is(err, domain.ErrContentTypeNotAllowed), errors.Is(err, domain.ErrContentMismatch):
+		return errorMapping{status: http.StatusUnsupportedMediaType, code: "not-supported"}
+	case errors.Is(err, errUnsupportedVersion):
+		return errorMapping{status: http.StatusPreconditionFailed, code: "not-supported"}
+	default:
+		return errorMapping{status: http.StatusInternalServerError, code: "exception"}
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	writeMappedError(w, r, err, mapError(err))
+}
+
+// writeErrorStatus writes err like writeError, but with a status of the
+// protocol in use rather than the one mapError picks.
+func writeErrorStatus(w http.ResponseWriter, r *http.Request, err error, status int) {
+	mapping := mapError(err)
+	mapping.status = status
+	writeMappedError(w, r, err, mapping)
+}
+
+func writeMappedError(w http.ResponseWriter, r *http.Request, err error, mapping errorMapping) {
+	diagnostics := err.Error()
+	if mapping.status == http.StatusInternalServerError {
+		log.Printf("request %s %s failed: %v", r.Method, r.URL.Path, err)
+		diagnostics = domain.ErrInternal.Error()
+	}
+
+	if mapping.status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+
+	switch negotiate(r, fhirJSONMediaType, jsonMediaType, problemJSONMediaType, textMediaType) {
+	case problemJSONMediaType:
+		writeJSON(w, problemJSONMediaType, mapping.status, problemDetails{
+			Type:   "about:blank",
+			Title:  http.StatusText(mapping.status),
+			Status: mapping.status,
+			Detail: diagnostics,
+			Code:   mapping.code,
+		})
+	case textMediaType:
+		http.Error(w, diagnostics, mapping.status)
+	default:
+		writeJSON(w, fhirJSONMediaType, mapping.status, operationOutcome{
+			ResourceType: "OperationOutcome",
+			Issue: []operationOutcomeIssue{
+				{
+					Severity:    "error",
+					Code:        mapping.code,
+					Diagnostics: diagnostics,
+				},
+			},
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, contentType string, status int, body any) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("failed to encode response body: %v", err)
+	}
+}
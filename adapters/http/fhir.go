@@ -0,0 +1,184 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	fhirJSONMediaType     = "application/fhir+json"
+	jsonMediaType         = "application/json"
+	binaryResourceType    = "Binary"
+	securityContextHeader = "X-Security-Context"
+	fhirEnvelopeOverhead  = 64 * 1024
+)
+
+type binaryResource struct {
+	ResourceType    string             `json:"resourceType"`
+	ID              string             `json:"id,omitempty"`
+	Meta            *resourceMeta      `json:"meta,omitempty"`
+	ContentType     string             `json:"contentType"`
+	SecurityContext *resourceReference `json:"securityContext,omitempty"`
+	Data            string             `json:"data,omitempty"`
+}
+
+type resourceMeta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+type resourceReference struct {
+	Reference string `json:"reference,omitempty"`
+}
+
+type binaryContent struct {
+	contentType     string
+	securityContext string
+	data            []byte
+}
+
+func (h *Handler) CreateBinary(w http.ResponseWriter, r *http.Request) {
+	content, err := h.readBinaryContent(w, r, "")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	file, err := h.fileService.CreateFile(
+		r.Context(),
+		content.contentType,
+		content.securityContext,
+		bytes.NewReader(content.data),
+		int64(len(content.data)),
+	)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+file.ID)
+	writeJSON(w, fhirJSONMediaType, http.StatusCreated, newBinaryResource(file))
+}
+
+func (h *Handler) ReadBinary(w http.ResponseWriter, r *http.Request) {
+	h.serveBinary(w, r, mux.Vars(r)["file_id"], true)
+}
+
+func (h *Handler) UpdateBinary(w http.ResponseWriter, r *http.Request) {
+	fileID := mux.Vars(r)["file_id"]
+
+	content, err := h.readBinaryContent(w, r, fileID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	file, err := h.fileService.UpdateFile(
+		r.Context(),
+		fileID,
+		content.contentType,
+		content.securityContext,
+		bytes.NewReader(content.data),
+		int64(len(content.data)),
+	)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, fhirJSONMediaType, http.StatusOK, newBinaryResource(file))
+}
+
+func (h *Handler) DeleteBinary(w http.ResponseWriter, r *http.Request) {
+	fileID := mux.Vars(r)["file_id"]
+
+	if err := h.fileService.RemoveFile(r.Context(), fileID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) readBinaryContent(w http.ResponseWriter, r *http.Request, fileID string) (*binaryContent, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid Content-Type header", domain.ErrInvalidInput)
+	}
+
+	if mediaType != fhirJSONMediaType && mediaType != jsonMediaType {
+		body := http.MaxBytesReader(w, r.Body, h.cfg.Upload.MaxSize)
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, bodyReadError(err)
+		}
+		return &binaryContent{
+			contentType:     mediaType,
+			securityContext: r.Header.Get(securityContextHeader),
+			data:            data,
+		}, nil
+	}
+
+	limit := int64(base64.StdEncoding.EncodedLen(int(h.cfg.Upload.MaxSize))) + fhirEnvelopeOverhead
+	body := http.MaxBytesReader(w, r.Body, limit)
+
+	var resource binaryResource
+	if err := json.NewDecoder(body).Decode(&resource); err != nil {
+		return nil, bodyReadError(err)
+	}
+	if resource.ResourceType != binaryResourceType {
+		return nil, fmt.Errorf("%w: resourceType must be %s", domain.ErrInvalidInput, binaryResourceType)
+	}
+	if fileID != "" && resource.ID != "" && resource.ID != fileID {
+		return nil, fmt.Errorf("%w: resource id does not match the request URL", domain.ErrInvalidInput)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(resource.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: data must be base64 encoded", domain.ErrInvalidInput)
+	}
+
+	content := &binaryContent{
+		contentType: resource.ContentType,
+		data:        data,
+	}
+	if resource.SecurityContext != nil {
+		content.securityContext = resource.SecurityContext.Reference
+	}
+
+	return content, nil
+}
+
+func newBinaryResource(file *domain.File) *binaryResource {
+	resource := &binaryResource{
+		ResourceType: binaryResourceType,
+		ID:           file.ID,
+		Meta: &resourceMeta{
+			LastUpdated: file.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		},
+		ContentType: file.ContentType,
+	}
+	if file.SecurityContext != "" {
+		resource.SecurityContext = &resourceReference{Reference: file.SecurityContext}
+	}
+	return resource
+}
+
+func bodyReadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return errPayloadTooLarge
+	}
+	return fmt.Errorf("%w: failed to read request body: %v", domain.ErrInvalidInput, err)
+}
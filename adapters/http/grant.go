@@ -0,0 +1,105 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+
+	"github.com/gorilla/mux"
+)
+
+type grantAccessRequest struct {
+	GranteeType string     `json:"granteeType"`
+	GranteeID   string     `json:"granteeId"`
+	Permission  string     `json:"permission"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+type grantResponse struct {
+	GranteeType string     `json:"granteeType"`
+	GranteeID   string     `json:"granteeId"`
+	Permission  string     `json:"permission"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	GrantedBy   string     `json:"grantedBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+type listGrantsResponse struct {
+	FileID string          `json:"fileId"`
+	Grants []grantResponse `json:"grants"`
+}
+
+func (h *Handler) GrantAccess(w http.ResponseWriter, r *http.Request) {
+	var req grantAccessRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, fmt.Errorf("%w: malformed request body", domain.ErrInvalidInput))
+		return
+	}
+
+	grant := domain.FileGrant{
+		FileID:     mux.Vars(r)["file_id"],
+		Grantee:    domain.Grantee{Type: domain.GranteeType(req.GranteeType), ID: req.GranteeID},
+		Permission: domain.Permission(req.Permission),
+	}
+	if req.ExpiresAt != nil {
+		grant.ExpiresAt = *req.ExpiresAt
+	}
+
+	created, err := h.fileService.GrantAccess(r.Context(), grant)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, jsonMediaType, http.StatusCreated, newGrantResponse(created))
+}
+
+func (h *Handler) ListGrants(w http.ResponseWriter, r *http.Request) {
+	fileID := mux.Vars(r)["file_id"]
+
+	grants, err := h.fileService.ListGrants(r.Context(), fileID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := listGrantsResponse{FileID: fileID, Grants: make([]grantResponse, 0, len(grants))}
+	for _, grant := range grants {
+		resp.Grants = append(resp.Grants, newGrantResponse(grant))
+	}
+
+	writeJSON(w, jsonMediaType, http.StatusOK, resp)
+}
+
+// RevokeAccess removes one permission when ?permission= is given and every
+// grant the grantee holds on the file otherwise.
+func (h *Handler) RevokeAccess(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	grantee := domain.Grantee{Type: domain.GranteeType(vars["grantee_type"]), ID: vars["grantee_id"]}
+	permission := domain.Permission(r.URL.Query().Get("permission"))
+
+	if err := h.fileService.RevokeAccess(r.Context(), vars["file_id"], grantee, permission); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newGrantResponse(grant *domain.FileGrant) grantResponse {
+	resp := grantResponse{
+		GranteeType: string(grant.Grantee.Type),
+		GranteeID:   grant.Grantee.ID,
+		Permission:  string(grant.Permission),
+		GrantedBy:   grant.GrantedBy,
+		CreatedAt:   grant.CreatedAt,
+	}
+	if !grant.ExpiresAt.IsZero() {
+		expiresAt := grant.ExpiresAt
+		resp.ExpiresAt = &expiresAt
+	}
+	return resp
+}
@@ -6,56 +6,107 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/gruzdev-dev/codex-files/configs"
 	"github.com/gruzdev-dev/codex-files/core/domain"
 	"github.com/gruzdev-dev/codex-files/core/services"
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
 
 	"github.com/gorilla/mux"
 	"github.com/minio/minio-go/v7/pkg/notification"
 )
 
 type Handler struct {
-	cfg         *configs.Config
-	fileService *services.FileService
+	cfg              *configs.Config
+	fileService      *services.FileService
+	resumableService *services.ResumableUploadService
+	shareService     *services.ShareLinkService
+	thumbnailService *services.ThumbnailService
+	router           *mux.Router
+	startedAt        time.Time
 }
 
-func NewHandler(cfg *configs.Config, fileService *services.FileService) *Handler {
+func NewHandler(
+	cfg *configs.Config,
+	fileService *services.FileService,
+	resumableService *services.ResumableUploadService,
+	shareService *services.ShareLinkService,
+	thumbnailService *services.ThumbnailService,
+) *Handler {
 	return &Handler{
-		cfg:         cfg,
-		fileService: fileService,
+		cfg:              cfg,
+		fileService:      fileService,
+		resumableService: resumableService,
+		shareService:     shareService,
+		thumbnailService: thumbnailService,
+		startedAt:        time.Now(),
 	}
 }
 
 func (h *Handler) RegisterRoutes(api *mux.Router) {
+	h.router = api
+
 	webhookAuthMiddleware := NewWebhookAuthMiddleware(h.cfg.S3.WebhookSecret)
 	webhookHandler := webhookAuthMiddleware.Handler(http.HandlerFunc(h.HandleS3Webhook))
 	api.Handle("/webhook/s3", webhookHandler).Methods("POST")
 
 	authMiddleware := NewAuthMiddleware(h.cfg.Auth.JWTSecret)
 	downloadHandler := authMiddleware.Handler(http.HandlerFunc(h.GetDownloadURL))
-	api.Handle("/files/{file_id}/download", downloadHandler).Methods("GET")
+	api.Handle("/files/{file_id}/download", downloadHandler).Methods("GET", "HEAD")
+	api.Handle("/files/{file_id}/thumbnail", authMiddleware.Handler(http.HandlerFunc(h.GetThumbnail))).Methods("GET", "HEAD")
+
+	api.Handle("/files", authMiddleware.Handler(http.HandlerFunc(h.UploadFile))).Methods("PUT", "POST")
+	api.Handle("/usage", authMiddleware.Handler(http.HandlerFunc(h.GetUsage))).Methods("GET")
+	api.Handle("/files/{file_id}/restore", authMiddleware.Handler(http.HandlerFunc(h.RestoreFile))).Methods("POST")
+	api.Handle("/files/{file_id}/grants", authMiddleware.Handler(http.HandlerFunc(h.ListGrants))).Methods("GET")
+	api.Handle("/files/{file_id}/grants", authMiddleware.Handler(http.HandlerFunc(h.GrantAccess))).Methods("POST")
+	api.Handle("/files/{file_id}/grants/{grantee_type}/{grantee_id}", authMiddleware.Handler(http.HandlerFunc(h.RevokeAccess))).Methods("DELETE")
+	api.Handle("/files/{file_id}/shares", authMiddleware.Handler(http.HandlerFunc(h.ListShareLinks))).Methods("GET")
+	api.Handle("/files/{file_id}/shares", authMiddleware.Handler(http.HandlerFunc(h.CreateShareLink))).Methods("POST")
+	api.Handle("/files/{file_id}/shares/{share_id}", authMiddleware.Handler(http.HandlerFunc(h.RevokeShareLink))).Methods("DELETE")
+
+	// Share links are used by people without an account, so the route is
+	// public. GET only: every request counts as a download.
+	api.HandleFunc("/s/{token}", h.DownloadShareLink).Methods("GET")
+
+	api.Handle("/files/multipart", authMiddleware.Handler(http.HandlerFunc(h.InitiateMultipartUpload))).Methods("POST")
+	api.Handle("/files/{file_id}/multipart/parts", authMiddleware.Handler(http.HandlerFunc(h.GetUploadPartURLs))).Methods("GET")
+	api.Handle("/files/{file_id}/multipart/complete", authMiddleware.Handler(http.HandlerFunc(h.CompleteMultipartUpload))).Methods("POST")
+	api.Handle("/files/{file_id}/multipart", authMiddleware.Handler(http.HandlerFunc(h.AbortMultipartUpload))).Methods("DELETE")
+
+	tus := api.PathPrefix("/tus").Subrouter()
+	tus.Use(TusMiddleware)
+	tus.HandleFunc("", h.TusOptions).Methods("OPTIONS")
+	tus.HandleFunc("/{file_id}", h.TusOptions).Methods("OPTIONS")
+	tus.Handle("", authMiddleware.Handler(http.HandlerFunc(h.CreateTusUpload))).Methods("POST")
+	tus.Handle("/{file_id}", authMiddleware.Handler(http.HandlerFunc(h.HeadTusUpload))).Methods("HEAD")
+	tus.Handle("/{file_id}", authMiddleware.Handler(http.HandlerFunc(h.PatchTusUpload))).Methods("PATCH")
+	tus.Handle("/{file_id}", authMiddleware.Handler(http.HandlerFunc(h.DeleteTusUpload))).Methods("DELETE")
+
+	api.HandleFunc("/fhir/metadata", h.GetCapabilityStatement).Methods("GET")
+	api.Handle("/fhir/Binary", authMiddleware.Handler(http.HandlerFunc(h.CreateBinary))).Methods("POST")
+	api.Handle("/fhir/Binary/{file_id}", authMiddleware.Handler(http.HandlerFunc(h.ReadBinary))).Methods("GET", "HEAD")
+	api.Handle("/fhir/Binary/{file_id}", authMiddleware.Handler(http.HandlerFunc(h.UpdateBinary))).Methods("PUT")
+	api.Handle("/fhir/Binary/{file_id}", authMiddleware.Handler(http.HandlerFunc(h.DeleteBinary))).Methods("DELETE")
 }
 
 func (h *Handler) GetDownloadURL(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	fileID := vars["file_id"]
 	if fileID == "" {
-		http.Error(w, "file_id is required", http.StatusBadRequest)
+		writeError(w, r, domain.ErrFileIDRequired)
+		return
+	}
+
+	if h.cfg.Download.Mode != configs.DownloadModeRedirect {
+		h.serveBinary(w, r, fileID, false)
 		return
 	}
 
 	result, err := h.fileService.GetDownloadURL(r.Context(), fileID)
 	if err != nil {
-		if err == domain.ErrFileNotFound || err == domain.ErrFileIDRequired {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		if err == domain.ErrAccessDenied {
-			http.Error(w, err.Error(), http.StatusForbidden)
-			return
-		}
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -64,7 +115,7 @@ func (h *Handler) GetDownloadURL(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) HandleS3Webhook(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, r, errMethodNotAllowed)
 		return
 	}
 
@@ -75,6 +126,7 @@ func (h *Handler) HandleS3Webhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	queued := true
 	for _, record := range info.Records {
 		if !strings.HasPrefix(record.EventName, "s3:ObjectCreated:") {
 			continue
@@ -90,6 +142,11 @@ func (h *Handler) HandleS3Webhook(w http.ResponseWriter, r *http.Request) {
 			decodedKey = key
 		}
 
+		// Thumbnails and kept originals are written by the service itself.
+		if _, ok := domain.ParentObjectKey(decodedKey); ok {
+			continue
+		}
+
 		parts := strings.Split(decodedKey, "/")
 		if len(parts) < 2 {
 			log.Printf("invalid s3 key format: %s", decodedKey)
@@ -97,10 +154,21 @@ func (h *Handler) HandleS3Webhook(w http.ResponseWriter, r *http.Request) {
 		}
 		fileID := parts[len(parts)-1]
 
-		if err := h.fileService.ConfirmUpload(r.Context(), fileID); err != nil {
-			log.Printf("failed to confirm upload for file %s: %v", fileID, err)
+		// Storage events are not tied to a tenant; the file ID alone finds
+		// the row, and the upload is confirmed within the file's tenant by
+		// a job worker.
+		ctx := tenant.Unscoped(r.Context())
+		if err := h.fileService.QueueConfirmUpload(ctx, fileID, record.S3.Object.ETag); err != nil {
+			log.Printf("failed to queue upload confirmation for file %s: %v", fileID, err)
+			queued = false
 		}
 	}
 
+	// An event that could not be queued is refused so that the object store
+	// delivers it again; confirming the same upload twice is harmless.
+	if !queued {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
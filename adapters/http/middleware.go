@@ -1,11 +1,13 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gruzdev-dev/codex-files/core/domain"
 	"github.com/gruzdev-dev/codex-files/pkg/identity"
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -38,16 +40,29 @@ func (m *AuthMiddleware) Handler(next http.Handler) http.Handler {
 		})
 
 		if err != nil || !token.Valid {
-			w.WriteHeader(http.StatusUnauthorized)
+			writeError(w, r, fmt.Errorf("%w: invalid bearer token", domain.ErrUnauthenticated))
+			return
+		}
+
+		tenantID := getClaim(claims, "tenant")
+		if tenantID == "" {
+			tenantID = domain.DefaultTenantID
+		}
+		if err := domain.ValidateTenantID(tenantID); err != nil {
+			writeError(w, r, fmt.Errorf("%w: invalid tenant claim", domain.ErrUnauthenticated))
 			return
 		}
 
 		id := domain.Identity{
-			UserID: getClaim(claims, "sub"),
-			Scopes: parseScopes(claims["scopes"]),
+			UserID:      getClaim(claims, "sub"),
+			Groups:      parseScopes(claims["groups"]),
+			Scopes:      append(parseScopes(claims["scopes"]), parseScopes(claims["scope"])...),
+			FHIRContext: parseFHIRContext(claims["fhirContext"]),
+			Patient:     getClaim(claims, "patient"),
 		}
 
 		ctx := identity.WithCtx(r.Context(), id)
+		ctx = tenant.WithCtx(ctx, tenantID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -71,6 +86,26 @@ func parseScopes(raw any) []string {
 	return nil
 }
 
+func parseFHIRContext(raw any) []string {
+	items, ok := raw.([]any)
+	if !ok {
+		return nil
+	}
+
+	var references []string
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			references = append(references, v)
+		case map[string]any:
+			if reference, ok := v["reference"].(string); ok && reference != "" {
+				references = append(references, reference)
+			}
+		}
+	}
+	return references
+}
+
 type WebhookAuthMiddleware struct {
 	secret string
 }
@@ -90,7 +125,7 @@ func (m *WebhookAuthMiddleware) Handler(next http.Handler) http.Handler {
 		}
 
 		if providedSecret == "" || providedSecret != m.secret {
-			w.WriteHeader(http.StatusUnauthorized)
+			writeError(w, r, fmt.Errorf("%w: invalid webhook secret", domain.ErrUnauthenticated))
 			return
 		}
 
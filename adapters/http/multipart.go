@@ -0,0 +1,168 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/pkg/identity"
+
+	"github.com/gorilla/mux"
+)
+
+const defaultPartURLBatch = 20
+
+type initiateMultipartRequest struct {
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+}
+
+type initiateMultipartResponse struct {
+	FileID      string `json:"fileId"`
+	PartSize    int64  `json:"partSize"`
+	PartCount   int    `json:"partCount"`
+	DownloadURL string `json:"downloadUrl"`
+}
+
+type partURLResponse struct {
+	PartNumber int    `json:"partNumber"`
+	UploadURL  string `json:"uploadUrl"`
+}
+
+type completeMultipartRequest struct {
+	Parts []completedPart `json:"parts"`
+}
+
+type completedPart struct {
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"etag"`
+}
+
+func (h *Handler) InitiateMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := requestOwner(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req initiateMultipartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, fmt.Errorf("%w: malformed request body", domain.ErrInvalidInput))
+		return
+	}
+
+	result, err := h.fileService.InitiateMultipartUpload(r.Context(), ownerID, req.ContentType, req.Size)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, jsonMediaType, http.StatusCreated, initiateMultipartResponse{
+		FileID:      result.FileID,
+		PartSize:    result.PartSize,
+		PartCount:   result.PartCount,
+		DownloadURL: result.DownloadURL,
+	})
+}
+
+func (h *Handler) GetUploadPartURLs(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := requestOwner(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	firstPart, err := queryInt(r, "first", 1)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	count, err := queryInt(r, "count", defaultPartURLBatch)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	urls, err := h.fileService.GetPartUploadURLs(r.Context(), ownerID, mux.Vars(r)["file_id"], firstPart, count)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	parts := make([]partURLResponse, 0, len(urls))
+	for _, url := range urls {
+		parts = append(parts, partURLResponse{
+			PartNumber: url.PartNumber,
+			UploadURL:  url.UploadURL,
+		})
+	}
+
+	writeJSON(w, jsonMediaType, http.StatusOK, map[string]any{"parts": parts})
+}
+
+func (h *Handler) CompleteMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := requestOwner(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var req completeMultipartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, fmt.Errorf("%w: malformed request body", domain.ErrInvalidInput))
+		return
+	}
+
+	parts := make([]domain.UploadPart, 0, len(req.Parts))
+	for _, part := range req.Parts {
+		parts = append(parts, domain.UploadPart{
+			PartNumber: part.PartNumber,
+			ETag:       part.ETag,
+		})
+	}
+
+	if err := h.fileService.CompleteMultipartUpload(r.Context(), ownerID, mux.Vars(r)["file_id"], parts); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) AbortMultipartUpload(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := requestOwner(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.fileService.AbortMultipartUpload(r.Context(), ownerID, mux.Vars(r)["file_id"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func requestOwner(r *http.Request) (string, error) {
+	user, ok := identity.FromCtx(r.Context())
+	if !ok || user.UserID == "" {
+		return "", domain.ErrUnauthenticated
+	}
+	return user.UserID, nil
+}
+
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s must be an integer", domain.ErrInvalidInput, name)
+	}
+	return value, nil
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	return ranges
+}
+
+func (a acceptRange) matches(mediaType string) bool {
+	if a.mediaType == "*/*" || a.mediaType == mediaType {
+		return true
+	}
+
+	prefix, ok := strings.CutSuffix(a.mediaType, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+func negotiate(r *http.Request, offers ...string) string {
+	header := r.Header.Get("Accept")
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+
+	best := ""
+	bestQuality := 0.0
+	for _, offer := range offers {
+		if offer == "" {
+			continue
+		}
+		mediaType, _, err := mime.ParseMediaType(offer)
+		if err != nil {
+			continue
+		}
+		for _, accepted := range parseAccept(header) {
+			if accepted.matches(mediaType) {
+				if accepted.quality > bestQuality {
+					best = offer
+					bestQuality = accepted.quality
+				}
+				break
+			}
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+)
+
+type quotaUsageResponse struct {
+	Scope         string `json:"scope"`
+	TenantID      string `json:"tenantId"`
+	OwnerID       string `json:"ownerId,omitempty"`
+	MaxBytes      int64  `json:"maxBytes"`
+	MaxFiles      int64  `json:"maxFiles"`
+	Bytes         int64  `json:"bytes"`
+	Files         int64  `json:"files"`
+	ReservedBytes int64  `json:"reservedBytes"`
+	ReservedFiles int64  `json:"reservedFiles"`
+}
+
+type getUsageResponse struct {
+	Usage []quotaUsageResponse `json:"usage"`
+}
+
+// GetUsage reports the caller's quotas and how much of them is taken. A
+// zero limit is unlimited.
+func (h *Handler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := requestOwner(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	usages, err := h.fileService.GetUsage(r.Context(), ownerID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := getUsageResponse{Usage: make([]quotaUsageResponse, 0, len(usages))}
+	for _, usage := range usages {
+		resp.Usage = append(resp.Usage, newQuotaUsageResponse(usage))
+	}
+
+	writeJSON(w, jsonMediaType, http.StatusOK, resp)
+}
+
+func newQuotaUsageResponse(usage *domain.QuotaUsage) quotaUsageResponse {
+	return quotaUsageResponse{
+		Scope:         string(usage.Quota.Scope),
+		TenantID:      usage.Quota.TenantID,
+		OwnerID:       usage.Quota.OwnerID,
+		MaxBytes:      usage.Quota.MaxBytes,
+		MaxFiles:      usage.Quota.MaxFiles,
+		Bytes:         usage.Usage.Bytes,
+		Files:         usage.Usage.Files,
+		ReservedBytes: usage.Usage.ReservedBytes,
+		ReservedFiles: usage.Usage.ReservedFiles,
+	}
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type restoreFileResponse struct {
+	FileID      string `json:"fileId"`
+	Status      string `json:"status"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+	DownloadURL string `json:"downloadUrl"`
+}
+
+func (h *Handler) RestoreFile(w http.ResponseWriter, r *http.Request) {
+	file, err := h.fileService.RestoreFile(r.Context(), mux.Vars(r)["file_id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, jsonMediaType, http.StatusOK, restoreFileResponse{
+		FileID:      file.ID,
+		Status:      string(file.Status),
+		Size:        file.Size,
+		ContentType: file.ContentType,
+		DownloadURL: fmt.Sprintf("/files/%s/download", file.ID),
+	})
+}
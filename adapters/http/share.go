@@ -0,0 +1,185 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+
+	"github.com/gorilla/mux"
+)
+
+const sharePasswordHeader = "X-Share-Password"
+
+var errShareDownloadRefused = errors.New("share link download was refused")
+
+type createShareLinkRequest struct {
+	// ExpiresIn is a Go duration such as "72h"; empty uses the default.
+	ExpiresIn    string `json:"expiresIn,omitempty"`
+	MaxDownloads int    `json:"maxDownloads,omitempty"`
+	Password     string `json:"password,omitempty"`
+}
+
+type shareLinkResponse struct {
+	ID           string     `json:"id"`
+	ShareURL     string     `json:"shareUrl,omitempty"`
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	MaxDownloads int        `json:"maxDownloads,omitempty"`
+	Downloads    int        `json:"downloads"`
+	HasPassword  bool       `json:"hasPassword"`
+	CreatedBy    string     `json:"createdBy"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+}
+
+type listShareLinksResponse struct {
+	FileID string              `json:"fileId"`
+	Shares []shareLinkResponse `json:"shares"`
+}
+
+func (h *Handler) CreateShareLink(w http.ResponseWriter, r *http.Request) {
+	var req createShareLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, fmt.Errorf("%w: malformed request body", domain.ErrInvalidInput))
+		return
+	}
+
+	var ttl time.Duration
+	if req.ExpiresIn != "" {
+		parsed, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil {
+			writeError(w, r, fmt.Errorf("%w: expiresIn must be a duration", domain.ErrInvalidInput))
+			return
+		}
+		ttl = parsed
+	}
+
+	result, err := h.shareService.CreateShareLink(r.Context(), mux.Vars(r)["file_id"], ttl, req.MaxDownloads, req.Password)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// The token is only ever returned here; it is stored hashed.
+	resp := newShareLinkResponse(result.Link)
+	resp.ShareURL = result.ShareURL
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, jsonMediaType, http.StatusCreated, resp)
+}
+
+func (h *Handler) ListShareLinks(w http.ResponseWriter, r *http.Request) {
+	fileID := mux.Vars(r)["file_id"]
+
+	links, err := h.shareService.ListShareLinks(r.Context(), fileID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := listShareLinksResponse{FileID: fileID, Shares: make([]shareLinkResponse, 0, len(links))}
+	for _, link := range links {
+		resp.Shares = append(resp.Shares, newShareLinkResponse(link))
+	}
+
+	writeJSON(w, jsonMediaType, http.StatusOK, resp)
+}
+
+func (h *Handler) RevokeShareLink(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.shareService.RevokeShareLink(r.Context(), vars["file_id"], vars["share_id"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DownloadShareLink always streams the content through the service rather
+// than redirecting, because a presigned URL could be reused without counting
+// against the link.
+func (h *Handler) DownloadShareLink(w http.ResponseWriter, r *http.Request) {
+	client := domain.ShareLinkUse{
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	}
+
+	download, err := h.shareService.OpenShareLink(r.Context(), mux.Vars(r)["token"], r.Header.Get(sharePasswordHeader), client)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer download.Content.Close()
+
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	counter := &downloadCounter{
+		ResponseWriter: w,
+		r:              r,
+		consume: func() error {
+			return h.shareService.ConsumeShareLink(r.Context(), download)
+		},
+	}
+	writeRawContent(counter, r, download.File, download.Content)
+}
+
+// downloadCounter counts a share link download once http.ServeContent has
+// decided to send the whole file. Range requests, HEAD requests and
+// conditional requests answered with 304 leave the count alone, so media
+// players and download managers can resume without using up the link.
+type downloadCounter struct {
+	http.ResponseWriter
+	r           *http.Request
+	consume     func() error
+	wroteHeader bool
+	refused     bool
+}
+
+func (c *downloadCounter) WriteHeader(status int) {
+	if c.wroteHeader {
+		return
+	}
+	c.wroteHeader = true
+
+	if status == http.StatusOK && c.r.Method == http.MethodGet {
+		if err := c.consume(); err != nil {
+			c.refused = true
+			// The headers describe the content, which is not sent.
+			for _, key := range []string{"Accept-Ranges", "Content-Length", "Content-Type", "ETag", "Last-Modified"} {
+				c.Header().Del(key)
+			}
+			writeError(c.ResponseWriter, c.r, err)
+			return
+		}
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *downloadCounter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	if c.refused {
+		return 0, errShareDownloadRefused
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+func newShareLinkResponse(link *domain.ShareLink) shareLinkResponse {
+	resp := shareLinkResponse{
+		ID:           link.ID,
+		ExpiresAt:    link.ExpiresAt,
+		MaxDownloads: link.MaxDownloads,
+		Downloads:    link.Downloads,
+		HasPassword:  link.HasPassword(),
+		CreatedBy:    link.CreatedBy,
+		CreatedAt:    link.CreatedAt,
+	}
+	if !link.RevokedAt.IsZero() {
+		revokedAt := link.RevokedAt
+		resp.RevokedAt = &revokedAt
+	}
+	return resp
+}
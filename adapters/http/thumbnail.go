@@ -0,0 +1,49 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gruzdev-dev/codex-files/configs"
+	"github.com/gruzdev-dev/codex-files/core/domain"
+
+	"github.com/gorilla/mux"
+)
+
+// GetThumbnail serves a thumbnail the way the original is downloaded: through
+// the service or as a redirect to a presigned URL. Without a size the
+// smallest configured size is served.
+func (h *Handler) GetThumbnail(w http.ResponseWriter, r *http.Request) {
+	fileID := mux.Vars(r)["file_id"]
+	if fileID == "" {
+		writeError(w, r, domain.ErrFileIDRequired)
+		return
+	}
+
+	size, err := queryInt(r, "size", 0)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if h.cfg.Download.Mode == configs.DownloadModeRedirect {
+		result, err := h.thumbnailService.GetThumbnailURL(r.Context(), fileID, size)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		http.Redirect(w, r, result.DownloadURL, http.StatusFound)
+		return
+	}
+
+	thumbnail, content, err := h.thumbnailService.OpenThumbnail(r.Context(), fileID, size)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", thumbnail.ContentType)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s-%d-%x"`, thumbnail.FileID, thumbnail.Size, thumbnail.CreatedAt.UnixNano()))
+	http.ServeContent(w, r, "", thumbnail.CreatedAt, content)
+}
@@ -0,0 +1,226 @@
+package http
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	tusVersion             = "1.0.0"
+	tusExtensions          = "creation,termination,checksum,expiration"
+	tusChecksumAlgorithms  = "sha1,sha256"
+	tusOffsetMediaType     = "application/offset+octet-stream"
+	defaultTusContentType  = "application/octet-stream"
+	statusChecksumMismatch = 460
+	tusResumableHeader     = "Tus-Resumable"
+	uploadOffsetHeader     = "Upload-Offset"
+	uploadLengthHeader     = "Upload-Length"
+	uploadMetadataHeader   = "Upload-Metadata"
+	uploadExpiresHeader    = "Upload-Expires"
+	uploadChecksumHeader   = "Upload-Checksum"
+	tusMetadataFileType    = "filetype"
+	tusMetadataContentType = "contentType"
+)
+
+// TusMiddleware enforces the Tus-Resumable protocol version on every request
+// except OPTIONS, which clients use to discover it.
+func TusMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(tusResumableHeader, tusVersion)
+
+		if r.Method != http.MethodOptions && r.Header.Get(tusResumableHeader) != tusVersion {
+			w.Header().Set("Tus-Version", tusVersion)
+			writeError(w, r, errUnsupportedVersion)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *Handler) TusOptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(h.cfg.Upload.MultipartMaxSize, 10))
+	w.Header().Set("Tus-Checksum-Algorithm", tusChecksumAlgorithms)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) CreateTusUpload(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := requestOwner(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get(uploadLengthHeader), 10, 64)
+	if err != nil || length < 0 {
+		writeError(w, r, fmt.Errorf("%w: %s header is required", domain.ErrInvalidInput, uploadLengthHeader))
+		return
+	}
+	if length > h.cfg.Upload.MultipartMaxSize {
+		writeError(w, r, errPayloadTooLarge)
+		return
+	}
+
+	rawMetadata := r.Header.Get(uploadMetadataHeader)
+	metadata, err := parseTusMetadata(rawMetadata)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	contentType := metadata[tusMetadataFileType]
+	if contentType == "" {
+		contentType = metadata[tusMetadataContentType]
+	}
+	if contentType == "" {
+		contentType = defaultTusContentType
+	}
+
+	upload, err := h.resumableService.CreateUpload(r.Context(), ownerID, contentType, length, rawMetadata)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+upload.FileID)
+	w.Header().Set(uploadExpiresHeader, upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) HeadTusUpload(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := requestOwner(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	upload, err := h.resumableService.GetUpload(r.Context(), ownerID, mux.Vars(r)["file_id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	w.Header().Set(uploadLengthHeader, strconv.FormatInt(upload.Length, 10))
+	if upload.Metadata != "" {
+		w.Header().Set(uploadMetadataHeader, upload.Metadata)
+	}
+	if !upload.ExpiresAt.IsZero() {
+		w.Header().Set(uploadExpiresHeader, upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) PatchTusUpload(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := requestOwner(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != tusOffsetMediaType {
+		writeError(w, r, errUnsupportedMediaType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		writeError(w, r, fmt.Errorf("%w: %s header is required", domain.ErrInvalidInput, uploadOffsetHeader))
+		return
+	}
+
+	checksum, err := parseTusChecksum(r.Header.Get(uploadChecksumHeader))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	upload, err := h.resumableService.WriteChunk(r.Context(), ownerID, mux.Vars(r)["file_id"], offset, r.Body, checksum)
+	if err != nil {
+		if errors.Is(err, domain.ErrChecksumMismatch) {
+			writeErrorStatus(w, r, err, statusChecksumMismatch)
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	if !upload.ExpiresAt.IsZero() {
+		w.Header().Set(uploadExpiresHeader, upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) DeleteTusUpload(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := requestOwner(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.resumableService.TerminateUpload(r.Context(), ownerID, mux.Vars(r)["file_id"]); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed %s value for key %q", domain.ErrInvalidInput, uploadMetadataHeader, key)
+		}
+		metadata[key] = string(value)
+	}
+
+	return metadata, nil
+}
+
+func parseTusChecksum(header string) (*domain.Checksum, error) {
+	if header == "" {
+		return nil, nil
+	}
+
+	algorithm, encoded, ok := strings.Cut(header, " ")
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed %s header", domain.ErrInvalidInput, uploadChecksumHeader)
+	}
+
+	value, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed %s header", domain.ErrInvalidInput, uploadChecksumHeader)
+	}
+
+	checksum := &domain.Checksum{
+		Algorithm: domain.ChecksumAlgorithm(algorithm),
+		Value:     value,
+	}
+	if _, err := checksum.Algorithm.New(); err != nil {
+		return nil, err
+	}
+
+	return checksum, nil
+}
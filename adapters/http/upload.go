@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+)
+
+type uploadFileResponse struct {
+	FileID            string `json:"fileId"`
+	Size              int64  `json:"size"`
+	ContentType       string `json:"contentType"`
+	ChecksumAlgorithm string `json:"checksumAlgorithm"`
+	Checksum          string `json:"checksum"`
+	DownloadURL       string `json:"downloadUrl"`
+}
+
+func (h *Handler) UploadFile(w http.ResponseWriter, r *http.Request) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(w, r, fmt.Errorf("%w: invalid Content-Type header", domain.ErrInvalidInput))
+		return
+	}
+
+	// The body is handed to the service unread; a ContentLength of -1
+	// (chunked transfer) leaves the size limit to the service.
+	file, err := h.fileService.UploadFile(
+		r.Context(),
+		mime.FormatMediaType(mediaType, params),
+		r.Header.Get(securityContextHeader),
+		r.Body,
+		r.ContentLength,
+	)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+file.ID+"/download")
+	writeJSON(w, jsonMediaType, http.StatusCreated, uploadFileResponse{
+		FileID:            file.ID,
+		Size:              file.Size,
+		ContentType:       file.ContentType,
+		ChecksumAlgorithm: string(file.Checksum.Algorithm),
+		Checksum:          hex.EncodeToString(file.Checksum.Value),
+		DownloadURL:       fmt.Sprintf("/files/%s/download", file.ID),
+	})
+}
@@ -0,0 +1,167 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+)
+
+const (
+	markerAPP1 = 0xE1
+
+	tagOrientation = 0x0112
+	typeShort      = 3
+)
+
+var exifID = []byte("Exif\x00\x00")
+
+// exifOrientation reads the Orientation tag from the first IFD of an EXIF
+// APP1 segment. It returns 1, the normal orientation, when the segment is not
+// EXIF or has no valid tag.
+func exifOrientation(segment []byte) int {
+	if !bytes.HasPrefix(segment, exifID) {
+		return 1
+	}
+	tiff := segment[len(exifID):]
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:4]) != 42 {
+		return 1
+	}
+
+	offset := int64(order.Uint32(tiff[4:8]))
+	if offset+2 > int64(len(tiff)) {
+		return 1
+	}
+	count := int64(order.Uint16(tiff[offset:]))
+	entries := tiff[offset+2:]
+	for i := range count {
+		if (i+1)*12 > int64(len(entries)) {
+			break
+		}
+		entry := entries[i*12 : (i+1)*12]
+		if order.Uint16(entry[0:2]) != tagOrientation {
+			continue
+		}
+		if order.Uint16(entry[2:4]) != typeShort || order.Uint32(entry[4:8]) != 1 {
+			return 1
+		}
+		if orientation := int(order.Uint16(entry[8:10])); orientation >= 1 && orientation <= 8 {
+			return orientation
+		}
+		return 1
+	}
+	return 1
+}
+
+// orientationSegment builds an EXIF APP1 segment that holds nothing but the
+// Orientation tag.
+func orientationSegment(orientation int) []byte {
+	segment := append([]byte{}, exifID...)
+	// Big-endian TIFF header with the first IFD right after it.
+	segment = append(segment, 'M', 'M', 0, 42, 0, 0, 0, 8)
+	segment = binary.BigEndian.AppendUint16(segment, 1)
+	segment = binary.BigEndian.AppendUint16(segment, tagOrientation)
+	segment = binary.BigEndian.AppendUint16(segment, typeShort)
+	segment = binary.BigEndian.AppendUint32(segment, 1)
+	segment = binary.BigEndian.AppendUint16(segment, uint16(orientation))
+	segment = append(segment, 0, 0)
+	// No next IFD.
+	return binary.BigEndian.AppendUint32(segment, 0)
+}
+
+// jpegOrientation reads the EXIF orientation of a JPEG image from the
+// segments before its first scan. Unreadable headers count as the normal
+// orientation and are left for the decoder to report.
+func jpegOrientation(content io.Reader) int {
+	src := bufio.NewReader(content)
+	if _, err := src.Discard(len(jpegSignature)); err != nil {
+		return 1
+	}
+
+	for {
+		marker, err := readMarker(src)
+		if err != nil || marker == markerSOS || marker == markerEOI {
+			return 1
+		}
+		if marker == markerTEM || marker >= markerRST0 && marker <= markerRST7 {
+			continue
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(src, length[:]); err != nil {
+			return 1
+		}
+		size := int(binary.BigEndian.Uint16(length[:])) - len(length)
+		if size < 0 {
+			return 1
+		}
+		if marker != markerAPP1 {
+			if _, err := src.Discard(size); err != nil {
+				return 1
+			}
+			continue
+		}
+
+		segment := make([]byte, size)
+		if _, err := io.ReadFull(src, segment); err != nil {
+			return 1
+		}
+		if orientation := exifOrientation(segment); orientation != 1 {
+			return orientation
+		}
+	}
+}
+
+// orient turns the image the way its EXIF orientation asks for, so that it
+// comes out as viewers display it.
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := range height {
+		for x := range width {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = width-1-x, y
+			case 3:
+				dx, dy = width-1-x, height-1-y
+			case 4:
+				dx, dy = x, height-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = height-1-y, x
+			case 7:
+				dx, dy = height-1-y, width-1-x
+			case 8:
+				dx, dy = y, width-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,281 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+)
+
+const (
+	markerTEM   = 0x01
+	markerRST0  = 0xD0
+	markerRST7  = 0xD7
+	markerEOI   = 0xD9
+	markerSOS   = 0xDA
+	markerAPP0  = 0xE0
+	markerAPP2  = 0xE2
+	markerAPP14 = 0xEE
+	markerAPP15 = 0xEF
+	markerCOM   = 0xFE
+)
+
+var (
+	jpegSignature = []byte{0xFF, 0xD8}
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+	iccProfileID  = []byte("ICC_PROFILE\x00")
+)
+
+// droppedPNGChunks are the ancillary chunks that carry metadata rather than
+// anything needed to render the image.
+var droppedPNGChunks = map[string]bool{
+	"eXIf": true,
+	"iTXt": true,
+	"tEXt": true,
+	"tIME": true,
+	"zTXt": true,
+}
+
+// MetadataStripper drops metadata blocks from JPEG and PNG images without
+// decoding them, so the image data itself is copied untouched.
+type MetadataStripper struct{}
+
+func NewMetadataStripper() ports.MetadataStripper {
+	return &MetadataStripper{}
+}
+
+func (s *MetadataStripper) Strip(ctx context.Context, dst io.Writer, content io.Reader) (int, error) {
+	src := bufio.NewReader(content)
+	out := bufio.NewWriter(dst)
+
+	// A short read leaves fewer bytes than asked for, which no signature
+	// matches.
+	header, _ := src.Peek(len(pngSignature))
+
+	var (
+		removed int
+		err     error
+	)
+	switch {
+	case bytes.HasPrefix(header, pngSignature):
+		removed, err = stripPNG(ctx, out, src)
+	case bytes.HasPrefix(header, jpegSignature):
+		removed, err = stripJPEG(ctx, out, src)
+	default:
+		return 0, fmt.Errorf("%w: content is not a JPEG or PNG image", domain.ErrInvalidInput)
+	}
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, fmt.Errorf("%w: image is truncated", domain.ErrInvalidInput)
+		}
+		return 0, err
+	}
+
+	if err := out.Flush(); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// stripJPEG copies the segments of a JPEG image, leaving out the application
+// segments and comments that hold metadata. Anything after the end of the
+// image, such as the preview images some cameras append, is dropped too.
+// The EXIF Orientation tag tells viewers how to turn the image for display,
+// so a rotated image keeps an EXIF segment with that tag alone.
+// Write errors are kept by out and reported when it is flushed.
+func stripJPEG(ctx context.Context, out *bufio.Writer, src *bufio.Reader) (int, error) {
+	if _, err := src.Discard(len(jpegSignature)); err != nil {
+		return 0, err
+	}
+	_, _ = out.Write(jpegSignature)
+
+	removed := 0
+	oriented := false
+	marker, err := readMarker(src)
+	for {
+		if err != nil {
+			return 0, err
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		switch {
+		case marker == markerEOI:
+			_, _ = out.Write([]byte{0xFF, markerEOI})
+			return removed + trailer(src), nil
+		case marker == markerTEM, marker >= markerRST0 && marker <= markerRST7:
+			_, _ = out.Write([]byte{0xFF, marker})
+			marker, err = readMarker(src)
+			continue
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(src, length[:]); err != nil {
+			return 0, err
+		}
+		size := int(binary.BigEndian.Uint16(length[:]))
+		if size < len(length) {
+			return 0, fmt.Errorf("%w: invalid JPEG segment length %d", domain.ErrInvalidInput, size)
+		}
+		segment := make([]byte, size-len(length))
+		if _, err := io.ReadFull(src, segment); err != nil {
+			return 0, err
+		}
+
+		drop := dropJPEGSegment(marker, segment)
+		if drop && marker == markerAPP1 && !oriented {
+			if orientation := exifOrientation(segment); orientation != 1 {
+				oriented = true
+				kept := orientationSegment(orientation)
+				if !bytes.Equal(segment, kept) {
+					removed++
+				}
+				segment, drop = kept, false
+				binary.BigEndian.PutUint16(length[:], uint16(len(segment)+len(length)))
+			}
+		}
+
+		if drop {
+			removed++
+		} else {
+			_, _ = out.Write([]byte{0xFF, marker})
+			_, _ = out.Write(length[:])
+			_, _ = out.Write(segment)
+		}
+
+		if marker == markerSOS {
+			marker, err = copyScan(out, src)
+		} else {
+			marker, err = readMarker(src)
+		}
+	}
+}
+
+// dropJPEGSegment keeps the JFIF and Adobe segments and ICC profiles, which
+// decoders need to render colors correctly. Every other application segment
+// is vendor metadata: EXIF and XMP in APP1, IPTC in APP13 and so on.
+func dropJPEGSegment(marker byte, segment []byte) bool {
+	switch {
+	case marker == markerCOM:
+		return true
+	case marker == markerAPP0, marker == markerAPP14:
+		return false
+	case marker == markerAPP2:
+		return !bytes.HasPrefix(segment, iccProfileID)
+	default:
+		return marker > markerAPP0 && marker <= markerAPP15
+	}
+}
+
+// readMarker reads the next marker, skipping the fill bytes that may precede
+// it.
+func readMarker(src *bufio.Reader) (byte, error) {
+	b, err := src.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xFF {
+		return 0, fmt.Errorf("%w: expected a JPEG marker", domain.ErrInvalidInput)
+	}
+
+	for b == 0xFF {
+		if b, err = src.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	if b == 0x00 {
+		return 0, fmt.Errorf("%w: expected a JPEG marker", domain.ErrInvalidInput)
+	}
+	return b, nil
+}
+
+// copyScan copies entropy-coded data up to the next marker and returns that
+// marker. Stuffed zero bytes and restart markers belong to the data.
+func copyScan(out *bufio.Writer, src *bufio.Reader) (byte, error) {
+	for {
+		chunk, err := src.ReadSlice(0xFF)
+		if err == bufio.ErrBufferFull {
+			_, _ = out.Write(chunk)
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		_, _ = out.Write(chunk[:len(chunk)-1])
+
+		next, err := src.ReadByte()
+		for err == nil && next == 0xFF {
+			next, err = src.ReadByte()
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if next == 0x00 || next >= markerRST0 && next <= markerRST7 {
+			_, _ = out.Write([]byte{0xFF, next})
+			continue
+		}
+		return next, nil
+	}
+}
+
+// trailer counts the data left after the end of the image as one more
+// dropped block, so that the image is rewritten without it.
+func trailer(src *bufio.Reader) int {
+	if _, err := src.Peek(1); err != nil {
+		return 0
+	}
+	return 1
+}
+
+// stripPNG copies the chunks of a PNG image, leaving out the text, time and
+// EXIF chunks. Each chunk carries its own CRC, so the chunks that are kept
+// stay valid. Anything after the IEND chunk is dropped.
+func stripPNG(ctx context.Context, out *bufio.Writer, src *bufio.Reader) (int, error) {
+	if _, err := src.Discard(len(pngSignature)); err != nil {
+		return 0, err
+	}
+	_, _ = out.Write(pngSignature)
+
+	removed := 0
+	var header [8]byte
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		if _, err := io.ReadFull(src, header[:]); err != nil {
+			return 0, err
+		}
+		length := binary.BigEndian.Uint32(header[:4])
+		if length > math.MaxInt32 {
+			return 0, fmt.Errorf("%w: invalid PNG chunk length %d", domain.ErrInvalidInput, length)
+		}
+		chunkType := string(header[4:])
+
+		// The chunk data is followed by a 4-byte CRC.
+		if droppedPNGChunks[chunkType] {
+			if _, err := src.Discard(int(length) + 4); err != nil {
+				return 0, err
+			}
+			removed++
+			continue
+		}
+
+		_, _ = out.Write(header[:])
+		if _, err := io.CopyN(out, src, int64(length)+4); err != nil {
+			return 0, err
+		}
+		if chunkType == "IEND" {
+			return removed + trailer(src), nil
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/gruzdev-dev/codex-files/configs"
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+)
+
+const jpegQuality = 85
+
+// Thumbnailer renders thumbnails with the standard library codecs only.
+// JPEG images get JPEG thumbnails; PNG and GIF images get PNG thumbnails,
+// which keep their transparency. Only the first frame of an animated GIF is
+// used.
+type Thumbnailer struct {
+	maxPixels int64
+}
+
+func NewThumbnailer(cfg *configs.Config) ports.Thumbnailer {
+	return &Thumbnailer{
+		maxPixels: cfg.Thumbnail.MaxPixels,
+	}
+}
+
+func (t *Thumbnailer) Thumbnail(ctx context.Context, content io.ReadSeeker, sizes []int) ([]*domain.RenderedThumbnail, error) {
+	// The header is checked before decoding, so that a small file claiming
+	// huge dimensions is refused without allocating its pixels.
+	config, format, err := image.DecodeConfig(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read image header: %v", domain.ErrInvalidInput, err)
+	}
+	if format != "jpeg" && format != "png" && format != "gif" {
+		return nil, fmt.Errorf("%w: unsupported image format %s", domain.ErrInvalidInput, format)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, fmt.Errorf("%w: image has no pixels", domain.ErrInvalidInput)
+	}
+	if t.maxPixels > 0 && int64(config.Width)*int64(config.Height) > t.maxPixels {
+		return nil, fmt.Errorf("%w: image of %dx%d pixels is too large", domain.ErrInvalidInput, config.Width, config.Height)
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind image: %w", err)
+		}
+		orientation = jpegOrientation(content)
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind image: %w", err)
+	}
+	decoded, _, err := image.Decode(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decode image: %v", domain.ErrInvalidInput, err)
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, decoded.Bounds().Dx(), decoded.Bounds().Dy()))
+	draw.Draw(src, src.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	// The JPEG decoder ignores EXIF, so phone photos come out as the sensor
+	// stored them unless they are turned here.
+	src = orient(src, orientation)
+
+	thumbnails := make([]*domain.RenderedThumbnail, 0, len(sizes))
+	for _, size := range sizes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		width, height := fit(src.Bounds().Dx(), src.Bounds().Dy(), size)
+		thumbnail, err := encode(downscale(src, width, height), format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %dpx thumbnail: %w", size, err)
+		}
+		thumbnail.Size = size
+		thumbnail.Width = width
+		thumbnail.Height = height
+		thumbnails = append(thumbnails, thumbnail)
+	}
+
+	return thumbnails, nil
+}
+
+// fit scales width and height down to fit into a size by size box, keeping
+// the aspect ratio. Images that already fit are not scaled up.
+func fit(width, height, size int) (int, int) {
+	longest := max(width, height)
+	if longest <= size {
+		return width, height
+	}
+	return max(1, (width*size+longest/2)/longest), max(1, (height*size+longest/2)/longest)
+}
+
+// downscale averages the source pixels that fall into each target pixel.
+// Averaging premultiplied colors keeps transparent pixels from darkening
+// their neighbours.
+func downscale(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+
+	for y := range height {
+		y0 := y * srcHeight / height
+		y1 := max((y+1)*srcHeight/height, y0+1)
+		for x := range width {
+			x0 := x * srcWidth / width
+			x1 := max((x+1)*srcWidth/width, x0+1)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += int(row[i])
+					g += int(row[i+1])
+					b += int(row[i+2])
+					a += int(row[i+3])
+					n++
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8((r + n/2) / n)
+			dst.Pix[offset+1] = uint8((g + n/2) / n)
+			dst.Pix[offset+2] = uint8((b + n/2) / n)
+			dst.Pix[offset+3] = uint8((a + n/2) / n)
+		}
+	}
+
+	return dst
+}
+
+func encode(img image.Image, format string) (*domain.RenderedThumbnail, error) {
+	var buf bytes.Buffer
+	thumbnail := &domain.RenderedThumbnail{}
+
+	if format == "jpeg" {
+		thumbnail.ContentType = "image/jpeg"
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+	} else {
+		thumbnail.ContentType = "image/png"
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+	}
+
+	thumbnail.Data = buf.Bytes()
+	return thumbnail, nil
+}
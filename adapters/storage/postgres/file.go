@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"encoding/hex"
 	"time"
 
 	"github.com/gruzdev-dev/codex-files/core/domain"
@@ -11,6 +12,8 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+const fileColumns = `id, tenant_id, owner_id, s3_path, size, content_type, security_context, checksum_algorithm, checksum, etag, status, scan_signature, scanned_at, is_deleted, created_at, updated_at, deleted_at`
+
 type FileRepo struct {
 	pool *pgxpool.Pool
 }
@@ -22,59 +25,84 @@ func NewFileRepo(pool *pgxpool.Pool) ports.FileRepository {
 }
 
 func (r *FileRepo) Create(ctx context.Context, file *domain.File) (*domain.File, error) {
-	query := `INSERT INTO files (id, owner_id, s3_path, size, content_type, status, is_deleted, created_at, updated_at) 
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
-	          RETURNING id, owner_id, s3_path, size, content_type, status, is_deleted, created_at, updated_at`
+	return insertFile(ctx, r.pool, file)
+}
+
+// CreateWithinQuotas creates the file once it fits in every one of quotas.
+// Each quota is locked for the rest of the transaction, so concurrent
+// creates against the same owner or tenant are checked one after another.
+func (r *FileRepo) CreateWithinQuotas(ctx context.Context, file *domain.File, quotas []domain.Quota) (*domain.File, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, quota := range lockOrder(quotas) {
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, quotaLockKey(quota)); err != nil {
+			return nil, err
+		}
+
+		usage, err := queryUsage(ctx, tx, quota.TenantID, quota.OwnerID)
+		if err != nil {
+			return nil, err
+		}
+		if err := quota.Check(*usage, file.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := insertFile(ctx, tx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func insertFile(ctx context.Context, q querier, file *domain.File) (*domain.File, error) {
+	query := `INSERT INTO files (` + fileColumns + `) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17) 
+	          RETURNING ` + fileColumns
 
-	var created domain.File
-	err := r.pool.QueryRow(ctx, query,
+	created, err := scanFile(q.QueryRow(ctx, query,
 		file.ID,
+		file.TenantID,
 		file.OwnerID,
 		file.S3Path,
 		file.Size,
 		file.ContentType,
+		file.SecurityContext,
+		string(file.Checksum.Algorithm),
+		hex.EncodeToString(file.Checksum.Value),
+		file.ETag,
 		string(file.Status),
+		file.ScanSignature,
+		nullableTime(file.ScannedAt),
 		file.IsDeleted,
 		file.CreatedAt,
 		file.UpdatedAt,
-	).Scan(
-		&created.ID,
-		&created.OwnerID,
-		&created.S3Path,
-		&created.Size,
-		&created.ContentType,
-		&created.Status,
-		&created.IsDeleted,
-		&created.CreatedAt,
-		&created.UpdatedAt,
-	)
+		nullableTime(file.DeletedAt),
+	))
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &created, nil
+	return created, nil
 }
 
 func (r *FileRepo) GetByID(ctx context.Context, id string) (*domain.File, error) {
-	query := `SELECT id, owner_id, s3_path, size, content_type, status, is_deleted, created_at, updated_at 
+	filter, args := tenantFilter(ctx, "tenant_id", []any{id})
+	query := `SELECT ` + fileColumns + ` 
 	          FROM files 
-	          WHERE id = $1 AND is_deleted = false`
-
-	var file domain.File
-	var statusStr string
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&file.ID,
-		&file.OwnerID,
-		&file.S3Path,
-		&file.Size,
-		&file.ContentType,
-		&statusStr,
-		&file.IsDeleted,
-		&file.CreatedAt,
-		&file.UpdatedAt,
-	)
+	          WHERE id = $1 AND is_deleted = false` + filter
 
+	file, err := scanFile(r.pool.QueryRow(ctx, query, args...))
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, domain.ErrFileNotFound
@@ -82,37 +110,31 @@ func (r *FileRepo) GetByID(ctx context.Context, id string) (*domain.File, error)
 		return nil, err
 	}
 
-	file.Status = domain.FileStatus(statusStr)
-	return &file, nil
+	return file, nil
 }
 
 func (r *FileRepo) Update(ctx context.Context, file *domain.File) (*domain.File, error) {
 	file.UpdatedAt = time.Now()
-	query := `UPDATE files 
-	          SET s3_path = $2, size = $3, content_type = $4, status = $5, updated_at = $6 
-	          WHERE id = $1 AND is_deleted = false
-	          RETURNING id, owner_id, s3_path, size, content_type, status, is_deleted, created_at, updated_at`
-
-	var updated domain.File
-	var statusStr string
-	err := r.pool.QueryRow(ctx, query,
+	filter, args := tenantFilter(ctx, "tenant_id", []any{
 		file.ID,
 		file.S3Path,
 		file.Size,
 		file.ContentType,
+		file.SecurityContext,
+		string(file.Checksum.Algorithm),
+		hex.EncodeToString(file.Checksum.Value),
+		file.ETag,
 		string(file.Status),
+		file.ScanSignature,
+		nullableTime(file.ScannedAt),
 		file.UpdatedAt,
-	).Scan(
-		&updated.ID,
-		&updated.OwnerID,
-		&updated.S3Path,
-		&updated.Size,
-		&updated.ContentType,
-		&statusStr,
-		&updated.IsDeleted,
-		&updated.CreatedAt,
-		&updated.UpdatedAt,
-	)
+	})
+	query := `UPDATE files 
+	          SET s3_path = $2, size = $3, content_type = $4, security_context = $5, checksum_algorithm = $6, checksum = $7, etag = $8, status = $9, scan_signature = $10, scanned_at = $11, updated_at = $12 
+	          WHERE id = $1 AND is_deleted = false` + filter + `
+	          RETURNING ` + fileColumns
+
+	updated, err := scanFile(r.pool.QueryRow(ctx, query, args...))
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -121,16 +143,197 @@ func (r *FileRepo) Update(ctx context.Context, file *domain.File) (*domain.File,
 		return nil, err
 	}
 
-	updated.Status = domain.FileStatus(statusStr)
-	return &updated, nil
+	return updated, nil
 }
 
 func (r *FileRepo) SoftDelete(ctx context.Context, id string) error {
+	filter, args := tenantFilter(ctx, "tenant_id", []any{id, time.Now()})
+	query := `UPDATE files 
+	          SET is_deleted = true, updated_at = $2, deleted_at = $2 
+	          WHERE id = $1 AND is_deleted = false` + filter
+
+	result, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrFileNotFound
+	}
+
+	return nil
+}
+
+func (r *FileRepo) ListAbandoned(ctx context.Context, cutoff domain.UploadCutoff, limit int) ([]*domain.File, error) {
+	filter, args := tenantFilter(ctx, "f.tenant_id", []any{
+		string(domain.FileStatusPending),
+		cutoff.Pending,
+		cutoff.Multipart,
+		cutoff.Resumable,
+		limit,
+	})
+	query := `SELECT ` + fileColumns + ` 
+	          FROM files f 
+	          WHERE f.status = $1 AND f.is_deleted = false AND f.created_at < $2` + filter + ` 
+	            AND NOT EXISTS (
+	                SELECT 1 FROM multipart_uploads m 
+	                LEFT JOIN resumable_uploads r ON r.file_id = m.file_id 
+	                WHERE m.file_id = f.id 
+	                  AND CASE WHEN r.file_id IS NULL THEN m.created_at >= $3 ELSE r.expires_at >= $4 END
+	            ) 
+	          ORDER BY f.created_at 
+	          LIMIT $5`
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*domain.File
+	for rows.Next() {
+		file, err := scanFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+func (r *FileRepo) ListUnscanned(ctx context.Context, limit int) ([]*domain.File, error) {
+	filter, args := tenantFilter(ctx, "f.tenant_id", []any{
+		string(domain.FileStatusUploaded),
+		string(domain.JobKindScanFile),
+		limit,
+	})
+	query := `SELECT ` + fileColumns + ` 
+	          FROM files f 
+	          WHERE f.status = $1 AND f.is_deleted = false` + filter + ` 
+	            AND NOT EXISTS (SELECT 1 FROM jobs j WHERE j.file_id = f.id AND j.kind = $2) 
+	          ORDER BY f.created_at 
+	          LIMIT $3`
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*domain.File
+	for rows.Next() {
+		file, err := scanFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+func (r *FileRepo) GetDeletedByID(ctx context.Context, id string) (*domain.File, error) {
+	filter, args := tenantFilter(ctx, "tenant_id", []any{id})
+	query := `SELECT ` + fileColumns + ` 
+	          FROM files 
+	          WHERE id = $1 AND is_deleted = true AND purged_at IS NULL` + filter
+
+	file, err := scanFile(r.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrFileNotFound
+		}
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Restore undeletes a file soft-deleted after deletedAfter and records event in
+// its history. A file the purger has leased is no longer restorable.
+func (r *FileRepo) Restore(ctx context.Context, id string, deletedAfter time.Time, event *domain.FileEvent) (*domain.File, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	filter, args := tenantFilter(ctx, "tenant_id", []any{id, deletedAfter, now})
 	query := `UPDATE files 
-	          SET is_deleted = true, updated_at = $2 
-	          WHERE id = $1 AND is_deleted = false`
+	          SET is_deleted = false, deleted_at = NULL, updated_at = $3 
+	          WHERE id = $1 AND is_deleted = true AND purged_at IS NULL AND deleted_at >= $2 
+	            AND (purge_lease_until IS NULL OR purge_lease_until < $3)` + filter + ` 
+	          RETURNING ` + fileColumns
 
-	result, err := r.pool.Exec(ctx, query, id, time.Now())
+	restored, err := scanFile(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrFileNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO file_history (file_id, action, actor_id, created_at) VALUES ($1, $2, $3, $4)`,
+		event.FileID, string(event.Action), event.ActorID, event.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// ClaimPurgeable leases a batch of files soft-deleted before deletedBefore.
+// Rows locked or leased by another replica are skipped, and a lease that
+// expires because its holder died makes the row claimable again.
+func (r *FileRepo) ClaimPurgeable(ctx context.Context, deletedBefore time.Time, lease time.Duration, limit int) ([]*domain.File, error) {
+	now := time.Now()
+	filter, args := tenantFilter(ctx, "tenant_id", []any{deletedBefore, now, now.Add(lease), limit})
+	query := `UPDATE files 
+	          SET purge_lease_until = $3 
+	          WHERE id IN (
+	              SELECT id FROM files 
+	              WHERE is_deleted = true AND purged_at IS NULL AND deleted_at < $1 
+	                AND (purge_lease_until IS NULL OR purge_lease_until < $2)` + filter + ` 
+	              ORDER BY deleted_at 
+	              LIMIT $4 
+	              FOR UPDATE SKIP LOCKED
+	          ) 
+	          RETURNING ` + fileColumns
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*domain.File
+	for rows.Next() {
+		file, err := scanFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+// MarkPurged tombstones a soft-deleted file once its object is gone. The row
+// is kept for audit, but nothing that points at the content survives.
+func (r *FileRepo) MarkPurged(ctx context.Context, id string) error {
+	filter, args := tenantFilter(ctx, "tenant_id", []any{id, string(domain.FileStatusPurged), time.Now()})
+	query := `UPDATE files 
+	          SET status = $2, security_context = '', purged_at = $3, updated_at = $3, purge_lease_until = NULL 
+	          WHERE id = $1 AND is_deleted = true AND purged_at IS NULL` + filter
+
+	result, err := r.pool.Exec(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -141,3 +344,110 @@ func (r *FileRepo) SoftDelete(ctx context.Context, id string) error {
 
 	return nil
 }
+
+func (r *FileRepo) ListOwners(ctx context.Context) ([]string, error) {
+	filter, args := tenantFilter(ctx, "tenant_id", nil)
+	return r.listDistinct(ctx, `SELECT DISTINCT owner_id FROM files WHERE true`+filter+` ORDER BY owner_id`, args...)
+}
+
+func (r *FileRepo) ListTenants(ctx context.Context) ([]string, error) {
+	return r.listDistinct(ctx, `SELECT DISTINCT tenant_id FROM files ORDER BY tenant_id`)
+}
+
+func (r *FileRepo) listDistinct(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+// ListByOwner returns every row of the owner, deleted and purged ones
+// included.
+func (r *FileRepo) ListByOwner(ctx context.Context, ownerID string) ([]*domain.File, error) {
+	filter, args := tenantFilter(ctx, "tenant_id", []any{ownerID})
+	query := `SELECT ` + fileColumns + ` 
+	          FROM files 
+	          WHERE owner_id = $1` + filter + ` 
+	          ORDER BY created_at`
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*domain.File
+	for rows.Next() {
+		file, err := scanFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+func scanFile(row pgx.Row) (*domain.File, error) {
+	var file domain.File
+	var deletedAt, scannedAt *time.Time
+	var statusStr, algorithm, checksum string
+	err := row.Scan(
+		&file.ID,
+		&file.TenantID,
+		&file.OwnerID,
+		&file.S3Path,
+		&file.Size,
+		&file.ContentType,
+		&file.SecurityContext,
+		&algorithm,
+		&checksum,
+		&file.ETag,
+		&statusStr,
+		&file.ScanSignature,
+		&scannedAt,
+		&file.IsDeleted,
+		&file.CreatedAt,
+		&file.UpdatedAt,
+		&deletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	file.Status = domain.FileStatus(statusStr)
+	if deletedAt != nil {
+		file.DeletedAt = *deletedAt
+	}
+	if scannedAt != nil {
+		file.ScannedAt = *scannedAt
+	}
+	if algorithm != "" {
+		value, err := hex.DecodeString(checksum)
+		if err != nil {
+			return nil, err
+		}
+		file.Checksum = domain.Checksum{Algorithm: domain.ChecksumAlgorithm(algorithm), Value: value}
+	}
+
+	return &file, nil
+}
+
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const jobColumns = `id, kind, file_id, payload, status, attempts, run_at, locked_until, last_error, created_at, updated_at`
+
+type JobRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewJobRepo(pool *pgxpool.Pool) ports.JobRepository {
+	return &JobRepo{
+		pool: pool,
+	}
+}
+
+func (r *JobRepo) Enqueue(ctx context.Context, job *domain.Job) (*domain.Job, error) {
+	payload := job.Payload
+	if payload == nil {
+		payload = map[string]string{}
+	}
+
+	query := `INSERT INTO jobs (` + jobColumns + `)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	          RETURNING ` + jobColumns
+
+	return scanJob(r.pool.QueryRow(ctx, query,
+		job.ID,
+		string(job.Kind),
+		job.FileID,
+		payload,
+		string(job.Status),
+		job.Attempts,
+		job.RunAt,
+		nullableTime(job.LockedUntil),
+		job.LastError,
+		job.CreatedAt,
+		job.UpdatedAt,
+	))
+}
+
+// Claim leases due jobs in the order they became due. Rows locked by another
+// worker's claim are skipped rather than waited for. Jobs whose lease ran
+// out on their last attempt are dead-lettered instead of being run again.
+func (r *JobRepo) Claim(ctx context.Context, kinds []domain.JobKind, lease time.Duration, limit int, maxAttempts int) ([]*domain.Job, error) {
+	names := make([]string, len(kinds))
+	for i, kind := range kinds {
+		names[i] = string(kind)
+	}
+
+	now := time.Now()
+	query := `WITH dead AS (
+	              UPDATE jobs 
+	              SET status = $7, locked_until = NULL, last_error = 'lease ran out on the last attempt', updated_at = $3 
+	              WHERE kind = ANY($4) AND status = $1 AND locked_until < $3 AND attempts >= $8
+	          ) 
+	          UPDATE jobs 
+	          SET status = $1, attempts = attempts + 1, locked_until = $2, updated_at = $3 
+	          WHERE id IN (
+	              SELECT id FROM jobs 
+	              WHERE kind = ANY($4) 
+	                AND ((status = $5 AND run_at <= $3) OR (status = $1 AND locked_until < $3 AND attempts < $8)) 
+	              ORDER BY run_at 
+	              LIMIT $6 
+	              FOR UPDATE SKIP LOCKED
+	          ) 
+	          RETURNING ` + jobColumns
+
+	rows, err := r.pool.Query(ctx, query,
+		string(domain.JobStatusRunning),
+		now.Add(lease),
+		now,
+		names,
+		string(domain.JobStatusQueued),
+		limit,
+		string(domain.JobStatusDead),
+		maxAttempts,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*domain.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Update only records the outcome of the attempt that is still running:
+// every claim counts an attempt, so a job claimed again since has moved on.
+func (r *JobRepo) Update(ctx context.Context, job *domain.Job) error {
+	query := `UPDATE jobs 
+	          SET status = $2, run_at = $4, locked_until = $5, last_error = $6, updated_at = $7 
+	          WHERE id = $1 AND status = $8 AND attempts = $3`
+
+	result, err := r.pool.Exec(ctx, query,
+		job.ID,
+		string(job.Status),
+		job.Attempts,
+		job.RunAt,
+		nullableTime(job.LockedUntil),
+		job.LastError,
+		job.UpdatedAt,
+		string(domain.JobStatusRunning),
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrJobLeaseLost
+	}
+
+	return nil
+}
+
+// DeleteFinished skips rows locked by another worker's delete, so that
+// workers cleaning up at the same time do not wait for each other.
+func (r *JobRepo) DeleteFinished(ctx context.Context, doneBefore, deadBefore time.Time, limit int) (int, error) {
+	query := `DELETE FROM jobs 
+	          WHERE id IN (
+	              SELECT id FROM jobs 
+	              WHERE (status = $1 AND updated_at < $2) OR (status = $3 AND updated_at < $4) 
+	              LIMIT $5 
+	              FOR UPDATE SKIP LOCKED
+	          )`
+
+	result, err := r.pool.Exec(ctx, query,
+		string(domain.JobStatusDone),
+		doneBefore,
+		string(domain.JobStatusDead),
+		deadBefore,
+		limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
+func scanJob(row pgx.Row) (*domain.Job, error) {
+	var job domain.Job
+	var kind, status string
+	var lockedUntil *time.Time
+	err := row.Scan(
+		&job.ID,
+		&kind,
+		&job.FileID,
+		&job.Payload,
+		&status,
+		&job.Attempts,
+		&job.RunAt,
+		&lockedUntil,
+		&job.LastError,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Kind = domain.JobKind(kind)
+	job.Status = domain.JobStatus(status)
+	if lockedUntil != nil {
+		job.LockedUntil = *lockedUntil
+	}
+
+	return &job, nil
+}
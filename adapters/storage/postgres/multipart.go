@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MultipartUploadRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewMultipartUploadRepo(pool *pgxpool.Pool) ports.MultipartUploadRepository {
+	return &MultipartUploadRepo{
+		pool: pool,
+	}
+}
+
+func (r *MultipartUploadRepo) Create(ctx context.Context, upload *domain.MultipartUpload) (*domain.MultipartUpload, error) {
+	query := `INSERT INTO multipart_uploads (file_id, upload_id, part_size, part_count, created_at) 
+	          VALUES ($1, $2, $3, $4, $5) 
+	          RETURNING file_id, upload_id, part_size, part_count, created_at`
+
+	var created domain.MultipartUpload
+	err := r.pool.QueryRow(ctx, query,
+		upload.FileID,
+		upload.UploadID,
+		upload.PartSize,
+		upload.PartCount,
+		upload.CreatedAt,
+	).Scan(
+		&created.FileID,
+		&created.UploadID,
+		&created.PartSize,
+		&created.PartCount,
+		&created.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *MultipartUploadRepo) GetByFileID(ctx context.Context, fileID string) (*domain.MultipartUpload, error) {
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{fileID})
+	query := `SELECT file_id, upload_id, part_size, part_count, created_at 
+	          FROM multipart_uploads 
+	          WHERE file_id = $1` + filter
+
+	var upload domain.MultipartUpload
+	err := r.pool.QueryRow(ctx, query, args...).Scan(
+		&upload.FileID,
+		&upload.UploadID,
+		&upload.PartSize,
+		&upload.PartCount,
+		&upload.CreatedAt,
+	)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrUploadNotFound
+		}
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+func (r *MultipartUploadRepo) Delete(ctx context.Context, fileID string) error {
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{fileID})
+	query := `DELETE FROM multipart_uploads WHERE file_id = $1` + filter
+
+	result, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrUploadNotFound
+	}
+
+	return nil
+}
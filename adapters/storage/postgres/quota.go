@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type QuotaRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewQuotaRepo(pool *pgxpool.Pool) ports.QuotaRepository {
+	return &QuotaRepo{
+		pool: pool,
+	}
+}
+
+func (r *QuotaRepo) ListOverrides(ctx context.Context, tenantID, ownerID string) ([]*domain.Quota, error) {
+	query := `SELECT tenant_id, owner_id, max_bytes, max_files
+	          FROM quotas
+	          WHERE tenant_id = $1 AND owner_id IN ('', $2)`
+
+	rows, err := r.pool.Query(ctx, query, tenantID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var quotas []*domain.Quota
+	for rows.Next() {
+		var quota domain.Quota
+		if err := rows.Scan(&quota.TenantID, &quota.OwnerID, &quota.MaxBytes, &quota.MaxFiles); err != nil {
+			return nil, err
+		}
+		quota.Scope = domain.QuotaScopeOwner
+		if quota.OwnerID == "" {
+			quota.Scope = domain.QuotaScopeTenant
+		}
+		quotas = append(quotas, &quota)
+	}
+
+	return quotas, rows.Err()
+}
+
+// GetUsage counts live files only: stored ones as used, whatever their scan
+// verdict, and pending ones as reserved. Expired and deleted files no longer
+// count.
+func (r *QuotaRepo) GetUsage(ctx context.Context, tenantID, ownerID string) (*domain.Usage, error) {
+	return queryUsage(ctx, r.pool, tenantID, ownerID)
+}
+
+// Lock holds the locks of quotas, which creating a file within them also
+// takes, until the returned release is called.
+func (r *QuotaRepo) Lock(ctx context.Context, quotas []domain.Quota) (func(), error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	release := func() {
+		// The request may be cancelled by now, and locks that cannot be
+		// released go away with their connection.
+		for _, key := range keys {
+			if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtextextended($1, 0))`, key); err != nil {
+				_ = conn.Hijack().Close(context.Background())
+				return
+			}
+		}
+		conn.Release()
+	}
+
+	for _, quota := range lockOrder(quotas) {
+		key := quotaLockKey(quota)
+		if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock(hashtextextended($1, 0))`, key); err != nil {
+			release()
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return release, nil
+}
+
+// lockOrder sorts quotas the way they are locked: tenant quotas before owner
+// quotas, so that two lockers never wait on each other.
+func lockOrder(quotas []domain.Quota) []domain.Quota {
+	quotas = slices.Clone(quotas)
+	slices.SortStableFunc(quotas, func(a, b domain.Quota) int {
+		return cmp.Compare(a.OwnerID, b.OwnerID)
+	})
+	return quotas
+}
+
+func quotaLockKey(quota domain.Quota) string {
+	return "quota:" + quota.TenantID + "/" + quota.OwnerID
+}
+
+// querier is what a pool and a transaction have in common, so that a query
+// can run in either.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func queryUsage(ctx context.Context, q querier, tenantID, ownerID string) (*domain.Usage, error) {
+	query := `SELECT 
+	              COUNT(*) FILTER (WHERE status = ANY($3)),
+	              COALESCE(SUM(size) FILTER (WHERE status = ANY($3)), 0),
+	              COUNT(*) FILTER (WHERE status = $4),
+	              COALESCE(SUM(size) FILTER (WHERE status = $4), 0)
+	          FROM files 
+	          WHERE tenant_id = $1 AND ($2::text = '' OR owner_id = $2::text) AND is_deleted = false`
+
+	var usage domain.Usage
+	err := q.QueryRow(ctx, query,
+		tenantID,
+		ownerID,
+		storedStatuses(),
+		string(domain.FileStatusPending),
+	).Scan(
+		&usage.Files,
+		&usage.Bytes,
+		&usage.ReservedFiles,
+		&usage.ReservedBytes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+func storedStatuses() []string {
+	statuses := make([]string, len(domain.StoredStatuses))
+	for i, status := range domain.StoredStatuses {
+		statuses[i] = string(status)
+	}
+	return statuses
+}
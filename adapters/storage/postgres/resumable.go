@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ResumableUploadRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewResumableUploadRepo(pool *pgxpool.Pool) ports.ResumableUploadRepository {
+	return &ResumableUploadRepo{
+		pool: pool,
+	}
+}
+
+func (r *ResumableUploadRepo) Create(ctx context.Context, upload *domain.ResumableUpload) (*domain.ResumableUpload, error) {
+	query := `INSERT INTO resumable_uploads (file_id, upload_length, upload_offset, metadata, buffer, expires_at, created_at) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7) 
+	          RETURNING file_id, upload_length, upload_offset, metadata, buffer, expires_at, created_at`
+
+	buffer := upload.Buffer
+	if buffer == nil {
+		buffer = []byte{}
+	}
+
+	var created domain.ResumableUpload
+	err := r.pool.QueryRow(ctx, query,
+		upload.FileID,
+		upload.Length,
+		upload.Offset,
+		upload.Metadata,
+		buffer,
+		upload.ExpiresAt,
+		upload.CreatedAt,
+	).Scan(
+		&created.FileID,
+		&created.Length,
+		&created.Offset,
+		&created.Metadata,
+		&created.Buffer,
+		&created.ExpiresAt,
+		&created.CreatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *ResumableUploadRepo) GetByFileID(ctx context.Context, fileID string) (*domain.ResumableUpload, error) {
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{fileID})
+	query := `SELECT file_id, upload_length, upload_offset, metadata, buffer, expires_at, created_at 
+	          FROM resumable_uploads 
+	          WHERE file_id = $1` + filter
+
+	var upload domain.ResumableUpload
+	err := r.pool.QueryRow(ctx, query, args...).Scan(
+		&upload.FileID,
+		&upload.Length,
+		&upload.Offset,
+		&upload.Metadata,
+		&upload.Buffer,
+		&upload.ExpiresAt,
+		&upload.CreatedAt,
+	)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrUploadNotFound
+		}
+		return nil, err
+	}
+
+	partsQuery := `SELECT part_number, etag 
+	               FROM upload_parts 
+	               WHERE file_id = $1 
+	               ORDER BY part_number`
+
+	rows, err := r.pool.Query(ctx, partsQuery, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var part domain.UploadPart
+		if err := rows.Scan(&part.PartNumber, &part.ETag); err != nil {
+			return nil, err
+		}
+		upload.Parts = append(upload.Parts, part)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &upload, nil
+}
+
+// Advance stores the new offset and buffered tail of an upload together with
+// the parts written since fromOffset. It fails with domain.ErrUploadConflict
+// when another request has moved the offset in the meantime.
+func (r *ResumableUploadRepo) Advance(ctx context.Context, upload *domain.ResumableUpload, fromOffset int64, parts []domain.UploadPart) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	buffer := upload.Buffer
+	if buffer == nil {
+		buffer = []byte{}
+	}
+
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{
+		upload.FileID,
+		upload.Offset,
+		buffer,
+		upload.ExpiresAt,
+		fromOffset,
+	})
+	query := `UPDATE resumable_uploads 
+	          SET upload_offset = $2, buffer = $3, expires_at = $4 
+	          WHERE file_id = $1 AND upload_offset = $5` + filter
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrUploadConflict
+	}
+
+	partQuery := `INSERT INTO upload_parts (file_id, part_number, etag) 
+	              VALUES ($1, $2, $3) 
+	              ON CONFLICT (file_id, part_number) DO UPDATE SET etag = EXCLUDED.etag`
+
+	for _, part := range parts {
+		if _, err := tx.Exec(ctx, partQuery, upload.FileID, part.PartNumber, part.ETag); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Lock takes a session-level advisory lock on a connection of its own, which
+// is held across the part uploads of a chunk and returned to the pool on
+// release.
+func (r *ResumableUploadRepo) Lock(ctx context.Context, fileID string) (func(), error) {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := "resumable_uploads:" + fileID
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtextextended($1, 0))`, key).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, err
+	}
+	if !locked {
+		conn.Release()
+		return nil, domain.ErrUploadConflict
+	}
+
+	return func() {
+		// The request may be cancelled by now, and a lock that cannot be
+		// released goes away with its connection.
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtextextended($1, 0))`, key); err != nil {
+			_ = conn.Hijack().Close(context.Background())
+			return
+		}
+		conn.Release()
+	}, nil
+}
@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const shareLinkColumns = `id, file_id, token_hash, password_hash, max_downloads, downloads, expires_at, created_by, created_at, revoked_at`
+
+type ShareLinkRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewShareLinkRepo(pool *pgxpool.Pool) ports.ShareLinkRepository {
+	return &ShareLinkRepo{
+		pool: pool,
+	}
+}
+
+func (r *ShareLinkRepo) Create(ctx context.Context, link *domain.ShareLink) (*domain.ShareLink, error) {
+	query := `INSERT INTO share_links (` + shareLinkColumns + `)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	          RETURNING ` + shareLinkColumns
+
+	row := r.pool.QueryRow(ctx, query,
+		link.ID,
+		link.FileID,
+		link.TokenHash,
+		link.PasswordHash,
+		link.MaxDownloads,
+		link.Downloads,
+		link.ExpiresAt,
+		link.CreatedBy,
+		link.CreatedAt,
+		nullableTime(link.RevokedAt),
+	)
+
+	return scanShareLink(row)
+}
+
+func (r *ShareLinkRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.ShareLink, error) {
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{tokenHash})
+	query := `SELECT ` + shareLinkColumns + ` FROM share_links WHERE token_hash = $1` + filter
+
+	link, err := scanShareLink(r.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrShareNotFound
+		}
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func (r *ShareLinkRepo) ListByFileID(ctx context.Context, fileID string) ([]*domain.ShareLink, error) {
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{fileID})
+	query := `SELECT ` + shareLinkColumns + `
+	          FROM share_links
+	          WHERE file_id = $1` + filter + `
+	          ORDER BY created_at DESC`
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []*domain.ShareLink
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+func (r *ShareLinkRepo) Revoke(ctx context.Context, fileID, id string) error {
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{id, fileID, time.Now()})
+	query := `UPDATE share_links SET revoked_at = $3
+	          WHERE id = $1 AND file_id = $2 AND revoked_at IS NULL` + filter
+
+	result, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	if result.RowsAffected() == 0 {
+		return domain.ErrShareNotFound
+	}
+
+	return nil
+}
+
+// Consume counts a download against the link and logs it in one transaction.
+// The limits are checked again in the UPDATE, so concurrent downloads cannot
+// take a link past its maximum.
+func (r *ShareLinkRepo) Consume(ctx context.Context, use *domain.ShareLinkUse) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{use.LinkID, use.CreatedAt})
+	query := `UPDATE share_links SET downloads = downloads + 1
+	          WHERE id = $1 AND revoked_at IS NULL AND expires_at > $2
+	            AND (max_downloads = 0 OR downloads < max_downloads)` + filter
+
+	result, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		return domain.ErrShareExpired
+	}
+
+	if err := insertShareLinkUse(ctx, tx, use); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *ShareLinkRepo) LogUse(ctx context.Context, use *domain.ShareLinkUse) error {
+	return insertShareLinkUse(ctx, r.pool, use)
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+func insertShareLinkUse(ctx context.Context, db execer, use *domain.ShareLinkUse) error {
+	_, err := db.Exec(ctx,
+		`INSERT INTO share_link_uses (link_id, outcome, remote_addr, user_agent, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		use.LinkID, string(use.Outcome), use.RemoteAddr, use.UserAgent, use.CreatedAt,
+	)
+	return err
+}
+
+func scanShareLink(row pgx.Row) (*domain.ShareLink, error) {
+	var (
+		link      domain.ShareLink
+		revokedAt *time.Time
+	)
+
+	err := row.Scan(
+		&link.ID,
+		&link.FileID,
+		&link.TokenHash,
+		&link.PasswordHash,
+		&link.MaxDownloads,
+		&link.Downloads,
+		&link.ExpiresAt,
+		&link.CreatedBy,
+		&link.CreatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if revokedAt != nil {
+		link.RevokedAt = *revokedAt
+	}
+
+	return &link, nil
+}
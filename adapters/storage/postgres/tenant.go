@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+// fileTenantColumn resolves the tenant of tables keyed by file_id through the
+// file they belong to.
+const fileTenantColumn = `(SELECT tf.tenant_id FROM files tf WHERE tf.id = file_id)`
+
+// tenantFilter restricts a query to the tenant ctx is scoped to. It appends
+// the tenant to args and returns the condition to add to the WHERE clause,
+// which is empty when ctx is unscoped.
+func tenantFilter(ctx context.Context, column string, args []any) (string, []any) {
+	if tenant.IsUnscoped(ctx) {
+		return "", args
+	}
+	args = append(args, tenant.FromCtx(ctx))
+	return fmt.Sprintf(" AND %s = $%d", column, len(args)), args
+}
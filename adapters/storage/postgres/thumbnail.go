@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const thumbnailColumns = `file_id, size, s3_path, content_type, width, height, byte_size, created_at`
+
+type ThumbnailRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewThumbnailRepo(pool *pgxpool.Pool) ports.ThumbnailRepository {
+	return &ThumbnailRepo{
+		pool: pool,
+	}
+}
+
+func (r *ThumbnailRepo) Save(ctx context.Context, thumbnail *domain.Thumbnail) (*domain.Thumbnail, error) {
+	query := `INSERT INTO file_thumbnails (` + thumbnailColumns + `)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	          ON CONFLICT (file_id, size) DO UPDATE SET
+	              s3_path = EXCLUDED.s3_path,
+	              content_type = EXCLUDED.content_type,
+	              width = EXCLUDED.width,
+	              height = EXCLUDED.height,
+	              byte_size = EXCLUDED.byte_size,
+	              created_at = EXCLUDED.created_at
+	          RETURNING ` + thumbnailColumns
+
+	row := r.pool.QueryRow(ctx, query,
+		thumbnail.FileID,
+		thumbnail.Size,
+		thumbnail.S3Path,
+		thumbnail.ContentType,
+		thumbnail.Width,
+		thumbnail.Height,
+		thumbnail.ByteSize,
+		thumbnail.CreatedAt,
+	)
+
+	return scanThumbnail(row)
+}
+
+func (r *ThumbnailRepo) Get(ctx context.Context, fileID string, size int) (*domain.Thumbnail, error) {
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{fileID, size})
+	query := `SELECT ` + thumbnailColumns + ` FROM file_thumbnails WHERE file_id = $1 AND size = $2` + filter
+
+	thumbnail, err := scanThumbnail(r.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, domain.ErrThumbnailNotFound
+		}
+		return nil, err
+	}
+
+	return thumbnail, nil
+}
+
+func (r *ThumbnailRepo) ListByFileID(ctx context.Context, fileID string) ([]*domain.Thumbnail, error) {
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{fileID})
+	query := `SELECT ` + thumbnailColumns + `
+	          FROM file_thumbnails
+	          WHERE file_id = $1` + filter + `
+	          ORDER BY size`
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var thumbnails []*domain.Thumbnail
+	for rows.Next() {
+		thumbnail, err := scanThumbnail(rows)
+		if err != nil {
+			return nil, err
+		}
+		thumbnails = append(thumbnails, thumbnail)
+	}
+
+	return thumbnails, rows.Err()
+}
+
+func (r *ThumbnailRepo) DeleteByFileID(ctx context.Context, fileID string) error {
+	filter, args := tenantFilter(ctx, fileTenantColumn, []any{fileID})
+	_, err := r.pool.Exec(ctx, `DELETE FROM file_thumbnails WHERE file_id = $1`+filter, args...)
+	return err
+}
+
+func scanThumbnail(row pgx.Row) (*domain.Thumbnail, error) {
+	var thumbnail domain.Thumbnail
+
+	err := row.Scan(
+		&thumbnail.FileID,
+		&thumbnail.Size,
+		&thumbnail.S3Path,
+		&thumbnail.ContentType,
+		&thumbnail.Width,
+		&thumbnail.Height,
+		&thumbnail.ByteSize,
+		&thumbnail.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &thumbnail, nil
+}
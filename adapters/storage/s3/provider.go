@@ -3,20 +3,28 @@ package s3
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/gruzdev-dev/codex-files/configs"
+	"github.com/gruzdev-dev/codex-files/core/domain"
 	"github.com/gruzdev-dev/codex-files/core/ports"
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
 )
 
+const streamingPartSize = 16 * 1024 * 1024
+
 type S3Provider struct {
 	client       *minio.Client
+	core         *minio.Core
 	bucket       string
+	buckets      map[string]string
 	externalHost string
 }
 
@@ -60,22 +68,38 @@ func NewS3Provider(cfg *configs.Config) (ports.FileProvider, error) {
 
 	return &S3Provider{
 		client:       client,
+		core:         &minio.Core{Client: client},
 		bucket:       cfg.S3.Bucket,
+		buckets:      cfg.S3.TenantBuckets,
 		externalHost: cfg.S3.ExternalHost,
 	}, nil
 }
 
-func (p *S3Provider) GenerateUploadURL(ctx context.Context, s3Path string, contentType string, maxSize int64, ttl time.Duration) (string, error) {
+// bucketFor returns the bucket of the tenant ctx is scoped to. Tenants
+// without an override share the default bucket under their key prefix.
+func (p *S3Provider) bucketFor(ctx context.Context) string {
+	if bucket, ok := p.buckets[tenant.FromCtx(ctx)]; ok {
+		return bucket
+	}
+	return p.bucket
+}
+
+func (p *S3Provider) GenerateUploadURL(ctx context.Context, s3Path string, contentType string, maxSize int64, checksum domain.Checksum, ttl time.Duration) (string, error) {
 	reqParams := make(url.Values)
 	extraHeaders := make(http.Header)
 
 	extraHeaders.Set("Content-Type", contentType)
+	if !checksum.IsZero() {
+		// Signing the checksum header makes the store reject a body that
+		// does not hash to the declared value.
+		extraHeaders.Set("x-amz-checksum-"+string(checksum.Algorithm), checksum.Base64())
+	}
 
 	if maxSize > 0 {
 		reqParams.Set("x-amz-content-length-range", fmt.Sprintf("0,%d", maxSize))
 	}
 
-	presignedURL, err := p.client.PresignHeader(ctx, http.MethodPut, p.bucket, s3Path, ttl, reqParams, extraHeaders)
+	presignedURL, err := p.client.PresignHeader(ctx, http.MethodPut, p.bucketFor(ctx), s3Path, ttl, reqParams, extraHeaders)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate upload URL: %w", err)
 	}
@@ -91,7 +115,7 @@ func (p *S3Provider) GenerateUploadURL(ctx context.Context, s3Path string, conte
 }
 
 func (p *S3Provider) GenerateDownloadURL(ctx context.Context, s3Path string, ttl time.Duration) (string, error) {
-	presignedURL, err := p.client.PresignedGetObject(ctx, p.bucket, s3Path, ttl, nil)
+	presignedURL, err := p.client.PresignedGetObject(ctx, p.bucketFor(ctx), s3Path, ttl, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate download URL: %w", err)
 	}
@@ -105,3 +129,149 @@ func (p *S3Provider) GenerateDownloadURL(ctx context.Context, s3Path string, ttl
 
 	return presignedURL.String(), nil
 }
+
+func (p *S3Provider) PutObject(ctx context.Context, s3Path string, contentType string, content io.Reader, size int64) error {
+	opts := minio.PutObjectOptions{
+		ContentType: contentType,
+	}
+	if size < 0 {
+		// Without a known length the client buffers one part at a time, so
+		// keep parts small instead of sizing them for the 5 TiB maximum.
+		opts.PartSize = streamingPartSize
+	}
+
+	_, err := p.client.PutObject(ctx, p.bucketFor(ctx), s3Path, content, size, opts)
+	if err != nil {
+		return fmt.Errorf("failed to put object: %w", err)
+	}
+
+	return nil
+}
+
+func (p *S3Provider) OpenObject(ctx context.Context, s3Path string) (io.ReadSeekCloser, error) {
+	object, err := p.client.GetObject(ctx, p.bucketFor(ctx), s3Path, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open object: %w", err)
+	}
+
+	if _, err := object.Stat(); err != nil {
+		_ = object.Close()
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	return object, nil
+}
+
+func (p *S3Provider) StatObject(ctx context.Context, s3Path string) (*domain.ObjectInfo, error) {
+	info, err := p.client.StatObject(ctx, p.bucketFor(ctx), s3Path, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, domain.ErrObjectNotFound
+		}
+		return nil, fmt.Errorf("failed to stat object: %w", err)
+	}
+
+	return &domain.ObjectInfo{
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+	}, nil
+}
+
+func (p *S3Provider) DeleteObject(ctx context.Context, s3Path string) error {
+	if err := p.client.RemoveObject(ctx, p.bucketFor(ctx), s3Path, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+
+	return nil
+}
+
+// ListObjects lists the objects under prefix. Without recursive, keys one
+// level down are reported as prefixes ending in "/".
+func (p *S3Provider) ListObjects(ctx context.Context, prefix string, recursive bool) ([]domain.ObjectInfo, error) {
+	var objects []domain.ObjectInfo
+	for object := range p.client.ListObjects(ctx, p.bucketFor(ctx), minio.ListObjectsOptions{Prefix: prefix, Recursive: recursive}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", object.Err)
+		}
+		objects = append(objects, domain.ObjectInfo{
+			Key:          object.Key,
+			Size:         object.Size,
+			ContentType:  object.ContentType,
+			ETag:         object.ETag,
+			LastModified: object.LastModified,
+		})
+	}
+
+	return objects, nil
+}
+
+func (p *S3Provider) CreateMultipartUpload(ctx context.Context, s3Path string, contentType string) (string, error) {
+	uploadID, err := p.core.NewMultipartUpload(ctx, p.bucketFor(ctx), s3Path, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+
+	return uploadID, nil
+}
+
+func (p *S3Provider) GeneratePartUploadURL(ctx context.Context, s3Path string, uploadID string, partNumber int, ttl time.Duration) (string, error) {
+	reqParams := make(url.Values)
+	reqParams.Set("partNumber", strconv.Itoa(partNumber))
+	reqParams.Set("uploadId", uploadID)
+
+	presignedURL, err := p.client.Presign(ctx, http.MethodPut, p.bucketFor(ctx), s3Path, ttl, reqParams)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate part upload URL: %w", err)
+	}
+
+	if p.externalHost != "" {
+		presignedURL.Host = p.externalHost
+		if presignedURL.Scheme == "http" {
+			presignedURL.Scheme = "https"
+		}
+	}
+
+	return presignedURL.String(), nil
+}
+
+func (p *S3Provider) UploadPart(ctx context.Context, s3Path string, uploadID string, partNumber int, content io.Reader, size int64) (string, error) {
+	part, err := p.core.PutObjectPart(ctx, p.bucketFor(ctx), s3Path, uploadID, partNumber, content, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+
+	return part.ETag, nil
+}
+
+func (p *S3Provider) CompleteMultipartUpload(ctx context.Context, s3Path string, uploadID string, parts []domain.UploadPart) error {
+	completeParts := make([]minio.CompletePart, len(parts))
+	for i, part := range parts {
+		completeParts[i] = minio.CompletePart{
+			PartNumber: part.PartNumber,
+			ETag:       part.ETag,
+		}
+	}
+
+	_, err := p.core.CompleteMultipartUpload(ctx, p.bucketFor(ctx), s3Path, uploadID, completeParts, minio.PutObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+
+	return nil
+}
+
+func (p *S3Provider) AbortMultipartUpload(ctx context.Context, s3Path string, uploadID string) error {
+	if err := p.core.AbortMultipartUpload(ctx, p.bucketFor(ctx), s3Path, uploadID); err != nil {
+		// An upload that is already gone leaves nothing to clean up.
+		if minio.ToErrorResponse(err).Code == "NoSuchUpload" {
+			return nil
+		}
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+
+	return nil
+}
@@ -3,13 +3,17 @@ package main
 import (
 	"context"
 
+	clamavAdapter "github.com/gruzdev-dev/codex-files/adapters/clamav"
 	grpcAdapter "github.com/gruzdev-dev/codex-files/adapters/grpc"
 	httpAdapter "github.com/gruzdev-dev/codex-files/adapters/http"
+	imagingAdapter "github.com/gruzdev-dev/codex-files/adapters/imaging"
 	postgresAdapter "github.com/gruzdev-dev/codex-files/adapters/storage/postgres"
 	s3Adapter "github.com/gruzdev-dev/codex-files/adapters/storage/s3"
 	"github.com/gruzdev-dev/codex-files/configs"
+	"github.com/gruzdev-dev/codex-files/core/domain"
 	"github.com/gruzdev-dev/codex-files/core/ports"
 	"github.com/gruzdev-dev/codex-files/core/services"
+	"github.com/gruzdev-dev/codex-files/internal/wiring"
 	grpcServer "github.com/gruzdev-dev/codex-files/servers/grpc"
 	httpServer "github.com/gruzdev-dev/codex-files/servers/http"
 
@@ -32,11 +36,79 @@ func BuildContainer() (*dig.Container, error) {
 		return nil, err
 	}
 
+	if err := container.Provide(postgresAdapter.NewFileGrantRepo, dig.As(new(ports.FileGrantRepository))); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(postgresAdapter.NewQuotaRepo, dig.As(new(ports.QuotaRepository))); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(postgresAdapter.NewJobRepo, dig.As(new(ports.JobRepository))); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(postgresAdapter.NewThumbnailRepo, dig.As(new(ports.ThumbnailRepository))); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(postgresAdapter.NewShareLinkRepo, dig.As(new(ports.ShareLinkRepository))); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(postgresAdapter.NewMultipartUploadRepo, dig.As(new(ports.MultipartUploadRepository))); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(postgresAdapter.NewResumableUploadRepo, dig.As(new(ports.ResumableUploadRepository))); err != nil {
+		return nil, err
+	}
+
 	if err := container.Provide(s3Adapter.NewS3Provider, dig.As(new(ports.FileProvider))); err != nil {
 		return nil, err
 	}
 
-	if err := container.Provide(newFileService); err != nil {
+	if err := container.Provide(clamavAdapter.NewScanner); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(imagingAdapter.NewThumbnailer); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(imagingAdapter.NewMetadataStripper); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(wiring.NewFileService); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(newResumableUploadService); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(newUploadReaper); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(newFilePurger); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(newJobRunner); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(newShareLinkService); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(newThumbnailService); err != nil {
+		return nil, err
+	}
+
+	if err := container.Provide(newMetadataService); err != nil {
 		return nil, err
 	}
 
@@ -68,16 +140,97 @@ func newDBPool(cfg *configs.Config) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
-func newFileService(
+func newThumbnailService(
+	fileService *services.FileService,
+	thumbnails ports.ThumbnailRepository,
+	thumbnailer ports.Thumbnailer,
+	fileProvider ports.FileProvider,
+	cfg *configs.Config,
+) *services.ThumbnailService {
+	return services.NewThumbnailService(fileService, thumbnails, thumbnailer, fileProvider, services.ThumbnailConfig{
+		Sizes: cfg.Thumbnail.Sizes,
+	})
+}
+
+func newMetadataService(
+	fileService *services.FileService,
+	stripper ports.MetadataStripper,
+	fileProvider ports.FileProvider,
+) *services.MetadataService {
+	return services.NewMetadataService(fileService, stripper, fileProvider)
+}
+
+func newShareLinkService(
+	fileService *services.FileService,
+	shares ports.ShareLinkRepository,
+	fileProvider ports.FileProvider,
+	cfg *configs.Config,
+) *services.ShareLinkService {
+	return services.NewShareLinkService(fileService, shares, fileProvider, services.ShareLinkConfig{
+		DefaultTTL: cfg.Share.DefaultTTL,
+		MaxTTL:     cfg.Share.MaxTTL,
+	})
+}
+
+func newResumableUploadService(
+	fileService *services.FileService,
+	uploads ports.MultipartUploadRepository,
+	resumable ports.ResumableUploadRepository,
+	fileProvider ports.FileProvider,
+	cfg *configs.Config,
+) *services.ResumableUploadService {
+	return services.NewResumableUploadService(fileService, uploads, resumable, fileProvider, cfg.Upload.ResumableTTL)
+}
+
+func newUploadReaper(
+	fileService *services.FileService,
 	repo ports.FileRepository,
+	uploads ports.MultipartUploadRepository,
 	fileProvider ports.FileProvider,
 	cfg *configs.Config,
-) *services.FileService {
-	return services.NewFileService(
-		repo,
-		fileProvider,
-		cfg.Upload.MaxSize,
-		cfg.Upload.TTL,
-		cfg.Download.TTL,
-	)
+) *services.UploadReaper {
+	return services.NewUploadReaper(fileService, repo, uploads, fileProvider, services.UploadReaperConfig{
+		UploadTTL:    cfg.Upload.TTL,
+		ResumableTTL: cfg.Upload.ResumableTTL,
+		GracePeriod:  cfg.Reaper.GracePeriod,
+		BatchSize:    cfg.Reaper.BatchSize,
+	})
+}
+
+func newFilePurger(
+	repo ports.FileRepository,
+	uploads ports.MultipartUploadRepository,
+	thumbnails ports.ThumbnailRepository,
+	fileProvider ports.FileProvider,
+	cfg *configs.Config,
+) *services.FilePurger {
+	return services.NewFilePurger(repo, uploads, thumbnails, fileProvider, services.FilePurgerConfig{
+		Retention: cfg.Purge.Retention,
+		Lease:     cfg.Purge.Lease,
+		BatchSize: cfg.Purge.BatchSize,
+	})
+}
+
+// newJobRunner is the registry of job processors: every kind of post-upload
+// job is registered here.
+func newJobRunner(
+	jobs ports.JobRepository,
+	fileService *services.FileService,
+	thumbnailService *services.ThumbnailService,
+	metadataService *services.MetadataService,
+	cfg *configs.Config,
+) *services.JobRunner {
+	runner := services.NewJobRunner(jobs, services.JobRunnerConfig{
+		BatchSize:     cfg.Jobs.BatchSize,
+		Lease:         cfg.Jobs.Lease,
+		MaxAttempts:   cfg.Jobs.MaxAttempts,
+		Backoff:       domain.JobBackoff{Base: cfg.Jobs.BackoffBase, Max: cfg.Jobs.BackoffMax},
+		DoneRetention: cfg.Jobs.DoneRetention,
+		DeadRetention: cfg.Jobs.DeadRetention,
+	})
+	runner.Register(domain.JobKindConfirmUpload, fileService.ProcessConfirmUpload)
+	runner.Register(domain.JobKindScanFile, fileService.ProcessScanFile)
+	runner.Register(domain.JobKindStripMetadata, metadataService.ProcessStripMetadata)
+	runner.Register(domain.JobKindThumbnails, thumbnailService.ProcessThumbnails)
+	return runner
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/services"
+)
+
+var jobMetrics = expvar.NewMap("job_runner")
+
+func runJobWorker(ctx context.Context, runner *services.JobRunner, worker int, interval time.Duration, batchSize int) error {
+	name := fmt.Sprintf("job worker %d", worker)
+	return runBatches(ctx, name, interval, batchSize, func(ctx context.Context) int {
+		deleteFinishedJobs(ctx, name, runner)
+		return runJobs(ctx, name, runner)
+	})
+}
+
+func deleteFinishedJobs(ctx context.Context, name string, runner *services.JobRunner) {
+	deleted, err := runner.DeleteFinished(ctx)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("%s: %v", name, err)
+		jobMetrics.Add("errors", 1)
+	}
+
+	jobMetrics.Add("deleted", int64(deleted))
+}
+
+func runJobs(ctx context.Context, name string, runner *services.JobRunner) int {
+	started := time.Now()
+	result, err := runner.RunBatch(ctx)
+
+	jobMetrics.Add("runs", 1)
+	jobMetrics.AddFloat("duration_seconds", time.Since(started).Seconds())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("%s: %v", name, err)
+			jobMetrics.Add("errors", 1)
+		}
+		if result == nil {
+			return 0
+		}
+	}
+
+	jobMetrics.Add("claimed", int64(result.Claimed))
+	jobMetrics.Add("done", int64(result.Done))
+	jobMetrics.Add("retried", int64(result.Retried))
+	jobMetrics.Add("dead", int64(result.Dead))
+	jobMetrics.Add("lost", int64(result.Lost))
+
+	if result.Claimed > 0 {
+		log.Printf("%s: claimed %d, done %d, retried %d, dead %d, lost %d", name, result.Claimed, result.Done, result.Retried, result.Dead, result.Lost)
+	}
+
+	// Retried jobs wait out their backoff, so only a full batch of claimed
+	// jobs hints that more are ready to run.
+	return result.Claimed
+}
@@ -8,6 +8,8 @@ import (
 
 	"golang.org/x/sync/errgroup"
 
+	"github.com/gruzdev-dev/codex-files/configs"
+	"github.com/gruzdev-dev/codex-files/core/services"
 	grpcServer "github.com/gruzdev-dev/codex-files/servers/grpc"
 	httpServer "github.com/gruzdev-dev/codex-files/servers/http"
 )
@@ -19,8 +21,13 @@ func main() {
 	}
 
 	err = container.Invoke(func(
+		cfg *configs.Config,
 		httpSrv *httpServer.Server,
 		grpcSrv *grpcServer.Server,
+		reaper *services.UploadReaper,
+		purger *services.FilePurger,
+		fileService *services.FileService,
+		jobs *services.JobRunner,
 	) error {
 		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer stop()
@@ -35,6 +42,24 @@ func main() {
 			return grpcSrv.Start(ctx)
 		})
 
+		g.Go(func() error {
+			return runUploadReaper(ctx, reaper, cfg.Reaper.Interval, cfg.Reaper.BatchSize)
+		})
+
+		g.Go(func() error {
+			return runFilePurger(ctx, purger, cfg.Purge.Interval, cfg.Purge.BatchSize)
+		})
+
+		g.Go(func() error {
+			return runScanBackfill(ctx, fileService, cfg.ClamAV.BackfillInterval, cfg.ClamAV.BackfillBatchSize)
+		})
+
+		for worker := range cfg.Jobs.Workers {
+			g.Go(func() error {
+				return runJobWorker(ctx, jobs, worker, cfg.Jobs.Interval, cfg.Jobs.BatchSize)
+			})
+		}
+
 		return g.Wait()
 	})
 
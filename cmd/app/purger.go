@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/services"
+)
+
+var purgerMetrics = expvar.NewMap("file_purger")
+
+func runFilePurger(ctx context.Context, purger *services.FilePurger, interval time.Duration, batchSize int) error {
+	return runBatches(ctx, "file purger", interval, batchSize, func(ctx context.Context) int {
+		return purgeFiles(ctx, purger)
+	})
+}
+
+func purgeFiles(ctx context.Context, purger *services.FilePurger) int {
+	started := time.Now()
+	result, err := purger.PurgeBatch(ctx)
+
+	purgerMetrics.Add("runs", 1)
+	purgerMetrics.AddFloat("duration_seconds", time.Since(started).Seconds())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("file purger: %v", err)
+			purgerMetrics.Add("errors", 1)
+		}
+		if result == nil {
+			return 0
+		}
+	}
+
+	purgerMetrics.Add("claimed", int64(result.Claimed))
+	purgerMetrics.Add("purged", int64(result.Purged))
+	purgerMetrics.Add("failed", int64(result.Failed))
+
+	if result.Claimed > 0 {
+		log.Printf("file purger: claimed %d, purged %d, failed %d", result.Claimed, result.Purged, result.Failed)
+	}
+
+	// Failed files stay leased until the lease runs out, so a batch that
+	// only failed does not need an immediate follow-up.
+	return result.Purged
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/services"
+)
+
+var reaperMetrics = expvar.NewMap("upload_reaper")
+
+func runUploadReaper(ctx context.Context, reaper *services.UploadReaper, interval time.Duration, batchSize int) error {
+	return runBatches(ctx, "upload reaper", interval, batchSize, func(ctx context.Context) int {
+		return reapUploads(ctx, reaper)
+	})
+}
+
+func reapUploads(ctx context.Context, reaper *services.UploadReaper) int {
+	started := time.Now()
+	result, err := reaper.ReapBatch(ctx)
+
+	reaperMetrics.Add("runs", 1)
+	reaperMetrics.AddFloat("duration_seconds", time.Since(started).Seconds())
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("upload reaper: %v", err)
+			reaperMetrics.Add("errors", 1)
+		}
+		if result == nil {
+			return 0
+		}
+	}
+
+	reaperMetrics.Add("scanned", int64(result.Scanned))
+	reaperMetrics.Add("confirmed", int64(result.Confirmed))
+	reaperMetrics.Add("rejected", int64(result.Rejected))
+	reaperMetrics.Add("expired", int64(result.Expired))
+	reaperMetrics.Add("skipped", int64(result.Skipped))
+	reaperMetrics.Add("failed", int64(result.Failed))
+
+	if result.Scanned > 0 {
+		log.Printf("upload reaper: scanned %d, confirmed %d, rejected %d, expired %d, skipped %d, failed %d",
+			result.Scanned, result.Confirmed, result.Rejected, result.Expired, result.Skipped, result.Failed)
+	}
+
+	// Files that keep failing stay pending and would be listed again, so
+	// only count the ones that left the pending state towards a full batch.
+	return result.Scanned - result.Failed
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/services"
+)
+
+var scanBackfillMetrics = expvar.NewMap("scan_backfill")
+
+func runScanBackfill(ctx context.Context, fileService *services.FileService, interval time.Duration, batchSize int) error {
+	return runBatches(ctx, "scan backfill", interval, batchSize, func(ctx context.Context) int {
+		return queueUnscanned(ctx, fileService, batchSize)
+	})
+}
+
+func queueUnscanned(ctx context.Context, fileService *services.FileService, batchSize int) int {
+	queued, err := fileService.QueueUnscanned(ctx, batchSize)
+
+	scanBackfillMetrics.Add("runs", 1)
+	scanBackfillMetrics.Add("queued", int64(queued))
+	if err != nil && ctx.Err() == nil {
+		log.Printf("scan backfill: %v", err)
+		scanBackfillMetrics.Add("errors", 1)
+	}
+
+	if queued > 0 {
+		log.Printf("scan backfill: queued %d", queued)
+	}
+
+	// Queued files are no longer listed, so a full batch hints that more
+	// are waiting.
+	return queued
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runBatches calls run every interval until ctx is done. run reports how many
+// items it settled; a full batch means more work is likely waiting, so it is
+// followed by another batch straight away instead of waiting for the next tick.
+func runBatches(ctx context.Context, name string, interval time.Duration, batchSize int, run func(context.Context) int) error {
+	if interval <= 0 || batchSize <= 0 {
+		log.Printf("%s disabled", name)
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if run(ctx) >= batchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	clamavAdapter "github.com/gruzdev-dev/codex-files/adapters/clamav"
+	postgresAdapter "github.com/gruzdev-dev/codex-files/adapters/storage/postgres"
+	s3Adapter "github.com/gruzdev-dev/codex-files/adapters/storage/s3"
+	"github.com/gruzdev-dev/codex-files/configs"
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/services"
+	"github.com/gruzdev-dev/codex-files/internal/wiring"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var discrepancyKinds = []domain.DiscrepancyKind{
+	domain.DiscrepancyOrphanObject,
+	domain.DiscrepancyUnconfirmed,
+	domain.DiscrepancyMissingObject,
+	domain.DiscrepancySizeMismatch,
+}
+
+func main() {
+	tenantID := flag.String("tenant", "", "reconcile a single tenant instead of all of them")
+	owner := flag.String("owner", "", "reconcile a single owner prefix instead of the whole bucket")
+	fix := flag.String("fix", "", "comma-separated discrepancy kinds to repair, or \"all\"")
+	minAge := flag.Duration("min-age", time.Hour, "ignore orphan objects written more recently than this")
+	out := flag.String("out", "-", "file to write the JSON report to, \"-\" for stdout")
+	flag.Parse()
+
+	fixKinds, err := parseFix(*fix)
+	if err != nil {
+		log.Fatalf("Invalid -fix: %v", err)
+	}
+	if *tenantID != "" {
+		if err := domain.ValidateTenantID(*tenantID); err != nil {
+			log.Fatalf("Invalid -tenant: %v", err)
+		}
+	}
+
+	cfg, err := configs.NewConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer pool.Close()
+
+	fileProvider, err := s3Adapter.NewS3Provider(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create S3 provider: %v", err)
+	}
+
+	repo := postgresAdapter.NewFileRepo(pool)
+	fileService := wiring.NewFileService(repo, postgresAdapter.NewMultipartUploadRepo(pool), postgresAdapter.NewFileGrantRepo(pool), postgresAdapter.NewQuotaRepo(pool), postgresAdapter.NewJobRepo(pool), fileProvider, clamavAdapter.NewScanner(cfg), cfg)
+	reconciler := services.NewReconciler(fileService, repo, fileProvider)
+
+	report, err := reconciler.Reconcile(ctx, domain.ReconcileOptions{
+		TenantID:     *tenantID,
+		OwnerID:      *owner,
+		Fix:          fixKinds,
+		MinObjectAge: *minAge,
+	})
+	if err != nil {
+		log.Fatalf("Reconciliation failed: %v", err)
+	}
+
+	if err := writeReport(*out, report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	log.Printf("Reconciled %d tenants, %d owners, %d objects, %d files: %d discrepancies",
+		report.Tenants, report.Owners, report.Objects, report.Files, len(report.Discrepancies))
+}
+
+func parseFix(value string) (map[domain.DiscrepancyKind]bool, error) {
+	kinds := make(map[domain.DiscrepancyKind]bool)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+			continue
+		case name == "all":
+			for _, kind := range discrepancyKinds {
+				kinds[kind] = true
+			}
+		default:
+			kind := domain.DiscrepancyKind(name)
+			if !isDiscrepancyKind(kind) {
+				return nil, fmt.Errorf("unknown discrepancy kind %q", name)
+			}
+			kinds[kind] = true
+		}
+	}
+	return kinds, nil
+}
+
+func isDiscrepancyKind(kind domain.DiscrepancyKind) bool {
+	for _, known := range discrepancyKinds {
+		if kind == known {
+			return true
+		}
+	}
+	return false
+}
+
+func writeReport(path string, report *domain.ReconcileReport) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(newReportJSON(report))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+)
+
+type reportJSON struct {
+	StartedAt     time.Time         `json:"startedAt"`
+	FinishedAt    time.Time         `json:"finishedAt"`
+	Tenants       int               `json:"tenants"`
+	Owners        int               `json:"owners"`
+	Objects       int               `json:"objects"`
+	Files         int               `json:"files"`
+	Summary       map[string]int    `json:"summary"`
+	Discrepancies []discrepancyJSON `json:"discrepancies"`
+}
+
+type discrepancyJSON struct {
+	Kind         string `json:"kind"`
+	Key          string `json:"key"`
+	TenantID     string `json:"tenantId,omitempty"`
+	OwnerID      string `json:"ownerId,omitempty"`
+	FileID       string `json:"fileId,omitempty"`
+	Status       string `json:"status,omitempty"`
+	ExpectedSize int64  `json:"expectedSize,omitempty"`
+	ActualSize   int64  `json:"actualSize,omitempty"`
+	Fixed        bool   `json:"fixed"`
+	FixError     string `json:"fixError,omitempty"`
+}
+
+func newReportJSON(report *domain.ReconcileReport) reportJSON {
+	out := reportJSON{
+		StartedAt:     report.StartedAt,
+		FinishedAt:    report.FinishedAt,
+		Tenants:       report.Tenants,
+		Owners:        report.Owners,
+		Objects:       report.Objects,
+		Files:         report.Files,
+		Summary:       make(map[string]int),
+		Discrepancies: make([]discrepancyJSON, 0, len(report.Discrepancies)),
+	}
+
+	for _, d := range report.Discrepancies {
+		out.Summary[string(d.Kind)]++
+		out.Discrepancies = append(out.Discrepancies, discrepancyJSON{
+			Kind:         string(d.Kind),
+			Key:          d.Key,
+			TenantID:     d.TenantID,
+			OwnerID:      d.OwnerID,
+			FileID:       d.FileID,
+			Status:       string(d.Status),
+			ExpectedSize: d.ExpectedSize,
+			ActualSize:   d.ActualSize,
+			Fixed:        d.Fixed,
+			FixError:     d.FixError,
+		})
+	}
+
+	return out
+}
@@ -2,11 +2,19 @@ package configs
 
 import (
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	DownloadModeProxy    = "proxy"
+	DownloadModeRedirect = "redirect"
+)
+
 type Config struct {
 	HTTP struct {
 		Port string
@@ -17,6 +25,11 @@ type Config struct {
 	Auth struct {
 		JWTSecret      string
 		InternalSecret string
+		SMART          struct {
+			AuthorizeURL string
+			TokenURL     string
+			RevokeURL    string
+		}
 	}
 	DB struct {
 		Host     string
@@ -30,16 +43,75 @@ type Config struct {
 		AccessKey     string
 		SecretKey     string
 		Bucket        string
+		TenantBuckets map[string]string
 		ExternalHost  string
 		UseSSL        bool
 		WebhookSecret string
 	}
 	Upload struct {
-		MaxSize int64
-		TTL     time.Duration
+		MaxSize          int64
+		MultipartMaxSize int64
+		PartSize         int64
+		TTL              time.Duration
+		ResumableTTL     time.Duration
+		AllowedTypes     []string
+		DeniedTypes      []string
+		TypeMaxSizes     map[string]int64
 	}
 	Download struct {
-		TTL time.Duration
+		TTL  time.Duration
+		Mode string
+	}
+	Reaper struct {
+		Interval    time.Duration
+		BatchSize   int
+		GracePeriod time.Duration
+	}
+	Purge struct {
+		Retention time.Duration
+		Interval  time.Duration
+		BatchSize int
+		Lease     time.Duration
+	}
+	Share struct {
+		DefaultTTL time.Duration
+		MaxTTL     time.Duration
+	}
+	Jobs struct {
+		Interval    time.Duration
+		Workers     int
+		BatchSize   int
+		Lease       time.Duration
+		MaxAttempts int
+		BackoffBase time.Duration
+		BackoffMax  time.Duration
+		// Finished jobs are deleted once they are older than their
+		// retention; zero keeps them.
+		DoneRetention time.Duration
+		DeadRetention time.Duration
+	}
+	Thumbnail struct {
+		Sizes     []int
+		MaxPixels int64
+	}
+	Metadata struct {
+		StripTenants []string
+		StripOwners  []string
+		KeepOriginal bool
+	}
+	ClamAV struct {
+		Address string
+		Timeout time.Duration
+		// Files stored before scanning was enabled are queued for a scan in
+		// batches of BackfillBatchSize every BackfillInterval.
+		BackfillInterval  time.Duration
+		BackfillBatchSize int
+	}
+	Quota struct {
+		OwnerMaxBytes  int64
+		OwnerMaxFiles  int64
+		TenantMaxBytes int64
+		TenantMaxFiles int64
 	}
 }
 
@@ -62,6 +134,18 @@ func NewConfig() (*Config, error) {
 		cfg.Auth.InternalSecret = envInternalSecret
 	}
 
+	if envSMARTAuthorizeURL := os.Getenv("SMART_AUTHORIZE_URL"); envSMARTAuthorizeURL != "" {
+		cfg.Auth.SMART.AuthorizeURL = envSMARTAuthorizeURL
+	}
+
+	if envSMARTTokenURL := os.Getenv("SMART_TOKEN_URL"); envSMARTTokenURL != "" {
+		cfg.Auth.SMART.TokenURL = envSMARTTokenURL
+	}
+
+	if envSMARTRevokeURL := os.Getenv("SMART_REVOKE_URL"); envSMARTRevokeURL != "" {
+		cfg.Auth.SMART.RevokeURL = envSMARTRevokeURL
+	}
+
 	if envDBHost := os.Getenv("POSTGRES_HOST"); envDBHost != "" {
 		cfg.DB.Host = envDBHost
 	}
@@ -100,6 +184,14 @@ func NewConfig() (*Config, error) {
 		cfg.S3.Bucket = envS3Bucket
 	}
 
+	if envS3TenantBuckets := os.Getenv("S3_TENANT_BUCKETS"); envS3TenantBuckets != "" {
+		buckets, err := parseTenantBuckets(envS3TenantBuckets)
+		if err != nil {
+			return nil, err
+		}
+		cfg.S3.TenantBuckets = buckets
+	}
+
 	if envS3ExternalHost := os.Getenv("S3_EXTERNAL_HOST"); envS3ExternalHost != "" {
 		cfg.S3.ExternalHost = envS3ExternalHost
 	}
@@ -120,6 +212,22 @@ func NewConfig() (*Config, error) {
 		cfg.Upload.MaxSize = 100 * 1024 * 1024
 	}
 
+	if envMultipartMaxSize := os.Getenv("UPLOAD_MULTIPART_MAX_SIZE"); envMultipartMaxSize != "" {
+		if size, err := strconv.ParseInt(envMultipartMaxSize, 10, 64); err == nil {
+			cfg.Upload.MultipartMaxSize = size
+		}
+	} else {
+		cfg.Upload.MultipartMaxSize = 50 * 1024 * 1024 * 1024
+	}
+
+	if envPartSize := os.Getenv("UPLOAD_PART_SIZE"); envPartSize != "" {
+		if size, err := strconv.ParseInt(envPartSize, 10, 64); err == nil {
+			cfg.Upload.PartSize = size
+		}
+	} else {
+		cfg.Upload.PartSize = 64 * 1024 * 1024
+	}
+
 	if envUploadTTL := os.Getenv("UPLOAD_TTL"); envUploadTTL != "" {
 		if ttl, err := time.ParseDuration(envUploadTTL); err == nil {
 			cfg.Upload.TTL = ttl
@@ -128,6 +236,31 @@ func NewConfig() (*Config, error) {
 		cfg.Upload.TTL = 5 * time.Minute
 	}
 
+	if envResumableTTL := os.Getenv("UPLOAD_RESUMABLE_TTL"); envResumableTTL != "" {
+		if ttl, err := time.ParseDuration(envResumableTTL); err == nil {
+			cfg.Upload.ResumableTTL = ttl
+		}
+	} else {
+		cfg.Upload.ResumableTTL = 24 * time.Hour
+	}
+
+	// Every content type is accepted unless an allowlist or denylist is set.
+	if envAllowedTypes := os.Getenv("UPLOAD_ALLOWED_TYPES"); envAllowedTypes != "" {
+		cfg.Upload.AllowedTypes = parseList(envAllowedTypes)
+	}
+
+	if envDeniedTypes := os.Getenv("UPLOAD_DENIED_TYPES"); envDeniedTypes != "" {
+		cfg.Upload.DeniedTypes = parseList(envDeniedTypes)
+	}
+
+	if envTypeMaxSizes := os.Getenv("UPLOAD_TYPE_MAX_SIZES"); envTypeMaxSizes != "" {
+		sizes, err := parseTypeMaxSizes(envTypeMaxSizes)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Upload.TypeMaxSizes = sizes
+	}
+
 	if envDownloadTTL := os.Getenv("DOWNLOAD_TTL"); envDownloadTTL != "" {
 		if ttl, err := time.ParseDuration(envDownloadTTL); err == nil {
 			cfg.Download.TTL = ttl
@@ -136,10 +269,318 @@ func NewConfig() (*Config, error) {
 		cfg.Download.TTL = 15 * time.Minute
 	}
 
+	switch envDownloadMode := os.Getenv("DOWNLOAD_MODE"); envDownloadMode {
+	case "":
+		cfg.Download.Mode = DownloadModeProxy
+	case DownloadModeProxy, DownloadModeRedirect:
+		cfg.Download.Mode = envDownloadMode
+	default:
+		return nil, fmt.Errorf("invalid DOWNLOAD_MODE %q: must be %q or %q", envDownloadMode, DownloadModeProxy, DownloadModeRedirect)
+	}
+
+	if envReaperInterval := os.Getenv("REAPER_INTERVAL"); envReaperInterval != "" {
+		if interval, err := time.ParseDuration(envReaperInterval); err == nil {
+			cfg.Reaper.Interval = interval
+		}
+	} else {
+		cfg.Reaper.Interval = time.Minute
+	}
+
+	if envReaperBatchSize := os.Getenv("REAPER_BATCH_SIZE"); envReaperBatchSize != "" {
+		if size, err := strconv.Atoi(envReaperBatchSize); err == nil {
+			cfg.Reaper.BatchSize = size
+		}
+	} else {
+		cfg.Reaper.BatchSize = 100
+	}
+
+	if envReaperGracePeriod := os.Getenv("REAPER_GRACE_PERIOD"); envReaperGracePeriod != "" {
+		if grace, err := time.ParseDuration(envReaperGracePeriod); err == nil {
+			cfg.Reaper.GracePeriod = grace
+		}
+	} else {
+		cfg.Reaper.GracePeriod = 15 * time.Minute
+	}
+
+	if envPurgeRetention := os.Getenv("PURGE_RETENTION"); envPurgeRetention != "" {
+		if retention, err := time.ParseDuration(envPurgeRetention); err == nil {
+			cfg.Purge.Retention = retention
+		}
+	} else {
+		cfg.Purge.Retention = 30 * 24 * time.Hour
+	}
+
+	if envPurgeInterval := os.Getenv("PURGE_INTERVAL"); envPurgeInterval != "" {
+		if interval, err := time.ParseDuration(envPurgeInterval); err == nil {
+			cfg.Purge.Interval = interval
+		}
+	} else {
+		cfg.Purge.Interval = 10 * time.Minute
+	}
+
+	if envPurgeBatchSize := os.Getenv("PURGE_BATCH_SIZE"); envPurgeBatchSize != "" {
+		if size, err := strconv.Atoi(envPurgeBatchSize); err == nil {
+			cfg.Purge.BatchSize = size
+		}
+	} else {
+		cfg.Purge.BatchSize = 100
+	}
+
+	if envPurgeLease := os.Getenv("PURGE_LEASE"); envPurgeLease != "" {
+		if lease, err := time.ParseDuration(envPurgeLease); err == nil {
+			cfg.Purge.Lease = lease
+		}
+	} else {
+		cfg.Purge.Lease = 5 * time.Minute
+	}
+
+	if envShareDefaultTTL := os.Getenv("SHARE_DEFAULT_TTL"); envShareDefaultTTL != "" {
+		if ttl, err := time.ParseDuration(envShareDefaultTTL); err == nil {
+			cfg.Share.DefaultTTL = ttl
+		}
+	} else {
+		cfg.Share.DefaultTTL = 7 * 24 * time.Hour
+	}
+
+	if envShareMaxTTL := os.Getenv("SHARE_MAX_TTL"); envShareMaxTTL != "" {
+		if ttl, err := time.ParseDuration(envShareMaxTTL); err == nil {
+			cfg.Share.MaxTTL = ttl
+		}
+	} else {
+		cfg.Share.MaxTTL = 30 * 24 * time.Hour
+	}
+
+	if envJobsInterval := os.Getenv("JOBS_INTERVAL"); envJobsInterval != "" {
+		if interval, err := time.ParseDuration(envJobsInterval); err == nil {
+			cfg.Jobs.Interval = interval
+		}
+	} else {
+		cfg.Jobs.Interval = time.Second
+	}
+
+	if envJobsWorkers := os.Getenv("JOBS_WORKERS"); envJobsWorkers != "" {
+		if workers, err := strconv.Atoi(envJobsWorkers); err == nil {
+			cfg.Jobs.Workers = workers
+		}
+	} else {
+		cfg.Jobs.Workers = 4
+	}
+
+	if envJobsBatchSize := os.Getenv("JOBS_BATCH_SIZE"); envJobsBatchSize != "" {
+		if size, err := strconv.Atoi(envJobsBatchSize); err == nil {
+			cfg.Jobs.BatchSize = size
+		}
+	} else {
+		cfg.Jobs.BatchSize = 10
+	}
+
+	if envJobsLease := os.Getenv("JOBS_LEASE"); envJobsLease != "" {
+		if lease, err := time.ParseDuration(envJobsLease); err == nil {
+			cfg.Jobs.Lease = lease
+		}
+	} else {
+		cfg.Jobs.Lease = 10 * time.Minute
+	}
+
+	if envJobsMaxAttempts := os.Getenv("JOBS_MAX_ATTEMPTS"); envJobsMaxAttempts != "" {
+		if attempts, err := strconv.Atoi(envJobsMaxAttempts); err == nil {
+			cfg.Jobs.MaxAttempts = attempts
+		}
+	} else {
+		cfg.Jobs.MaxAttempts = 8
+	}
+
+	if envJobsBackoffBase := os.Getenv("JOBS_BACKOFF_BASE"); envJobsBackoffBase != "" {
+		if backoff, err := time.ParseDuration(envJobsBackoffBase); err == nil {
+			cfg.Jobs.BackoffBase = backoff
+		}
+	} else {
+		cfg.Jobs.BackoffBase = 10 * time.Second
+	}
+
+	if envJobsBackoffMax := os.Getenv("JOBS_BACKOFF_MAX"); envJobsBackoffMax != "" {
+		if backoff, err := time.ParseDuration(envJobsBackoffMax); err == nil {
+			cfg.Jobs.BackoffMax = backoff
+		}
+	} else {
+		cfg.Jobs.BackoffMax = time.Hour
+	}
+
+	if envJobsDoneRetention := os.Getenv("JOBS_DONE_RETENTION"); envJobsDoneRetention != "" {
+		if retention, err := time.ParseDuration(envJobsDoneRetention); err == nil {
+			cfg.Jobs.DoneRetention = retention
+		}
+	} else {
+		cfg.Jobs.DoneRetention = 24 * time.Hour
+	}
+
+	if envJobsDeadRetention := os.Getenv("JOBS_DEAD_RETENTION"); envJobsDeadRetention != "" {
+		if retention, err := time.ParseDuration(envJobsDeadRetention); err == nil {
+			cfg.Jobs.DeadRetention = retention
+		}
+	} else {
+		cfg.Jobs.DeadRetention = 30 * 24 * time.Hour
+	}
+
+	// Thumbnails are generated in the default sizes unless THUMBNAIL_SIZES
+	// is set, and "none" turns them off.
+	switch envThumbnailSizes := os.Getenv("THUMBNAIL_SIZES"); envThumbnailSizes {
+	case "":
+		cfg.Thumbnail.Sizes = []int{128, 256, 512}
+	case "none":
+	default:
+		sizes, err := parseThumbnailSizes(envThumbnailSizes)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Thumbnail.Sizes = sizes
+	}
+
+	if envThumbnailMaxPixels := os.Getenv("THUMBNAIL_MAX_PIXELS"); envThumbnailMaxPixels != "" {
+		if pixels, err := strconv.ParseInt(envThumbnailMaxPixels, 10, 64); err == nil {
+			cfg.Thumbnail.MaxPixels = pixels
+		}
+	} else {
+		cfg.Thumbnail.MaxPixels = 50_000_000
+	}
+
+	// Image metadata is kept unless tenants or owners are selected for
+	// stripping; "*" selects everyone.
+	if envMetadataStripTenants := os.Getenv("METADATA_STRIP_TENANTS"); envMetadataStripTenants != "" {
+		cfg.Metadata.StripTenants = parseList(envMetadataStripTenants)
+	}
+
+	if envMetadataStripOwners := os.Getenv("METADATA_STRIP_OWNERS"); envMetadataStripOwners != "" {
+		cfg.Metadata.StripOwners = parseList(envMetadataStripOwners)
+	}
+
+	cfg.Metadata.KeepOriginal = os.Getenv("METADATA_KEEP_ORIGINAL") == "true"
+
+	// Antivirus scanning is disabled unless a clamd address is set.
+	if envClamAVAddress := os.Getenv("CLAMAV_ADDRESS"); envClamAVAddress != "" {
+		cfg.ClamAV.Address = envClamAVAddress
+	}
+
+	if envClamAVTimeout := os.Getenv("CLAMAV_TIMEOUT"); envClamAVTimeout != "" {
+		if timeout, err := time.ParseDuration(envClamAVTimeout); err == nil {
+			cfg.ClamAV.Timeout = timeout
+		}
+	} else {
+		cfg.ClamAV.Timeout = 5 * time.Minute
+	}
+
+	if envClamAVBackfillInterval := os.Getenv("CLAMAV_BACKFILL_INTERVAL"); envClamAVBackfillInterval != "" {
+		if interval, err := time.ParseDuration(envClamAVBackfillInterval); err == nil {
+			cfg.ClamAV.BackfillInterval = interval
+		}
+	} else {
+		cfg.ClamAV.BackfillInterval = 10 * time.Minute
+	}
+
+	if envClamAVBackfillBatchSize := os.Getenv("CLAMAV_BACKFILL_BATCH_SIZE"); envClamAVBackfillBatchSize != "" {
+		if size, err := strconv.Atoi(envClamAVBackfillBatchSize); err == nil {
+			cfg.ClamAV.BackfillBatchSize = size
+		}
+	} else {
+		cfg.ClamAV.BackfillBatchSize = 100
+	}
+
+	// Quotas are unlimited unless set; overrides are stored in the database.
+	if envQuotaOwnerMaxBytes := os.Getenv("QUOTA_OWNER_MAX_BYTES"); envQuotaOwnerMaxBytes != "" {
+		if size, err := strconv.ParseInt(envQuotaOwnerMaxBytes, 10, 64); err == nil {
+			cfg.Quota.OwnerMaxBytes = size
+		}
+	}
+
+	if envQuotaOwnerMaxFiles := os.Getenv("QUOTA_OWNER_MAX_FILES"); envQuotaOwnerMaxFiles != "" {
+		if count, err := strconv.ParseInt(envQuotaOwnerMaxFiles, 10, 64); err == nil {
+			cfg.Quota.OwnerMaxFiles = count
+		}
+	}
+
+	if envQuotaTenantMaxBytes := os.Getenv("QUOTA_TENANT_MAX_BYTES"); envQuotaTenantMaxBytes != "" {
+		if size, err := strconv.ParseInt(envQuotaTenantMaxBytes, 10, 64); err == nil {
+			cfg.Quota.TenantMaxBytes = size
+		}
+	}
+
+	if envQuotaTenantMaxFiles := os.Getenv("QUOTA_TENANT_MAX_FILES"); envQuotaTenantMaxFiles != "" {
+		if count, err := strconv.ParseInt(envQuotaTenantMaxFiles, 10, 64); err == nil {
+			cfg.Quota.TenantMaxFiles = count
+		}
+	}
+
 	return &cfg, nil
 }
 
+// parseTenantBuckets reads a comma-separated list of tenant=bucket pairs.
+func parseTenantBuckets(value string) (map[string]string, error) {
+	buckets := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		tenantID, bucket, ok := strings.Cut(pair, "=")
+		tenantID, bucket = strings.TrimSpace(tenantID), strings.TrimSpace(bucket)
+		if !ok || tenantID == "" || bucket == "" {
+			return nil, fmt.Errorf("invalid S3_TENANT_BUCKETS entry %q: must be tenant=bucket", pair)
+		}
+		buckets[tenantID] = bucket
+	}
+	return buckets, nil
+}
+
+// parseList reads a comma-separated list, dropping empty entries.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseTypeMaxSizes reads a comma-separated list of type=bytes pairs, where
+// type is a media type or a type/* wildcard.
+func parseTypeMaxSizes(value string) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+	for _, pair := range parseList(value) {
+		contentType, size, ok := strings.Cut(pair, "=")
+		contentType = strings.TrimSpace(contentType)
+		maxSize, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+		if !ok || contentType == "" || err != nil || maxSize <= 0 {
+			return nil, fmt.Errorf("invalid UPLOAD_TYPE_MAX_SIZES entry %q: must be type=bytes", pair)
+		}
+		sizes[strings.ToLower(contentType)] = maxSize
+	}
+	return sizes, nil
+}
+
 func (c *Config) DatabaseURL() string {
 	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
 		c.DB.User, c.DB.Password, c.DB.Host, c.DB.Port, c.DB.Database)
 }
+
+// TenantIDs returns the tenants configured with a bucket of their own.
+func (c *Config) TenantIDs() []string {
+	return slices.Sorted(maps.Keys(c.S3.TenantBuckets))
+}
+
+// parseThumbnailSizes reads a comma-separated list of thumbnail sizes in
+// pixels, dropping duplicates.
+func parseThumbnailSizes(value string) ([]int, error) {
+	var sizes []int
+	for _, item := range parseList(value) {
+		size, err := strconv.Atoi(item)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid THUMBNAIL_SIZES entry %q: must be a positive number of pixels", item)
+		}
+		if !slices.Contains(sizes, size) {
+			sizes = append(sizes, size)
+		}
+	}
+	slices.Sort(sizes)
+	return sizes, nil
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+)
+
+type ChecksumAlgorithm string
+
+const (
+	ChecksumSHA1   ChecksumAlgorithm = "sha1"
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+)
+
+type Checksum struct {
+	Algorithm ChecksumAlgorithm
+	Value     []byte
+}
+
+func (a ChecksumAlgorithm) New() (hash.Hash, error) {
+	switch a {
+	case ChecksumSHA1:
+		return sha1.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported checksum algorithm %q", ErrInvalidInput, a)
+	}
+}
+
+func (c Checksum) IsZero() bool {
+	return c.Algorithm == ""
+}
+
+func (c Checksum) Validate() error {
+	h, err := c.Algorithm.New()
+	if err != nil {
+		return err
+	}
+	if len(c.Value) != h.Size() {
+		return fmt.Errorf("%w: %s checksum must be %d bytes", ErrInvalidInput, c.Algorithm, h.Size())
+	}
+	return nil
+}
+
+func (c Checksum) Matches(sum []byte) bool {
+	return bytes.Equal(c.Value, sum)
+}
+
+func (c Checksum) Base64() string {
+	return base64.StdEncoding.EncodeToString(c.Value)
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// ContentPolicy decides which media types may be stored and how large each
+// may be. Patterns are exact media types or "type/*" for a whole top-level
+// type.
+type ContentPolicy struct {
+	// Allowed lists the accepted types; when empty every type not denied is
+	// accepted.
+	Allowed []string
+	// Denied lists types that are refused even when allowed.
+	Denied []string
+	// MaxSizes caps the size of matching types below the general limit. The
+	// exact type takes precedence over its wildcard.
+	MaxSizes map[string]int64
+}
+
+// Check returns ErrContentTypeNotAllowed when the type may not be stored and
+// ErrFileTooLarge when size goes over the limit for the type. A negative size
+// only checks the type.
+func (p ContentPolicy) Check(contentType string, size int64) error {
+	mediaType := MediaType(contentType)
+	if matchesAny(p.Denied, mediaType) || (len(p.Allowed) > 0 && !matchesAny(p.Allowed, mediaType)) {
+		return fmt.Errorf("%w: %s", ErrContentTypeNotAllowed, mediaType)
+	}
+	if limit := p.MaxSize(mediaType); limit > 0 && size > limit {
+		return fmt.Errorf("%w: %s files may not exceed %d bytes", ErrFileTooLarge, mediaType, limit)
+	}
+	return nil
+}
+
+// MaxSize returns the size limit for the type, zero when only the general
+// limit applies.
+func (p ContentPolicy) MaxSize(contentType string) int64 {
+	mediaType := MediaType(contentType)
+	if limit, ok := p.MaxSizes[mediaType]; ok {
+		return limit
+	}
+	if major, _, ok := strings.Cut(mediaType, "/"); ok {
+		return p.MaxSizes[major+"/*"]
+	}
+	return 0
+}
+
+func matchesAny(patterns []string, mediaType string) bool {
+	major, _, _ := strings.Cut(mediaType, "/")
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == mediaType || pattern == major+"/*" || pattern == "*/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// MediaType strips parameters from a Content-Type value and lowercases it.
+func MediaType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// signatureTypes are the declared types whose content starts with a magic
+// number that sniffing always recognises.
+var signatureTypes = map[string]bool{
+	"application/gzip":  true,
+	"application/ogg":   true,
+	"application/pdf":   true,
+	"application/wasm":  true,
+	"application/x-rar": true,
+	"application/zip":   true,
+	"audio/mpeg":        true,
+	"audio/wave":        true,
+	"font/woff":         true,
+	"font/woff2":        true,
+	"image/bmp":         true,
+	"image/gif":         true,
+	"image/jpeg":        true,
+	"image/png":         true,
+	"image/webp":        true,
+	"image/x-icon":      true,
+	"video/mp4":         true,
+	"video/webm":        true,
+}
+
+// sniffAliases maps the names sniffing reports to the registered names
+// clients declare.
+var sniffAliases = map[string]string{
+	"application/x-gzip":           "application/gzip",
+	"application/x-rar-compressed": "application/x-rar",
+	"audio/wav":                    "audio/wave",
+	"image/jpg":                    "image/jpeg",
+	"image/vnd.microsoft.icon":     "image/x-icon",
+}
+
+// SniffedTypeMatches reports whether content sniffed as sniffed may be
+// stored under the declared type. Generic results only rule out types with
+// a signature of their own, while a specific result must agree with the
+// declared type or a format built on it. Opaque binary accepts anything.
+func SniffedTypeMatches(declared, sniffed string) bool {
+	declared, sniffed = canonicalType(declared), canonicalType(sniffed)
+	if declared == sniffed || declared == "application/octet-stream" {
+		return true
+	}
+
+	switch sniffed {
+	case "application/octet-stream", "text/plain":
+		return !signatureTypes[declared]
+	case "text/xml":
+		return declared == "application/xml" || strings.HasSuffix(declared, "+xml")
+	case "application/zip":
+		// Office documents, EPUB and Java archives are ZIP containers.
+		return strings.HasSuffix(declared, "+zip") ||
+			strings.HasPrefix(declared, "application/vnd.") ||
+			declared == "application/java-archive"
+	}
+	return false
+}
+
+func canonicalType(contentType string) string {
+	mediaType := MediaType(contentType)
+	if alias, ok := sniffAliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
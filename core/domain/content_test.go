@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentPolicy_Check(t *testing.T) {
+	policy := ContentPolicy{
+		Allowed:  []string{"application/pdf", "image/*"},
+		Denied:   []string{"image/svg+xml"},
+		MaxSizes: map[string]int64{"image/*": 100, "image/png": 200},
+	}
+
+	tests := []struct {
+		name        string
+		policy      ContentPolicy
+		contentType string
+		size        int64
+		wantErr     error
+	}{
+		{name: "empty policy accepts anything", contentType: "application/x-msdownload", size: 1 << 30},
+		{name: "allowed type", policy: policy, contentType: "application/pdf", size: 1 << 30},
+		{name: "parameters are ignored", policy: policy, contentType: "Application/PDF; charset=binary", size: 1},
+		{name: "wildcard allows", policy: policy, contentType: "image/gif", size: 100},
+		{name: "not allowed", policy: policy, contentType: "application/x-msdownload", size: 1, wantErr: ErrContentTypeNotAllowed},
+		{name: "denied wins over allowed", policy: policy, contentType: "image/svg+xml", size: 1, wantErr: ErrContentTypeNotAllowed},
+		{name: "wildcard limit", policy: policy, contentType: "image/gif", size: 101, wantErr: ErrFileTooLarge},
+		{name: "exact limit wins over wildcard", policy: policy, contentType: "image/png", size: 200},
+		{name: "unknown size skips limit", policy: policy, contentType: "image/gif", size: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.contentType, tt.size)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSniffedTypeMatches(t *testing.T) {
+	tests := []struct {
+		declared string
+		sniffed  string
+		want     bool
+	}{
+		{declared: "image/png", sniffed: "image/png", want: true},
+		{declared: "image/jpg", sniffed: "image/jpeg", want: true},
+		{declared: "application/pdf", sniffed: "application/pdf", want: true},
+		{declared: "text/csv", sniffed: "text/plain; charset=utf-8", want: true},
+		{declared: "application/json", sniffed: "text/plain; charset=utf-8", want: true},
+		{declared: "application/octet-stream", sniffed: "image/png", want: true},
+		{declared: "image/svg+xml", sniffed: "text/xml; charset=utf-8", want: true},
+		{declared: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", sniffed: "application/zip", want: true},
+		{declared: "image/png", sniffed: "application/octet-stream", want: false},
+		{declared: "image/png", sniffed: "text/plain; charset=utf-8", want: false},
+		{declared: "image/png", sniffed: "image/gif", want: false},
+		{declared: "text/plain", sniffed: "text/html; charset=utf-8", want: false},
+		{declared: "application/pdf", sniffed: "application/zip", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.declared+" as "+tt.sniffed, func(t *testing.T) {
+			assert.Equal(t, tt.want, SniffedTypeMatches(tt.declared, tt.sniffed))
+		})
+	}
+}
@@ -3,9 +3,26 @@ package domain
 import "errors"
 
 var (
-	ErrFileNotFound   = errors.New("file not found")
-	ErrFileIDRequired = errors.New("file id is required")
-	ErrAccessDenied   = errors.New("access denied")
-	ErrInvalidInput   = errors.New("invalid input data")
-	ErrInternal       = errors.New("internal server error")
+	ErrFileNotFound          = errors.New("file not found")
+	ErrObjectNotFound        = errors.New("object not found")
+	ErrUploadNotFound        = errors.New("upload not found")
+	ErrGrantNotFound         = errors.New("grant not found")
+	ErrShareNotFound         = errors.New("share link not found")
+	ErrJobNotFound           = errors.New("job not found")
+	ErrThumbnailNotFound     = errors.New("thumbnail not found")
+	ErrShareExpired          = errors.New("share link is no longer valid")
+	ErrJobLeaseLost          = errors.New("job lease was lost")
+	ErrUploadConflict        = errors.New("upload offset does not match")
+	ErrUploadExpired         = errors.New("upload has expired")
+	ErrRestoreExpired        = errors.New("restore window has expired")
+	ErrChecksumMismatch      = errors.New("checksum mismatch")
+	ErrFileIDRequired        = errors.New("file id is required")
+	ErrAccessDenied          = errors.New("access denied")
+	ErrUnauthenticated       = errors.New("authentication required")
+	ErrInvalidInput          = errors.New("invalid input data")
+	ErrFileTooLarge          = errors.New("file size exceeds maximum allowed size")
+	ErrQuotaExceeded         = errors.New("storage quota exceeded")
+	ErrContentTypeNotAllowed = errors.New("content type is not allowed")
+	ErrContentMismatch       = errors.New("content does not match declared content type")
+	ErrInternal              = errors.New("internal server error")
 )
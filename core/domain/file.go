@@ -1,7 +1,7 @@
 package domain
 
 import (
-	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,31 +10,58 @@ import (
 type FileStatus string
 
 const (
-	FileStatusPending  FileStatus = "pending"
-	FileStatusUploaded FileStatus = "uploaded"
+	FileStatusPending     FileStatus = "pending"
+	FileStatusUploaded    FileStatus = "uploaded"
+	FileStatusProcessing  FileStatus = "processing"
+	FileStatusScanning    FileStatus = "scanning"
+	FileStatusClean       FileStatus = "clean"
+	FileStatusQuarantined FileStatus = "quarantined"
+	FileStatusCorrupt     FileStatus = "corrupt"
+	FileStatusRejected    FileStatus = "rejected"
+	FileStatusExpired     FileStatus = "expired"
+	FileStatusPurged      FileStatus = "purged"
+	FileStatusMissing     FileStatus = "missing"
 )
 
+// StoredStatuses are the statuses of files whose content has landed in the
+// bucket and been accepted, whatever the outcome of the processing and the
+// antivirus scan.
+var StoredStatuses = []FileStatus{
+	FileStatusUploaded,
+	FileStatusProcessing,
+	FileStatusScanning,
+	FileStatusClean,
+	FileStatusQuarantined,
+}
+
 type File struct {
-	ID          string
-	OwnerID     string
-	S3Path      string
-	Size        int64
-	ContentType string
-	Status      FileStatus
-	IsDeleted   bool
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-}
-
-func NewFile(ownerID, contentType string, size int64) *File {
+	ID              string
+	TenantID        string
+	OwnerID         string
+	S3Path          string
+	Size            int64
+	ContentType     string
+	SecurityContext string
+	Checksum        Checksum
+	ETag            string
+	Status          FileStatus
+	ScanSignature   string
+	ScannedAt       time.Time
+	IsDeleted       bool
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       time.Time
+}
+
+func NewFile(tenantID, ownerID, contentType string, size int64) *File {
 	now := time.Now()
 	id := uuid.New().String()
 
-	s3Path := fmt.Sprintf("%s/%s", ownerID, id)
 	return &File{
 		ID:          id,
+		TenantID:    tenantID,
 		OwnerID:     ownerID,
-		S3Path:      s3Path,
+		S3Path:      ObjectKey(tenantID, ownerID, id),
 		ContentType: contentType,
 		Size:        size,
 		Status:      FileStatusPending,
@@ -49,9 +76,88 @@ func (f *File) MarkAsUploaded() {
 	f.UpdatedAt = time.Now()
 }
 
+// MarkAsProcessing queues the content for rewriting, such as the stripping of
+// image metadata, before it is scanned or handed out.
+func (f *File) MarkAsProcessing() {
+	f.Status = FileStatusProcessing
+	f.UpdatedAt = time.Now()
+}
+
+// MarkAsStripped records the content rewritten without its metadata.
+func (f *File) MarkAsStripped(size int64, checksum Checksum) {
+	f.Size = size
+	f.Checksum = checksum
+	f.UpdatedAt = time.Now()
+}
+
+// MarkAsScanning queues the content for an antivirus scan. The verdict on
+// any earlier content no longer applies.
+func (f *File) MarkAsScanning() {
+	f.Status = FileStatusScanning
+	f.ScanSignature = ""
+	f.ScannedAt = time.Time{}
+	f.UpdatedAt = time.Now()
+}
+
+// MarkAsScanned records the outcome of an antivirus scan: the file is clean,
+// quarantined under the signature that was found, or rejected when the
+// scanner refused its content.
+func (f *File) MarkAsScanned(result ScanResult) {
+	now := time.Now()
+	switch {
+	case result.Infected:
+		f.Status = FileStatusQuarantined
+	case result.Refused != "":
+		f.Status = FileStatusRejected
+	default:
+		f.Status = FileStatusClean
+	}
+	f.ScanSignature = result.Signature
+	f.ScannedAt = now
+	f.UpdatedAt = now
+}
+
+// HasContent reports whether the content of the file has been stored and
+// accepted.
+func (f *File) HasContent() bool {
+	return slices.Contains(StoredStatuses, f.Status)
+}
+
+func (f *File) MarkAsCorrupt() {
+	f.Status = FileStatusCorrupt
+	f.UpdatedAt = time.Now()
+}
+
+func (f *File) MarkAsRejected() {
+	f.Status = FileStatusRejected
+	f.UpdatedAt = time.Now()
+}
+
+func (f *File) MarkAsExpired() {
+	f.Status = FileStatusExpired
+	f.UpdatedAt = time.Now()
+}
+
+func (f *File) MarkAsMissing() {
+	f.Status = FileStatusMissing
+	f.UpdatedAt = time.Now()
+}
+
 func (f *File) MarkAsDeleted() {
+	now := time.Now()
 	f.IsDeleted = true
-	f.UpdatedAt = time.Now()
+	f.UpdatedAt = now
+	f.DeletedAt = now
+}
+
+// ScanResult is the verdict of an antivirus scan. Signature names what was
+// found in infected content.
+type ScanResult struct {
+	Infected  bool
+	Signature string
+	// Refused is the reason the scanner gave for not scanning the content at
+	// all, such as its size limit. Another attempt would fail the same way.
+	Refused string
 }
 
 type GetDownloadURLResult struct {
@@ -59,7 +165,34 @@ type GetDownloadURLResult struct {
 }
 
 type GenerateUploadURLResult struct {
-	FileID      string
-	UploadURL   string
-	DownloadURL string
+	FileID        string
+	UploadURL     string
+	UploadHeaders map[string]string
+	DownloadURL   string
+}
+
+// UploadCutoff marks, per upload flow, the point before which a pending upload
+// is considered abandoned.
+type UploadCutoff struct {
+	// Pending applies to single-request uploads, by file creation time.
+	Pending time.Time
+	// Multipart applies to multipart uploads, by upload creation time.
+	Multipart time.Time
+	// Resumable applies to resumable uploads, by their sliding expiry.
+	Resumable time.Time
+}
+
+type ReapUploadsResult struct {
+	Scanned   int
+	Confirmed int
+	Rejected  int
+	Expired   int
+	Skipped   int
+	Failed    int
+}
+
+type PurgeFilesResult struct {
+	Claimed int
+	Purged  int
+	Failed  int
 }
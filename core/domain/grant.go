@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+type GranteeType string
+
+const (
+	GranteeUser  GranteeType = "user"
+	GranteeGroup GranteeType = "group"
+)
+
+type Grantee struct {
+	Type GranteeType
+	ID   string
+}
+
+// FileGrant gives a user or a group one permission on a single file. A zero
+// ExpiresAt means the grant lasts until it is revoked.
+type FileGrant struct {
+	FileID     string
+	Grantee    Grantee
+	Permission Permission
+	ExpiresAt  time.Time
+	GrantedBy  string
+	CreatedAt  time.Time
+}
+
+func (g *FileGrant) Validate(now time.Time) error {
+	switch g.Grantee.Type {
+	case GranteeUser, GranteeGroup:
+	default:
+		return fmt.Errorf("%w: grantee type must be %q or %q", ErrInvalidInput, GranteeUser, GranteeGroup)
+	}
+	if g.Grantee.ID == "" {
+		return fmt.Errorf("%w: grantee ID is required", ErrInvalidInput)
+	}
+	switch g.Permission {
+	case PermissionRead, PermissionWrite, PermissionDelete:
+	default:
+		return fmt.Errorf("%w: unknown permission %q", ErrInvalidInput, g.Permission)
+	}
+	if !g.ExpiresAt.IsZero() && !g.ExpiresAt.After(now) {
+		return fmt.Errorf("%w: grant expiry must be in the future", ErrInvalidInput)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+type FileAction string
+
+const (
+	FileActionRestored FileAction = "restored"
+)
+
+// FileEvent is an entry in a file's history.
+type FileEvent struct {
+	FileID    string
+	Action    FileAction
+	ActorID   string
+	CreatedAt time.Time
+}
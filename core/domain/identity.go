@@ -1,12 +1,61 @@
 package domain
 
-import "slices"
+import (
+	"fmt"
+	"slices"
+)
 
 type Identity struct {
-	UserID string
-	Scopes []string
+	UserID      string
+	Groups      []string
+	Scopes      []string
+	FHIRContext []string
+	Patient     string
 }
 
 func (i *Identity) HasScope(scope string) bool {
 	return slices.Contains(i.Scopes, scope)
 }
+
+func (i *Identity) CanAccessResource(reference string, permission Permission) bool {
+	if reference == "" {
+		return false
+	}
+
+	if i.HasScope(fmt.Sprintf("files:%s:%s", reference, permission)) {
+		return true
+	}
+
+	return permission == PermissionRead && slices.Contains(i.FHIRContext, reference)
+}
+
+func (i *Identity) SMARTScopes() []SMARTScope {
+	var scopes []SMARTScope
+	for _, raw := range i.Scopes {
+		if scope, ok := ParseSMARTScope(raw); ok {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func (i *Identity) PatientReference() string {
+	if i.Patient == "" {
+		return ""
+	}
+	return "Patient/" + i.Patient
+}
+
+// Grantees lists the user and groups a file grant can be addressed to.
+func (i *Identity) Grantees() []Grantee {
+	var grantees []Grantee
+	if i.UserID != "" {
+		grantees = append(grantees, Grantee{Type: GranteeUser, ID: i.UserID})
+	}
+	for _, group := range i.Groups {
+		if group != "" {
+			grantees = append(grantees, Grantee{Type: GranteeGroup, ID: group})
+		}
+	}
+	return grantees
+}
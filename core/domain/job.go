@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobKind names the processor a job is handed to.
+type JobKind string
+
+const (
+	JobKindConfirmUpload JobKind = "confirm_upload"
+	JobKindScanFile      JobKind = "scan_file"
+	JobKindThumbnails    JobKind = "thumbnails"
+	JobKindStripMetadata JobKind = "strip_metadata"
+)
+
+type JobStatus string
+
+const (
+	JobStatusQueued  JobStatus = "queued"
+	JobStatusRunning JobStatus = "running"
+	JobStatusDone    JobStatus = "done"
+	// JobStatusDead is the dead-letter state of jobs that used up their
+	// attempts. They are kept for inspection and never run again.
+	JobStatusDead JobStatus = "dead"
+)
+
+// Job is a unit of post-upload work. Jobs are claimed by workers under a
+// lease, so a job whose worker died becomes claimable again once the lease
+// runs out.
+type Job struct {
+	ID          string
+	Kind        JobKind
+	FileID      string
+	Payload     map[string]string
+	Status      JobStatus
+	Attempts    int
+	RunAt       time.Time
+	LockedUntil time.Time
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func NewJob(kind JobKind, fileID string, payload map[string]string) *Job {
+	now := time.Now()
+
+	return &Job{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		FileID:    fileID,
+		Payload:   payload,
+		Status:    JobStatusQueued,
+		RunAt:     now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (j *Job) MarkAsDone() {
+	j.Status = JobStatusDone
+	j.LockedUntil = time.Time{}
+	j.LastError = ""
+	j.UpdatedAt = time.Now()
+}
+
+// MarkAsFailed schedules the next attempt after the backoff, or moves the job
+// to the dead-letter state once maxAttempts have been made.
+func (j *Job) MarkAsFailed(err error, backoff JobBackoff, maxAttempts int) {
+	now := time.Now()
+	j.LastError = err.Error()
+	j.LockedUntil = time.Time{}
+	j.UpdatedAt = now
+
+	if j.Attempts >= maxAttempts {
+		j.Status = JobStatusDead
+		return
+	}
+	j.Status = JobStatusQueued
+	j.RunAt = now.Add(backoff.Delay(j.Attempts))
+}
+
+// JobBackoff doubles the delay after every failed attempt, starting at Base
+// and never going over Max.
+type JobBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns how long to wait after the given number of attempts.
+func (b JobBackoff) Delay(attempts int) time.Duration {
+	delay := b.Base
+	for i := 1; i < attempts && delay < b.Max; i++ {
+		delay *= 2
+	}
+	return min(delay, b.Max)
+}
+
+type RunJobsResult struct {
+	Claimed int
+	Done    int
+	Retried int
+	Dead    int
+	// Lost counts jobs whose lease ran out before their outcome could be
+	// recorded. They run again under a new lease.
+	Lost int
+}
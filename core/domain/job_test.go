@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobBackoff_Delay(t *testing.T) {
+	backoff := JobBackoff{Base: time.Second, Max: 10 * time.Second}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 5, want: 10 * time.Second},
+		{attempts: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, backoff.Delay(tt.attempts), "attempts %d", tt.attempts)
+	}
+}
+
+func TestJob_MarkAsFailed(t *testing.T) {
+	backoff := JobBackoff{Base: time.Minute, Max: time.Hour}
+
+	t.Run("attempts left - retried after backoff", func(t *testing.T) {
+		job := NewJob(JobKindScanFile, "file-id", nil)
+		job.Attempts = 2
+
+		job.MarkAsFailed(errors.New("clamd unavailable"), backoff, 3)
+
+		assert.Equal(t, JobStatusQueued, job.Status)
+		assert.Equal(t, "clamd unavailable", job.LastError)
+		assert.WithinDuration(t, time.Now().Add(2*time.Minute), job.RunAt, time.Second)
+	})
+
+	t.Run("attempts used up - dead", func(t *testing.T) {
+		job := NewJob(JobKindScanFile, "file-id", nil)
+		job.Attempts = 3
+
+		job.MarkAsFailed(errors.New("clamd unavailable"), backoff, 3)
+
+		assert.Equal(t, JobStatusDead, job.Status)
+		assert.Equal(t, "clamd unavailable", job.LastError)
+	})
+}
@@ -0,0 +1,48 @@
+package domain
+
+import "slices"
+
+// PolicyEveryone selects every tenant or owner in a MetadataPolicy.
+const PolicyEveryone = "*"
+
+// originalKeySuffix marks the key of the original kept next to a file whose
+// content was rewritten.
+const originalKeySuffix = ".original"
+
+// metadataSourceTypes are the image types whose metadata can be stripped.
+var metadataSourceTypes = []string{"image/jpeg", "image/png"}
+
+// MetadataPolicy selects the images whose embedded metadata, such as EXIF,
+// XMP and GPS coordinates, is stripped after upload.
+type MetadataPolicy struct {
+	Tenants []string
+	Owners  []string
+	// KeepOriginal stores the content as uploaded next to the stripped file.
+	KeepOriginal bool
+}
+
+// Applies reports whether the metadata of the file is to be stripped.
+func (p MetadataPolicy) Applies(file *File) bool {
+	if !IsMetadataSource(file.ContentType) {
+		return false
+	}
+
+	tenantID := file.TenantID
+	if tenantID == "" {
+		tenantID = DefaultTenantID
+	}
+	return slices.Contains(p.Tenants, PolicyEveryone) || slices.Contains(p.Tenants, tenantID) ||
+		slices.Contains(p.Owners, PolicyEveryone) || slices.Contains(p.Owners, file.OwnerID)
+}
+
+// IsMetadataSource reports whether metadata can be stripped from content of
+// the given type.
+func IsMetadataSource(contentType string) bool {
+	return slices.Contains(metadataSourceTypes, canonicalType(contentType))
+}
+
+// OriginalKey returns the key under which the original of the object at key
+// is kept.
+func OriginalKey(key string) string {
+	return key + originalKeySuffix
+}
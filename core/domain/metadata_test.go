@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataPolicy_Applies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   MetadataPolicy
+		file     File
+		expected bool
+	}{
+		{
+			name:     "no policy",
+			file:     File{TenantID: "acme", OwnerID: "user-1", ContentType: "image/jpeg"},
+			expected: false,
+		},
+		{
+			name:     "tenant selected",
+			policy:   MetadataPolicy{Tenants: []string{"acme"}},
+			file:     File{TenantID: "acme", OwnerID: "user-1", ContentType: "image/jpeg"},
+			expected: true,
+		},
+		{
+			name:     "other tenant",
+			policy:   MetadataPolicy{Tenants: []string{"acme"}},
+			file:     File{TenantID: "globex", OwnerID: "user-1", ContentType: "image/jpeg"},
+			expected: false,
+		},
+		{
+			name:     "file without tenant belongs to the default tenant",
+			policy:   MetadataPolicy{Tenants: []string{DefaultTenantID}},
+			file:     File{OwnerID: "user-1", ContentType: "image/png"},
+			expected: true,
+		},
+		{
+			name:     "owner selected",
+			policy:   MetadataPolicy{Owners: []string{"user-1"}},
+			file:     File{TenantID: "globex", OwnerID: "user-1", ContentType: "image/png"},
+			expected: true,
+		},
+		{
+			name:     "everyone",
+			policy:   MetadataPolicy{Owners: []string{PolicyEveryone}},
+			file:     File{TenantID: "globex", OwnerID: "user-2", ContentType: "image/jpeg; charset=binary"},
+			expected: true,
+		},
+		{
+			name:     "not a supported image",
+			policy:   MetadataPolicy{Tenants: []string{PolicyEveryone}},
+			file:     File{TenantID: "acme", OwnerID: "user-1", ContentType: "image/gif"},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.Applies(&tt.file))
+		})
+	}
+}
+
+func TestParentObjectKey(t *testing.T) {
+	tests := []struct {
+		key            string
+		expectedParent string
+		expectedOK     bool
+	}{
+		{key: ThumbnailKey("acme/user-1/file-1", 256), expectedParent: "acme/user-1/file-1", expectedOK: true},
+		{key: OriginalKey("acme/user-1/file-1"), expectedParent: "acme/user-1/file-1", expectedOK: true},
+		{key: "acme/user-1/file-1", expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			parent, ok := ParentObjectKey(tt.key)
+			assert.Equal(t, tt.expectedOK, ok)
+			if ok {
+				assert.Equal(t, tt.expectedParent, parent)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+const (
+	MultipartMinPartSize = int64(5 * 1024 * 1024)
+	MultipartMaxParts    = 10000
+)
+
+type MultipartUpload struct {
+	FileID    string
+	UploadID  string
+	PartSize  int64
+	PartCount int
+	CreatedAt time.Time
+}
+
+type UploadPart struct {
+	PartNumber int
+	ETag       string
+}
+
+type PartUploadURL struct {
+	PartNumber int
+	UploadURL  string
+}
+
+type InitiateMultipartUploadResult struct {
+	FileID      string
+	PartSize    int64
+	PartCount   int
+	DownloadURL string
+}
+
+func PlanMultipartUpload(size, preferredPartSize int64) (partSize int64, partCount int) {
+	partSize = max(preferredPartSize, MultipartMinPartSize)
+	if minPartSize := (size + MultipartMaxParts - 1) / MultipartMaxParts; partSize < minPartSize {
+		const mebibyte = 1024 * 1024
+		partSize = (minPartSize + mebibyte - 1) / mebibyte * mebibyte
+	}
+
+	partCount = int((size + partSize - 1) / partSize)
+	return partSize, partCount
+}
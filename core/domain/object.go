@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// ObjectInfo describes an object as the store reports it, independent of what
+// the client declared when the upload was requested.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// ParentObjectKey returns the key of the file a derived object belongs to,
+// such as a thumbnail or a kept original, and false for any other key.
+func ParentObjectKey(key string) (string, bool) {
+	if parent, _, ok := strings.Cut(key, thumbnailKeyInfix); ok {
+		return parent, true
+	}
+	return strings.CutSuffix(key, originalKeySuffix)
+}
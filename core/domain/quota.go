@@ -0,0 +1,66 @@
+package domain
+
+import "fmt"
+
+type QuotaScope string
+
+const (
+	QuotaScopeOwner  QuotaScope = "owner"
+	QuotaScopeTenant QuotaScope = "tenant"
+)
+
+// Quota caps the storage of an owner within a tenant, or of a whole tenant
+// when OwnerID is empty. A zero limit is unlimited.
+type Quota struct {
+	Scope    QuotaScope
+	TenantID string
+	OwnerID  string
+	MaxBytes int64
+	MaxFiles int64
+}
+
+func (q Quota) Unlimited() bool {
+	return q.MaxBytes <= 0 && q.MaxFiles <= 0
+}
+
+// Check reports whether one more file of size fits in the quota on top of
+// usage. Pending uploads count in full, as their space is reserved.
+func (q Quota) Check(usage Usage, size int64) error {
+	if q.MaxFiles > 0 && usage.TotalFiles()+1 > q.MaxFiles {
+		return fmt.Errorf("%w: %s file limit of %d reached", ErrQuotaExceeded, q.Scope, q.MaxFiles)
+	}
+	if q.MaxBytes > 0 && usage.TotalBytes()+size > q.MaxBytes {
+		return fmt.Errorf("%w: %s storage limit of %d bytes would be exceeded", ErrQuotaExceeded, q.Scope, q.MaxBytes)
+	}
+	return nil
+}
+
+// CheckGrowth reports whether a stored file can grow by delta bytes within
+// the quota on top of usage.
+func (q Quota) CheckGrowth(usage Usage, delta int64) error {
+	if q.MaxBytes > 0 && usage.TotalBytes()+delta > q.MaxBytes {
+		return fmt.Errorf("%w: %s storage limit of %d bytes would be exceeded", ErrQuotaExceeded, q.Scope, q.MaxBytes)
+	}
+	return nil
+}
+
+// Usage is the storage taken by uploaded files and reserved by pending ones.
+type Usage struct {
+	Files         int64
+	Bytes         int64
+	ReservedFiles int64
+	ReservedBytes int64
+}
+
+func (u Usage) TotalFiles() int64 {
+	return u.Files + u.ReservedFiles
+}
+
+func (u Usage) TotalBytes() int64 {
+	return u.Bytes + u.ReservedBytes
+}
+
+type QuotaUsage struct {
+	Quota Quota
+	Usage Usage
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuota_Check(t *testing.T) {
+	usage := Usage{Files: 2, Bytes: 200, ReservedFiles: 1, ReservedBytes: 100}
+
+	tests := []struct {
+		name    string
+		quota   Quota
+		size    int64
+		wantErr bool
+	}{
+		{name: "unlimited", quota: Quota{}, size: 1 << 40},
+		{name: "fits", quota: Quota{MaxBytes: 400, MaxFiles: 4}, size: 100},
+		{name: "reserved bytes count", quota: Quota{MaxBytes: 350}, size: 100, wantErr: true},
+		{name: "reserved files count", quota: Quota{MaxFiles: 3}, size: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.quota.Check(usage, tt.size)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrQuotaExceeded)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
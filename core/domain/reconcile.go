@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyOrphanObject is an object with no live file row behind it.
+	DiscrepancyOrphanObject DiscrepancyKind = "orphan_object"
+	// DiscrepancyUnconfirmed is a pending file whose object has landed.
+	DiscrepancyUnconfirmed DiscrepancyKind = "unconfirmed"
+	// DiscrepancyMissingObject is an uploaded file whose object is gone.
+	DiscrepancyMissingObject DiscrepancyKind = "missing_object"
+	// DiscrepancySizeMismatch is an uploaded file whose object has another size.
+	DiscrepancySizeMismatch DiscrepancyKind = "size_mismatch"
+)
+
+type ReconcileOptions struct {
+	// TenantID limits the run to a single tenant when set. Without it the
+	// run covers every tenant, or only the default one when OwnerID is set.
+	TenantID string
+	// OwnerID limits the run to a single owner prefix when set.
+	OwnerID string
+	// Fix lists the kinds of discrepancy to repair rather than only report.
+	Fix map[DiscrepancyKind]bool
+	// MinObjectAge keeps objects written more recently than this out of the
+	// orphan check, since a streamed upload stores the object before its row.
+	MinObjectAge time.Duration
+}
+
+// Fixes reports whether any kind of discrepancy is to be repaired.
+func (o ReconcileOptions) Fixes() bool {
+	for _, fix := range o.Fix {
+		if fix {
+			return true
+		}
+	}
+	return false
+}
+
+type Discrepancy struct {
+	Kind         DiscrepancyKind
+	Key          string
+	TenantID     string
+	OwnerID      string
+	FileID       string
+	Status       FileStatus
+	ExpectedSize int64
+	ActualSize   int64
+	Fixed        bool
+	FixError     string
+}
+
+type ReconcileReport struct {
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	Tenants       int
+	Owners        int
+	Objects       int
+	Files         int
+	Discrepancies []Discrepancy
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var referencePattern = regexp.MustCompile(`^[A-Z][A-Za-z]+/[A-Za-z0-9\-.]{1,64}$`)
+
+func ValidateReference(reference string) error {
+	if reference == "" {
+		return nil
+	}
+	if !referencePattern.MatchString(reference) {
+		return fmt.Errorf("%w: security context must be a relative reference like Patient/123", ErrInvalidInput)
+	}
+	return nil
+}
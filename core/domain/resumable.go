@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+type ResumableUpload struct {
+	FileID    string
+	Length    int64
+	Offset    int64
+	Metadata  string
+	Buffer    []byte
+	Parts     []UploadPart
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (u *ResumableUpload) IsComplete() bool {
+	return u.Offset == u.Length
+}
+
+func (u *ResumableUpload) IsExpired(now time.Time) bool {
+	return !u.ExpiresAt.IsZero() && now.After(u.ExpiresAt)
+}
@@ -0,0 +1,174 @@
+package domain
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	shareTokenBytes        = 32
+	sharePasswordSaltBytes = 16
+	sharePasswordKeyBytes  = 32
+	sharePasswordScheme    = "pbkdf2-sha256"
+	sharePasswordRounds    = 600_000
+)
+
+type ShareLinkOutcome string
+
+const (
+	ShareLinkDownloaded  ShareLinkOutcome = "downloaded"
+	ShareLinkDenied      ShareLinkOutcome = "denied"
+	ShareLinkRevoked     ShareLinkOutcome = "revoked"
+	ShareLinkExpired     ShareLinkOutcome = "expired"
+	ShareLinkExhausted   ShareLinkOutcome = "exhausted"
+	ShareLinkUnavailable ShareLinkOutcome = "unavailable"
+)
+
+// ShareLink lets anyone holding its token download one file without an
+// account. Only a hash of the token is stored, so a leaked table does not
+// leak working links. A zero MaxDownloads means the link is not limited by
+// count, only by ExpiresAt.
+type ShareLink struct {
+	ID           string
+	FileID       string
+	TokenHash    string
+	PasswordHash string
+	MaxDownloads int
+	Downloads    int
+	ExpiresAt    time.Time
+	CreatedBy    string
+	CreatedAt    time.Time
+	RevokedAt    time.Time
+}
+
+// ShareLinkUse is an entry in a share link's access log.
+type ShareLinkUse struct {
+	LinkID     string
+	Outcome    ShareLinkOutcome
+	RemoteAddr string
+	UserAgent  string
+	CreatedAt  time.Time
+}
+
+// SharedDownload is a share link resolved to the content of its file. The
+// download is not counted against the link until it is consumed.
+type SharedDownload struct {
+	File    *File
+	Content io.ReadSeekCloser
+	Use     ShareLinkUse
+}
+
+type CreateShareLinkResult struct {
+	Link     *ShareLink
+	Token    string
+	ShareURL string
+}
+
+// NewShareLink creates a link for fileID and returns it together with the
+// plain token, which is never stored and cannot be recovered later.
+func NewShareLink(fileID, createdBy string, expiresAt time.Time, maxDownloads int, password string) (*ShareLink, string, error) {
+	raw := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	link := &ShareLink{
+		ID:           uuid.New().String(),
+		FileID:       fileID,
+		TokenHash:    HashShareToken(token),
+		MaxDownloads: maxDownloads,
+		ExpiresAt:    expiresAt,
+		CreatedBy:    createdBy,
+		CreatedAt:    time.Now(),
+	}
+
+	if password != "" {
+		hash, err := hashSharePassword(password)
+		if err != nil {
+			return nil, "", err
+		}
+		link.PasswordHash = hash
+	}
+
+	return link, token, nil
+}
+
+func HashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Status reports why the link can no longer be used, or an empty outcome when
+// it can.
+func (l *ShareLink) Status(now time.Time) ShareLinkOutcome {
+	switch {
+	case !l.RevokedAt.IsZero():
+		return ShareLinkRevoked
+	case !now.Before(l.ExpiresAt):
+		return ShareLinkExpired
+	case l.MaxDownloads > 0 && l.Downloads >= l.MaxDownloads:
+		return ShareLinkExhausted
+	default:
+		return ""
+	}
+}
+
+func (l *ShareLink) HasPassword() bool {
+	return l.PasswordHash != ""
+}
+
+func (l *ShareLink) CheckPassword(password string) bool {
+	if !l.HasPassword() {
+		return true
+	}
+
+	parts := strings.Split(l.PasswordHash, "$")
+	if len(parts) != 4 || parts[0] != sharePasswordScheme {
+		return false
+	}
+	rounds, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	got, err := pbkdf2.Key(sha256.New, password, salt, rounds, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+func hashSharePassword(password string) (string, error) {
+	salt := make([]byte, sharePasswordSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, sharePasswordRounds, sharePasswordKeyBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s$%d$%s$%s", sharePasswordScheme, sharePasswordRounds,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
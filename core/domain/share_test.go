@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShareLink(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+
+	link, token, err := NewShareLink("file-id", "owner-id", expiresAt, 3, "secret")
+	require.NoError(t, err)
+
+	assert.NotEmpty(t, link.ID)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, HashShareToken(token), link.TokenHash)
+	assert.NotContains(t, link.TokenHash, token)
+	assert.NotContains(t, link.PasswordHash, "secret")
+	assert.True(t, link.HasPassword())
+	assert.True(t, link.CheckPassword("secret"))
+	assert.False(t, link.CheckPassword("wrong"))
+	assert.False(t, link.CheckPassword(""))
+
+	other, otherToken, err := NewShareLink("file-id", "owner-id", expiresAt, 0, "")
+	require.NoError(t, err)
+	assert.NotEqual(t, token, otherToken)
+	assert.False(t, other.HasPassword())
+	assert.True(t, other.CheckPassword(""))
+}
+
+func TestShareLink_Status(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		link     ShareLink
+		expected ShareLinkOutcome
+	}{
+		{
+			name:     "usable",
+			link:     ShareLink{ExpiresAt: now.Add(time.Hour), MaxDownloads: 2, Downloads: 1},
+			expected: "",
+		},
+		{
+			name:     "unlimited downloads",
+			link:     ShareLink{ExpiresAt: now.Add(time.Hour), Downloads: 100},
+			expected: "",
+		},
+		{
+			name:     "expired",
+			link:     ShareLink{ExpiresAt: now},
+			expected: ShareLinkExpired,
+		},
+		{
+			name:     "download limit reached",
+			link:     ShareLink{ExpiresAt: now.Add(time.Hour), MaxDownloads: 2, Downloads: 2},
+			expected: ShareLinkExhausted,
+		},
+		{
+			name:     "revoked wins over expiry",
+			link:     ShareLink{ExpiresAt: now.Add(-time.Hour), RevokedAt: now.Add(-2 * time.Hour)},
+			expected: ShareLinkRevoked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.link.Status(now))
+		})
+	}
+}
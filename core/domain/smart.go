@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+type SMARTContext string
+
+const (
+	SMARTContextPatient SMARTContext = "patient"
+	SMARTContextUser    SMARTContext = "user"
+	SMARTContextSystem  SMARTContext = "system"
+)
+
+type SMARTScope struct {
+	Context      SMARTContext
+	ResourceType string
+	Create       bool
+	Read         bool
+	Update       bool
+	Delete       bool
+	Search       bool
+}
+
+var (
+	smartScopePattern = regexp.MustCompile(`^(patient|user|system)/([A-Z][A-Za-z]*|\*)\.([a-z*]+)$`)
+	smartV2Pattern    = regexp.MustCompile(`^c?r?u?d?s?$`)
+)
+
+func ParseSMARTScope(raw string) (SMARTScope, bool) {
+	if strings.Contains(raw, "?") {
+		// Scopes narrowed by search parameters cannot be evaluated against a
+		// file, so they never grant access here.
+		return SMARTScope{}, false
+	}
+
+	matches := smartScopePattern.FindStringSubmatch(raw)
+	if matches == nil {
+		return SMARTScope{}, false
+	}
+
+	scope := SMARTScope{
+		Context:      SMARTContext(matches[1]),
+		ResourceType: matches[2],
+	}
+
+	switch permissions := matches[3]; permissions {
+	case "read":
+		scope.Read, scope.Search = true, true
+	case "write":
+		scope.Create, scope.Update, scope.Delete = true, true, true
+	case "*":
+		scope.Create, scope.Read, scope.Update, scope.Delete, scope.Search = true, true, true, true, true
+	default:
+		if !smartV2Pattern.MatchString(permissions) {
+			return SMARTScope{}, false
+		}
+		scope.Create = strings.Contains(permissions, "c")
+		scope.Read = strings.Contains(permissions, "r")
+		scope.Update = strings.Contains(permissions, "u")
+		scope.Delete = strings.Contains(permissions, "d")
+		scope.Search = strings.Contains(permissions, "s")
+	}
+
+	return scope, true
+}
+
+func (s SMARTScope) Allows(resourceType string, permission Permission) bool {
+	if s.ResourceType != "*" && s.ResourceType != resourceType {
+		return false
+	}
+
+	switch permission {
+	case PermissionRead:
+		return s.Read
+	case PermissionWrite:
+		return s.Update
+	case PermissionDelete:
+		return s.Delete
+	default:
+		return false
+	}
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSMARTScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected SMARTScope
+		ok       bool
+	}{
+		{
+			name:     "v1 read",
+			raw:      "patient/Binary.read",
+			expected: SMARTScope{Context: SMARTContextPatient, ResourceType: "Binary", Read: true, Search: true},
+			ok:       true,
+		},
+		{
+			name:     "v1 write",
+			raw:      "user/Binary.write",
+			expected: SMARTScope{Context: SMARTContextUser, ResourceType: "Binary", Create: true, Update: true, Delete: true},
+			ok:       true,
+		},
+		{
+			name: "v1 wildcard resource and permissions",
+			raw:  "system/*.*",
+			expected: SMARTScope{
+				Context: SMARTContextSystem, ResourceType: "*",
+				Create: true, Read: true, Update: true, Delete: true, Search: true,
+			},
+			ok: true,
+		},
+		{
+			name:     "v2 read and search",
+			raw:      "user/Binary.rs",
+			expected: SMARTScope{Context: SMARTContextUser, ResourceType: "Binary", Read: true, Search: true},
+			ok:       true,
+		},
+		{
+			name: "v2 all permissions",
+			raw:  "system/Binary.cruds",
+			expected: SMARTScope{
+				Context: SMARTContextSystem, ResourceType: "Binary",
+				Create: true, Read: true, Update: true, Delete: true, Search: true,
+			},
+			ok: true,
+		},
+		{
+			name: "v2 permissions out of order",
+			raw:  "user/Binary.rc",
+			ok:   false,
+		},
+		{
+			name: "v2 scope with query constraint",
+			raw:  "patient/Observation.rs?category=laboratory",
+			ok:   false,
+		},
+		{
+			name: "unknown context",
+			raw:  "admin/Binary.read",
+			ok:   false,
+		},
+		{
+			name: "custom files scope",
+			raw:  "files:file:abc:read",
+			ok:   false,
+		},
+		{
+			name: "launch scope",
+			raw:  "launch/patient",
+			ok:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope, ok := ParseSMARTScope(tt.raw)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.expected, scope)
+			}
+		})
+	}
+}
+
+func TestSMARTScope_Allows(t *testing.T) {
+	readOnly, _ := ParseSMARTScope("user/Binary.rs")
+	assert.True(t, readOnly.Allows("Binary", PermissionRead))
+	assert.False(t, readOnly.Allows("Binary", PermissionWrite))
+	assert.False(t, readOnly.Allows("Binary", PermissionDelete))
+	assert.False(t, readOnly.Allows("Patient", PermissionRead))
+
+	wildcard, _ := ParseSMARTScope("system/*.write")
+	assert.True(t, wildcard.Allows("Binary", PermissionWrite))
+	assert.True(t, wildcard.Allows("Binary", PermissionDelete))
+	assert.False(t, wildcard.Allows("Binary", PermissionRead))
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultTenantID is the tenant of requests that name none. Its objects live
+// under its own prefix like any other tenant's. Files stored before tenants
+// had prefixes keep their ownerID/fileID keys, which are recorded with each
+// file; see LegacyOwnerPrefix.
+const DefaultTenantID = "default"
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,62}$`)
+
+// ValidateTenantID rejects IDs that could not be used safely as the first
+// segment of an object key.
+func ValidateTenantID(tenantID string) error {
+	if !tenantIDPattern.MatchString(tenantID) {
+		return fmt.Errorf("%w: invalid tenant ID %q", ErrInvalidInput, tenantID)
+	}
+	return nil
+}
+
+// ValidateOwnerID rejects owner IDs that cannot be used as a single segment
+// of an object key, and those equal to a tenant ID, which could be taken for
+// that tenant's prefix in the older layout of the default tenant.
+func ValidateOwnerID(ownerID string, tenants []string) error {
+	if ownerID == "" || strings.Contains(ownerID, "/") {
+		return fmt.Errorf("%w: invalid owner ID %q", ErrInvalidInput, ownerID)
+	}
+	if ownerID == DefaultTenantID || slices.Contains(tenants, ownerID) {
+		return fmt.Errorf("%w: owner ID %q is a tenant ID", ErrInvalidInput, ownerID)
+	}
+	return nil
+}
+
+// TenantPrefix is the key prefix under which all objects of a tenant live.
+func TenantPrefix(tenantID string) string {
+	if tenantID == "" {
+		tenantID = DefaultTenantID
+	}
+	return tenantID + "/"
+}
+
+// LegacyOwnerPrefix is the key prefix of the objects an owner of the default
+// tenant stored before tenants had prefixes.
+func LegacyOwnerPrefix(ownerID string) string {
+	return ownerID + "/"
+}
+
+// IsLegacyOwnerKey reports whether key has the ownerID/fileID layout of
+// objects stored before tenants had prefixes, or belongs to such an object.
+// Keys of a tenant named like the owner have more segments.
+func IsLegacyOwnerKey(ownerID, key string) bool {
+	if parent, ok := ParentObjectKey(key); ok {
+		key = parent
+	}
+	fileID, ok := strings.CutPrefix(key, LegacyOwnerPrefix(ownerID))
+	return ok && fileID != "" && !strings.Contains(fileID, "/")
+}
+
+func ObjectKey(tenantID, ownerID, fileID string) string {
+	return fmt.Sprintf("%s%s/%s", TenantPrefix(tenantID), ownerID, fileID)
+}
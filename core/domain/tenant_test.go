@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectKey(t *testing.T) {
+	assert.Equal(t, "default/owner/file", ObjectKey("", "owner", "file"))
+	assert.Equal(t, "default/owner/file", ObjectKey(DefaultTenantID, "owner", "file"))
+	assert.Equal(t, "clinic-a/owner/file", ObjectKey("clinic-a", "owner", "file"))
+}
+
+func TestIsLegacyOwnerKey(t *testing.T) {
+	assert.True(t, IsLegacyOwnerKey("clinic-a", "clinic-a/file"))
+	assert.True(t, IsLegacyOwnerKey("clinic-a", ThumbnailKey("clinic-a/file", 128)))
+	assert.True(t, IsLegacyOwnerKey("clinic-a", OriginalKey("clinic-a/file")))
+
+	assert.False(t, IsLegacyOwnerKey("clinic-a", "clinic-a/owner/file"))
+	assert.False(t, IsLegacyOwnerKey("clinic-a", ThumbnailKey("clinic-a/owner/file", 128)))
+	assert.False(t, IsLegacyOwnerKey("clinic-a", "clinic-b/file"))
+	assert.False(t, IsLegacyOwnerKey("clinic-a", "clinic-a/"))
+}
+
+func TestValidateOwnerID(t *testing.T) {
+	tenants := []string{"clinic-a"}
+
+	assert.NoError(t, ValidateOwnerID("owner-1", tenants))
+
+	for _, ownerID := range []string{"", "a/b", "clinic-a", DefaultTenantID} {
+		assert.ErrorIs(t, ValidateOwnerID(ownerID, tenants), ErrInvalidInput, ownerID)
+	}
+}
+
+func TestValidateTenantID(t *testing.T) {
+	for _, tenantID := range []string{DefaultTenantID, "clinic-a", "h1.eu_west"} {
+		assert.NoError(t, ValidateTenantID(tenantID), tenantID)
+	}
+
+	for _, tenantID := range []string{"", "Clinic", "-clinic", "a/b", "..", "a b"} {
+		assert.ErrorIs(t, ValidateTenantID(tenantID), ErrInvalidInput, tenantID)
+	}
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// thumbnailKeyInfix separates the key of the original from the size in the
+// keys of its thumbnails, so that they share the original's prefix.
+const thumbnailKeyInfix = ".thumbnails/"
+
+// thumbnailSourceTypes are the image types thumbnails are generated for.
+var thumbnailSourceTypes = []string{"image/gif", "image/jpeg", "image/png"}
+
+// Thumbnail is a downscaled rendition of an image file, stored next to the
+// original. Size is the longest edge it was fitted into.
+type Thumbnail struct {
+	FileID      string
+	Size        int
+	S3Path      string
+	ContentType string
+	Width       int
+	Height      int
+	ByteSize    int64
+	CreatedAt   time.Time
+}
+
+// RenderedThumbnail is an encoded thumbnail that has not been stored yet.
+type RenderedThumbnail struct {
+	Size        int
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+func NewThumbnail(file *File, rendered *RenderedThumbnail) *Thumbnail {
+	return &Thumbnail{
+		FileID:      file.ID,
+		Size:        rendered.Size,
+		S3Path:      ThumbnailKey(file.S3Path, rendered.Size),
+		ContentType: rendered.ContentType,
+		Width:       rendered.Width,
+		Height:      rendered.Height,
+		ByteSize:    int64(len(rendered.Data)),
+		CreatedAt:   time.Now(),
+	}
+}
+
+// ThumbnailKey returns the object key of a thumbnail of the object at key.
+func ThumbnailKey(key string, size int) string {
+	return fmt.Sprintf("%s%s%d", key, thumbnailKeyInfix, size)
+}
+
+// IsThumbnailSource reports whether thumbnails are generated for content of
+// the given type.
+func IsThumbnailSource(contentType string) bool {
+	return slices.Contains(thumbnailSourceTypes, canonicalType(contentType))
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThumbnailKey(t *testing.T) {
+	key := ThumbnailKey("acme/user-1/file-1", 256)
+	assert.Equal(t, "acme/user-1/file-1.thumbnails/256", key)
+}
+
+func TestIsThumbnailSource(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    bool
+	}{
+		{contentType: "image/jpeg", expected: true},
+		{contentType: "image/jpg", expected: true},
+		{contentType: "image/png", expected: true},
+		{contentType: "IMAGE/GIF", expected: true},
+		{contentType: "image/png; charset=binary", expected: true},
+		{contentType: "image/webp", expected: false},
+		{contentType: "image/svg+xml", expected: false},
+		{contentType: "application/pdf", expected: false},
+		{contentType: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsThumbnailSource(tt.contentType))
+		})
+	}
+}
@@ -2,21 +2,143 @@ package ports
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/gruzdev-dev/codex-files/core/domain"
 )
 
-//go:generate mockgen -source=file.go -destination=file_mocks.go -package=ports FileRepository,FileProvider
+//go:generate mockgen -source=file.go -destination=file_mocks.go -package=ports FileRepository,FileGrantRepository,ShareLinkRepository,QuotaRepository,MultipartUploadRepository,ResumableUploadRepository,ThumbnailRepository,JobRepository,FileProvider,Thumbnailer,MetadataStripper,Scanner
 
 type FileRepository interface {
 	Create(ctx context.Context, file *domain.File) (*domain.File, error)
+	// CreateWithinQuotas creates the file only if it fits in every one of
+	// quotas, checked atomically with the insert; otherwise it fails with
+	// domain.ErrQuotaExceeded.
+	CreateWithinQuotas(ctx context.Context, file *domain.File, quotas []domain.Quota) (*domain.File, error)
 	GetByID(ctx context.Context, id string) (*domain.File, error)
 	Update(ctx context.Context, file *domain.File) (*domain.File, error)
 	SoftDelete(ctx context.Context, id string) error
+	GetDeletedByID(ctx context.Context, id string) (*domain.File, error)
+	Restore(ctx context.Context, id string, deletedAfter time.Time, event *domain.FileEvent) (*domain.File, error)
+	ListAbandoned(ctx context.Context, cutoff domain.UploadCutoff, limit int) ([]*domain.File, error)
+	// ListUnscanned lists uploaded files that no scan job was ever queued
+	// for, oldest first.
+	ListUnscanned(ctx context.Context, limit int) ([]*domain.File, error)
+	ClaimPurgeable(ctx context.Context, deletedBefore time.Time, lease time.Duration, limit int) ([]*domain.File, error)
+	MarkPurged(ctx context.Context, id string) error
+	ListOwners(ctx context.Context) ([]string, error)
+	ListByOwner(ctx context.Context, ownerID string) ([]*domain.File, error)
+	ListTenants(ctx context.Context) ([]string, error)
+}
+
+type FileGrantRepository interface {
+	Upsert(ctx context.Context, grant *domain.FileGrant) (*domain.FileGrant, error)
+	Delete(ctx context.Context, fileID string, grantee domain.Grantee, permission domain.Permission) error
+	ListByFileID(ctx context.Context, fileID string) ([]*domain.FileGrant, error)
+	HasGrant(ctx context.Context, fileID string, grantees []domain.Grantee, permission domain.Permission) (bool, error)
+}
+
+type ShareLinkRepository interface {
+	Create(ctx context.Context, link *domain.ShareLink) (*domain.ShareLink, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*domain.ShareLink, error)
+	ListByFileID(ctx context.Context, fileID string) ([]*domain.ShareLink, error)
+	Revoke(ctx context.Context, fileID, id string) error
+	Consume(ctx context.Context, use *domain.ShareLinkUse) error
+	LogUse(ctx context.Context, use *domain.ShareLinkUse) error
+}
+
+// QuotaRepository holds the quota overrides set for tenants and owners and
+// sums up what they store.
+type QuotaRepository interface {
+	// ListOverrides returns the overrides for the tenant and for the owner
+	// within it, if any.
+	ListOverrides(ctx context.Context, tenantID, ownerID string) ([]*domain.Quota, error)
+	// GetUsage sums the files of the owner, or of the whole tenant when
+	// ownerID is empty.
+	GetUsage(ctx context.Context, tenantID, ownerID string) (*domain.Usage, error)
+	// Lock holds quotas against files created within them, and against other
+	// lockers, until the returned release is called.
+	Lock(ctx context.Context, quotas []domain.Quota) (func(), error)
+}
+
+type MultipartUploadRepository interface {
+	Create(ctx context.Context, upload *domain.MultipartUpload) (*domain.MultipartUpload, error)
+	GetByFileID(ctx context.Context, fileID string) (*domain.MultipartUpload, error)
+	Delete(ctx context.Context, fileID string) error
+}
+
+type ResumableUploadRepository interface {
+	Create(ctx context.Context, upload *domain.ResumableUpload) (*domain.ResumableUpload, error)
+	GetByFileID(ctx context.Context, fileID string) (*domain.ResumableUpload, error)
+	Advance(ctx context.Context, upload *domain.ResumableUpload, fromOffset int64, parts []domain.UploadPart) error
+	// Lock gives the caller the sole right to write chunks of the upload until
+	// the returned release is called. It fails with domain.ErrUploadConflict
+	// while another request holds it.
+	Lock(ctx context.Context, fileID string) (func(), error)
+}
+
+// ThumbnailRepository stores the thumbnails of files, one per file and size.
+type ThumbnailRepository interface {
+	// Save stores a thumbnail, replacing the one of the same file and size.
+	Save(ctx context.Context, thumbnail *domain.Thumbnail) (*domain.Thumbnail, error)
+	Get(ctx context.Context, fileID string, size int) (*domain.Thumbnail, error)
+	ListByFileID(ctx context.Context, fileID string) ([]*domain.Thumbnail, error)
+	DeleteByFileID(ctx context.Context, fileID string) error
+}
+
+// JobRepository is the durable queue of post-upload jobs.
+type JobRepository interface {
+	Enqueue(ctx context.Context, job *domain.Job) (*domain.Job, error)
+	// Claim leases up to limit due jobs of the given kinds and counts an
+	// attempt for each. Jobs held by another worker are skipped, and running
+	// jobs whose lease ran out are claimed again, or moved to the dead-letter
+	// state once they made maxAttempts.
+	Claim(ctx context.Context, kinds []domain.JobKind, lease time.Duration, limit int, maxAttempts int) ([]*domain.Job, error)
+	// Update stores the outcome of an attempt and releases the lease. It
+	// fails with domain.ErrJobLeaseLost when the job was claimed again or
+	// dead-lettered since the attempt was claimed.
+	Update(ctx context.Context, job *domain.Job) error
+	// DeleteFinished deletes up to limit done jobs last updated before
+	// doneBefore and dead jobs last updated before deadBefore, and reports
+	// how many it deleted. A zero time keeps the jobs of that status.
+	DeleteFinished(ctx context.Context, doneBefore, deadBefore time.Time, limit int) (int, error)
 }
 
 type FileProvider interface {
-	GenerateUploadURL(ctx context.Context, s3Path string, contentType string, maxSize int64, ttl time.Duration) (string, error)
+	GenerateUploadURL(ctx context.Context, s3Path string, contentType string, maxSize int64, checksum domain.Checksum, ttl time.Duration) (string, error)
 	GenerateDownloadURL(ctx context.Context, s3Path string, ttl time.Duration) (string, error)
+	PutObject(ctx context.Context, s3Path string, contentType string, content io.Reader, size int64) error
+	OpenObject(ctx context.Context, s3Path string) (io.ReadSeekCloser, error)
+	StatObject(ctx context.Context, s3Path string) (*domain.ObjectInfo, error)
+	DeleteObject(ctx context.Context, s3Path string) error
+	ListObjects(ctx context.Context, prefix string, recursive bool) ([]domain.ObjectInfo, error)
+	CreateMultipartUpload(ctx context.Context, s3Path string, contentType string) (string, error)
+	GeneratePartUploadURL(ctx context.Context, s3Path string, uploadID string, partNumber int, ttl time.Duration) (string, error)
+	UploadPart(ctx context.Context, s3Path string, uploadID string, partNumber int, content io.Reader, size int64) (string, error)
+	CompleteMultipartUpload(ctx context.Context, s3Path string, uploadID string, parts []domain.UploadPart) error
+	AbortMultipartUpload(ctx context.Context, s3Path string, uploadID string) error
+}
+
+// Thumbnailer renders thumbnails of images.
+type Thumbnailer interface {
+	// Thumbnail decodes the image once and renders it fitted into each of
+	// sizes. Content that is not a supported image is rejected with
+	// domain.ErrInvalidInput.
+	Thumbnail(ctx context.Context, content io.ReadSeeker, sizes []int) ([]*domain.RenderedThumbnail, error)
+}
+
+// MetadataStripper removes embedded metadata from images.
+type MetadataStripper interface {
+	// Strip copies the JPEG or PNG image in content to dst without its EXIF,
+	// XMP and other metadata blocks, and returns how many blocks it dropped.
+	// Data after the end of the image is dropped too and counts as a block.
+	// Content that is not a well-formed image is rejected with
+	// domain.ErrInvalidInput.
+	Strip(ctx context.Context, dst io.Writer, content io.Reader) (int, error)
+}
+
+// Scanner checks content for malware.
+type Scanner interface {
+	Scan(ctx context.Context, content io.Reader) (*domain.ScanResult, error)
 }
@@ -3,7 +3,7 @@
 //
 // Generated by this command:
 //
-//	mockgen -source=file.go -destination=file_mocks.go -package=ports FileRepository,FileProvider
+//	mockgen -source=file.go -destination=file_mocks.go -package=ports FileRepository,FileGrantRepository,ShareLinkRepository,QuotaRepository,MultipartUploadRepository,ResumableUploadRepository,ThumbnailRepository,JobRepository,FileProvider,Thumbnailer,MetadataStripper,Scanner
 //
 
 // Package ports is a generated GoMock package.
@@ -11,11 +11,11 @@ package ports
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
 	time "time"
 
 	domain "github.com/gruzdev-dev/codex-files/core/domain"
-
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -43,6 +43,21 @@ func (m *MockFileRepository) EXPECT() *MockFileRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ClaimPurgeable mocks base method.
+func (m *MockFileRepository) ClaimPurgeable(ctx context.Context, deletedBefore time.Time, lease time.Duration, limit int) ([]*domain.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPurgeable", ctx, deletedBefore, lease, limit)
+	ret0, _ := ret[0].([]*domain.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimPurgeable indicates an expected call of ClaimPurgeable.
+func (mr *MockFileRepositoryMockRecorder) ClaimPurgeable(ctx, deletedBefore, lease, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPurgeable", reflect.TypeOf((*MockFileRepository)(nil).ClaimPurgeable), ctx, deletedBefore, lease, limit)
+}
+
 // Create mocks base method.
 func (m *MockFileRepository) Create(ctx context.Context, file *domain.File) (*domain.File, error) {
 	m.ctrl.T.Helper()
@@ -58,6 +73,21 @@ func (mr *MockFileRepositoryMockRecorder) Create(ctx, file any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockFileRepository)(nil).Create), ctx, file)
 }
 
+// CreateWithinQuotas mocks base method.
+func (m *MockFileRepository) CreateWithinQuotas(ctx context.Context, file *domain.File, quotas []domain.Quota) (*domain.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWithinQuotas", ctx, file, quotas)
+	ret0, _ := ret[0].(*domain.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWithinQuotas indicates an expected call of CreateWithinQuotas.
+func (mr *MockFileRepositoryMockRecorder) CreateWithinQuotas(ctx, file, quotas any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWithinQuotas", reflect.TypeOf((*MockFileRepository)(nil).CreateWithinQuotas), ctx, file, quotas)
+}
+
 // GetByID mocks base method.
 func (m *MockFileRepository) GetByID(ctx context.Context, id string) (*domain.File, error) {
 	m.ctrl.T.Helper()
@@ -73,6 +103,125 @@ func (mr *MockFileRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockFileRepository)(nil).GetByID), ctx, id)
 }
 
+// GetDeletedByID mocks base method.
+func (m *MockFileRepository) GetDeletedByID(ctx context.Context, id string) (*domain.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletedByID", ctx, id)
+	ret0, _ := ret[0].(*domain.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletedByID indicates an expected call of GetDeletedByID.
+func (mr *MockFileRepositoryMockRecorder) GetDeletedByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedByID", reflect.TypeOf((*MockFileRepository)(nil).GetDeletedByID), ctx, id)
+}
+
+// ListAbandoned mocks base method.
+func (m *MockFileRepository) ListAbandoned(ctx context.Context, cutoff domain.UploadCutoff, limit int) ([]*domain.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAbandoned", ctx, cutoff, limit)
+	ret0, _ := ret[0].([]*domain.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAbandoned indicates an expected call of ListAbandoned.
+func (mr *MockFileRepositoryMockRecorder) ListAbandoned(ctx, cutoff, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAbandoned", reflect.TypeOf((*MockFileRepository)(nil).ListAbandoned), ctx, cutoff, limit)
+}
+
+// ListByOwner mocks base method.
+func (m *MockFileRepository) ListByOwner(ctx context.Context, ownerID string) ([]*domain.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOwner", ctx, ownerID)
+	ret0, _ := ret[0].([]*domain.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOwner indicates an expected call of ListByOwner.
+func (mr *MockFileRepositoryMockRecorder) ListByOwner(ctx, ownerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOwner", reflect.TypeOf((*MockFileRepository)(nil).ListByOwner), ctx, ownerID)
+}
+
+// ListOwners mocks base method.
+func (m *MockFileRepository) ListOwners(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOwners", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOwners indicates an expected call of ListOwners.
+func (mr *MockFileRepositoryMockRecorder) ListOwners(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOwners", reflect.TypeOf((*MockFileRepository)(nil).ListOwners), ctx)
+}
+
+// ListTenants mocks base method.
+func (m *MockFileRepository) ListTenants(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTenants", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTenants indicates an expected call of ListTenants.
+func (mr *MockFileRepositoryMockRecorder) ListTenants(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTenants", reflect.TypeOf((*MockFileRepository)(nil).ListTenants), ctx)
+}
+
+// ListUnscanned mocks base method.
+func (m *MockFileRepository) ListUnscanned(ctx context.Context, limit int) ([]*domain.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnscanned", ctx, limit)
+	ret0, _ := ret[0].([]*domain.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnscanned indicates an expected call of ListUnscanned.
+func (mr *MockFileRepositoryMockRecorder) ListUnscanned(ctx, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnscanned", reflect.TypeOf((*MockFileRepository)(nil).ListUnscanned), ctx, limit)
+}
+
+// MarkPurged mocks base method.
+func (m *MockFileRepository) MarkPurged(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkPurged", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkPurged indicates an expected call of MarkPurged.
+func (mr *MockFileRepositoryMockRecorder) MarkPurged(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkPurged", reflect.TypeOf((*MockFileRepository)(nil).MarkPurged), ctx, id)
+}
+
+// Restore mocks base method.
+func (m *MockFileRepository) Restore(ctx context.Context, id string, deletedAfter time.Time, event *domain.FileEvent) (*domain.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", ctx, id, deletedAfter, event)
+	ret0, _ := ret[0].(*domain.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockFileRepositoryMockRecorder) Restore(ctx, id, deletedAfter, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockFileRepository)(nil).Restore), ctx, id, deletedAfter, event)
+}
+
 // SoftDelete mocks base method.
 func (m *MockFileRepository) SoftDelete(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -102,56 +251,899 @@ func (mr *MockFileRepositoryMockRecorder) Update(ctx, file any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockFileRepository)(nil).Update), ctx, file)
 }
 
-// MockFileProvider is a mock of FileProvider interface.
-type MockFileProvider struct {
+// MockFileGrantRepository is a mock of FileGrantRepository interface.
+type MockFileGrantRepository struct {
 	ctrl     *gomock.Controller
-	recorder *MockFileProviderMockRecorder
+	recorder *MockFileGrantRepositoryMockRecorder
 	isgomock struct{}
 }
 
-// MockFileProviderMockRecorder is the mock recorder for MockFileProvider.
-type MockFileProviderMockRecorder struct {
-	mock *MockFileProvider
+// MockFileGrantRepositoryMockRecorder is the mock recorder for MockFileGrantRepository.
+type MockFileGrantRepositoryMockRecorder struct {
+	mock *MockFileGrantRepository
 }
 
-// NewMockFileProvider creates a new mock instance.
-func NewMockFileProvider(ctrl *gomock.Controller) *MockFileProvider {
-	mock := &MockFileProvider{ctrl: ctrl}
-	mock.recorder = &MockFileProviderMockRecorder{mock}
+// NewMockFileGrantRepository creates a new mock instance.
+func NewMockFileGrantRepository(ctrl *gomock.Controller) *MockFileGrantRepository {
+	mock := &MockFileGrantRepository{ctrl: ctrl}
+	mock.recorder = &MockFileGrantRepositoryMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
-func (m *MockFileProvider) EXPECT() *MockFileProviderMockRecorder {
+func (m *MockFileGrantRepository) EXPECT() *MockFileGrantRepositoryMockRecorder {
 	return m.recorder
 }
 
-// GenerateDownloadURL mocks base method.
-func (m *MockFileProvider) GenerateDownloadURL(ctx context.Context, s3Path string, ttl time.Duration) (string, error) {
+// Delete mocks base method.
+func (m *MockFileGrantRepository) Delete(ctx context.Context, fileID string, grantee domain.Grantee, permission domain.Permission) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateDownloadURL", ctx, s3Path, ttl)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "Delete", ctx, fileID, grantee, permission)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockFileGrantRepositoryMockRecorder) Delete(ctx, fileID, grantee, permission any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockFileGrantRepository)(nil).Delete), ctx, fileID, grantee, permission)
+}
+
+// HasGrant mocks base method.
+func (m *MockFileGrantRepository) HasGrant(ctx context.Context, fileID string, grantees []domain.Grantee, permission domain.Permission) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasGrant", ctx, fileID, grantees, permission)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GenerateDownloadURL indicates an expected call of GenerateDownloadURL.
-func (mr *MockFileProviderMockRecorder) GenerateDownloadURL(ctx, s3Path, ttl any) *gomock.Call {
+// HasGrant indicates an expected call of HasGrant.
+func (mr *MockFileGrantRepositoryMockRecorder) HasGrant(ctx, fileID, grantees, permission any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateDownloadURL", reflect.TypeOf((*MockFileProvider)(nil).GenerateDownloadURL), ctx, s3Path, ttl)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasGrant", reflect.TypeOf((*MockFileGrantRepository)(nil).HasGrant), ctx, fileID, grantees, permission)
 }
 
-// GenerateUploadURL mocks base method.
-func (m *MockFileProvider) GenerateUploadURL(ctx context.Context, s3Path, contentType string, maxSize int64, ttl time.Duration) (string, error) {
+// ListByFileID mocks base method.
+func (m *MockFileGrantRepository) ListByFileID(ctx context.Context, fileID string) ([]*domain.FileGrant, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateUploadURL", ctx, s3Path, contentType, maxSize, ttl)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "ListByFileID", ctx, fileID)
+	ret0, _ := ret[0].([]*domain.FileGrant)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GenerateUploadURL indicates an expected call of GenerateUploadURL.
-func (mr *MockFileProviderMockRecorder) GenerateUploadURL(ctx, s3Path, contentType, maxSize, ttl any) *gomock.Call {
+// ListByFileID indicates an expected call of ListByFileID.
+func (mr *MockFileGrantRepositoryMockRecorder) ListByFileID(ctx, fileID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByFileID", reflect.TypeOf((*MockFileGrantRepository)(nil).ListByFileID), ctx, fileID)
+}
+
+// Upsert mocks base method.
+func (m *MockFileGrantRepository) Upsert(ctx context.Context, grant *domain.FileGrant) (*domain.FileGrant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", ctx, grant)
+	ret0, _ := ret[0].(*domain.FileGrant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Upsert indicates an expected call of Upsert.
+func (mr *MockFileGrantRepositoryMockRecorder) Upsert(ctx, grant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockFileGrantRepository)(nil).Upsert), ctx, grant)
+}
+
+// MockShareLinkRepository is a mock of ShareLinkRepository interface.
+type MockShareLinkRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockShareLinkRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockShareLinkRepositoryMockRecorder is the mock recorder for MockShareLinkRepository.
+type MockShareLinkRepositoryMockRecorder struct {
+	mock *MockShareLinkRepository
+}
+
+// NewMockShareLinkRepository creates a new mock instance.
+func NewMockShareLinkRepository(ctrl *gomock.Controller) *MockShareLinkRepository {
+	mock := &MockShareLinkRepository{ctrl: ctrl}
+	mock.recorder = &MockShareLinkRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockShareLinkRepository) EXPECT() *MockShareLinkRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockShareLinkRepository) Consume(ctx context.Context, use *domain.ShareLinkUse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, use)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockShareLinkRepositoryMockRecorder) Consume(ctx, use any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockShareLinkRepository)(nil).Consume), ctx, use)
+}
+
+// Create mocks base method.
+func (m *MockShareLinkRepository) Create(ctx context.Context, link *domain.ShareLink) (*domain.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, link)
+	ret0, _ := ret[0].(*domain.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockShareLinkRepositoryMockRecorder) Create(ctx, link any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockShareLinkRepository)(nil).Create), ctx, link)
+}
+
+// GetByTokenHash mocks base method.
+func (m *MockShareLinkRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByTokenHash", ctx, tokenHash)
+	ret0, _ := ret[0].(*domain.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByTokenHash indicates an expected call of GetByTokenHash.
+func (mr *MockShareLinkRepositoryMockRecorder) GetByTokenHash(ctx, tokenHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByTokenHash", reflect.TypeOf((*MockShareLinkRepository)(nil).GetByTokenHash), ctx, tokenHash)
+}
+
+// ListByFileID mocks base method.
+func (m *MockShareLinkRepository) ListByFileID(ctx context.Context, fileID string) ([]*domain.ShareLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByFileID", ctx, fileID)
+	ret0, _ := ret[0].([]*domain.ShareLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByFileID indicates an expected call of ListByFileID.
+func (mr *MockShareLinkRepositoryMockRecorder) ListByFileID(ctx, fileID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByFileID", reflect.TypeOf((*MockShareLinkRepository)(nil).ListByFileID), ctx, fileID)
+}
+
+// LogUse mocks base method.
+func (m *MockShareLinkRepository) LogUse(ctx context.Context, use *domain.ShareLinkUse) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LogUse", ctx, use)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LogUse indicates an expected call of LogUse.
+func (mr *MockShareLinkRepositoryMockRecorder) LogUse(ctx, use any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LogUse", reflect.TypeOf((*MockShareLinkRepository)(nil).LogUse), ctx, use)
+}
+
+// Revoke mocks base method.
+func (m *MockShareLinkRepository) Revoke(ctx context.Context, fileID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, fileID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockShareLinkRepositoryMockRecorder) Revoke(ctx, fileID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockShareLinkRepository)(nil).Revoke), ctx, fileID, id)
+}
+
+// MockQuotaRepository is a mock of QuotaRepository interface.
+type MockQuotaRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuotaRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockQuotaRepositoryMockRecorder is the mock recorder for MockQuotaRepository.
+type MockQuotaRepositoryMockRecorder struct {
+	mock *MockQuotaRepository
+}
+
+// NewMockQuotaRepository creates a new mock instance.
+func NewMockQuotaRepository(ctrl *gomock.Controller) *MockQuotaRepository {
+	mock := &MockQuotaRepository{ctrl: ctrl}
+	mock.recorder = &MockQuotaRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuotaRepository) EXPECT() *MockQuotaRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetUsage mocks base method.
+func (m *MockQuotaRepository) GetUsage(ctx context.Context, tenantID, ownerID string) (*domain.Usage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsage", ctx, tenantID, ownerID)
+	ret0, _ := ret[0].(*domain.Usage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsage indicates an expected call of GetUsage.
+func (mr *MockQuotaRepositoryMockRecorder) GetUsage(ctx, tenantID, ownerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsage", reflect.TypeOf((*MockQuotaRepository)(nil).GetUsage), ctx, tenantID, ownerID)
+}
+
+// ListOverrides mocks base method.
+func (m *MockQuotaRepository) ListOverrides(ctx context.Context, tenantID, ownerID string) ([]*domain.Quota, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOverrides", ctx, tenantID, ownerID)
+	ret0, _ := ret[0].([]*domain.Quota)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOverrides indicates an expected call of ListOverrides.
+func (mr *MockQuotaRepositoryMockRecorder) ListOverrides(ctx, tenantID, ownerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOverrides", reflect.TypeOf((*MockQuotaRepository)(nil).ListOverrides), ctx, tenantID, ownerID)
+}
+
+// Lock mocks base method.
+func (m *MockQuotaRepository) Lock(ctx context.Context, quotas []domain.Quota) (func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, quotas)
+	ret0, _ := ret[0].(func())
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockQuotaRepositoryMockRecorder) Lock(ctx, quotas any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockQuotaRepository)(nil).Lock), ctx, quotas)
+}
+
+// MockMultipartUploadRepository is a mock of MultipartUploadRepository interface.
+type MockMultipartUploadRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMultipartUploadRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockMultipartUploadRepositoryMockRecorder is the mock recorder for MockMultipartUploadRepository.
+type MockMultipartUploadRepositoryMockRecorder struct {
+	mock *MockMultipartUploadRepository
+}
+
+// NewMockMultipartUploadRepository creates a new mock instance.
+func NewMockMultipartUploadRepository(ctrl *gomock.Controller) *MockMultipartUploadRepository {
+	mock := &MockMultipartUploadRepository{ctrl: ctrl}
+	mock.recorder = &MockMultipartUploadRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMultipartUploadRepository) EXPECT() *MockMultipartUploadRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockMultipartUploadRepository) Create(ctx context.Context, upload *domain.MultipartUpload) (*domain.MultipartUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, upload)
+	ret0, _ := ret[0].(*domain.MultipartUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockMultipartUploadRepositoryMockRecorder) Create(ctx, upload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMultipartUploadRepository)(nil).Create), ctx, upload)
+}
+
+// Delete mocks base method.
+func (m *MockMultipartUploadRepository) Delete(ctx context.Context, fileID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, fileID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockMultipartUploadRepositoryMockRecorder) Delete(ctx, fileID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMultipartUploadRepository)(nil).Delete), ctx, fileID)
+}
+
+// GetByFileID mocks base method.
+func (m *MockMultipartUploadRepository) GetByFileID(ctx context.Context, fileID string) (*domain.MultipartUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByFileID", ctx, fileID)
+	ret0, _ := ret[0].(*domain.MultipartUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByFileID indicates an expected call of GetByFileID.
+func (mr *MockMultipartUploadRepositoryMockRecorder) GetByFileID(ctx, fileID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByFileID", reflect.TypeOf((*MockMultipartUploadRepository)(nil).GetByFileID), ctx, fileID)
+}
+
+// MockResumableUploadRepository is a mock of ResumableUploadRepository interface.
+type MockResumableUploadRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockResumableUploadRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockResumableUploadRepositoryMockRecorder is the mock recorder for MockResumableUploadRepository.
+type MockResumableUploadRepositoryMockRecorder struct {
+	mock *MockResumableUploadRepository
+}
+
+// NewMockResumableUploadRepository creates a new mock instance.
+func NewMockResumableUploadRepository(ctrl *gomock.Controller) *MockResumableUploadRepository {
+	mock := &MockResumableUploadRepository{ctrl: ctrl}
+	mock.recorder = &MockResumableUploadRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResumableUploadRepository) EXPECT() *MockResumableUploadRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Advance mocks base method.
+func (m *MockResumableUploadRepository) Advance(ctx context.Context, upload *domain.ResumableUpload, fromOffset int64, parts []domain.UploadPart) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Advance", ctx, upload, fromOffset, parts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Advance indicates an expected call of Advance.
+func (mr *MockResumableUploadRepositoryMockRecorder) Advance(ctx, upload, fromOffset, parts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Advance", reflect.TypeOf((*MockResumableUploadRepository)(nil).Advance), ctx, upload, fromOffset, parts)
+}
+
+// Create mocks base method.
+func (m *MockResumableUploadRepository) Create(ctx context.Context, upload *domain.ResumableUpload) (*domain.ResumableUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, upload)
+	ret0, _ := ret[0].(*domain.ResumableUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockResumableUploadRepositoryMockRecorder) Create(ctx, upload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockResumableUploadRepository)(nil).Create), ctx, upload)
+}
+
+// GetByFileID mocks base method.
+func (m *MockResumableUploadRepository) GetByFileID(ctx context.Context, fileID string) (*domain.ResumableUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByFileID", ctx, fileID)
+	ret0, _ := ret[0].(*domain.ResumableUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByFileID indicates an expected call of GetByFileID.
+func (mr *MockResumableUploadRepositoryMockRecorder) GetByFileID(ctx, fileID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByFileID", reflect.TypeOf((*MockResumableUploadRepository)(nil).GetByFileID), ctx, fileID)
+}
+
+// Lock mocks base method.
+func (m *MockResumableUploadRepository) Lock(ctx context.Context, fileID string) (func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, fileID)
+	ret0, _ := ret[0].(func())
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockResumableUploadRepositoryMockRecorder) Lock(ctx, fileID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockResumableUploadRepository)(nil).Lock), ctx, fileID)
+}
+
+// MockThumbnailRepository is a mock of ThumbnailRepository interface.
+type MockThumbnailRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockThumbnailRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockThumbnailRepositoryMockRecorder is the mock recorder for MockThumbnailRepository.
+type MockThumbnailRepositoryMockRecorder struct {
+	mock *MockThumbnailRepository
+}
+
+// NewMockThumbnailRepository creates a new mock instance.
+func NewMockThumbnailRepository(ctrl *gomock.Controller) *MockThumbnailRepository {
+	mock := &MockThumbnailRepository{ctrl: ctrl}
+	mock.recorder = &MockThumbnailRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockThumbnailRepository) EXPECT() *MockThumbnailRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteByFileID mocks base method.
+func (m *MockThumbnailRepository) DeleteByFileID(ctx context.Context, fileID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByFileID", ctx, fileID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByFileID indicates an expected call of DeleteByFileID.
+func (mr *MockThumbnailRepositoryMockRecorder) DeleteByFileID(ctx, fileID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByFileID", reflect.TypeOf((*MockThumbnailRepository)(nil).DeleteByFileID), ctx, fileID)
+}
+
+// Get mocks base method.
+func (m *MockThumbnailRepository) Get(ctx context.Context, fileID string, size int) (*domain.Thumbnail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, fileID, size)
+	ret0, _ := ret[0].(*domain.Thumbnail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockThumbnailRepositoryMockRecorder) Get(ctx, fileID, size any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockThumbnailRepository)(nil).Get), ctx, fileID, size)
+}
+
+// ListByFileID mocks base method.
+func (m *MockThumbnailRepository) ListByFileID(ctx context.Context, fileID string) ([]*domain.Thumbnail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByFileID", ctx, fileID)
+	ret0, _ := ret[0].([]*domain.Thumbnail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByFileID indicates an expected call of ListByFileID.
+func (mr *MockThumbnailRepositoryMockRecorder) ListByFileID(ctx, fileID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByFileID", reflect.TypeOf((*MockThumbnailRepository)(nil).ListByFileID), ctx, fileID)
+}
+
+// Save mocks base method.
+func (m *MockThumbnailRepository) Save(ctx context.Context, thumbnail *domain.Thumbnail) (*domain.Thumbnail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, thumbnail)
+	ret0, _ := ret[0].(*domain.Thumbnail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockThumbnailRepositoryMockRecorder) Save(ctx, thumbnail any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockThumbnailRepository)(nil).Save), ctx, thumbnail)
+}
+
+// MockJobRepository is a mock of JobRepository interface.
+type MockJobRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockJobRepositoryMockRecorder is the mock recorder for MockJobRepository.
+type MockJobRepositoryMockRecorder struct {
+	mock *MockJobRepository
+}
+
+// NewMockJobRepository creates a new mock instance.
+func NewMockJobRepository(ctrl *gomock.Controller) *MockJobRepository {
+	mock := &MockJobRepository{ctrl: ctrl}
+	mock.recorder = &MockJobRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobRepository) EXPECT() *MockJobRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockJobRepository) Claim(ctx context.Context, kinds []domain.JobKind, lease time.Duration, limit, maxAttempts int) ([]*domain.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, kinds, lease, limit, maxAttempts)
+	ret0, _ := ret[0].([]*domain.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockJobRepositoryMockRecorder) Claim(ctx, kinds, lease, limit, maxAttempts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockJobRepository)(nil).Claim), ctx, kinds, lease, limit, maxAttempts)
+}
+
+// DeleteFinished mocks base method.
+func (m *MockJobRepository) DeleteFinished(ctx context.Context, doneBefore, deadBefore time.Time, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFinished", ctx, doneBefore, deadBefore, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteFinished indicates an expected call of DeleteFinished.
+func (mr *MockJobRepositoryMockRecorder) DeleteFinished(ctx, doneBefore, deadBefore, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFinished", reflect.TypeOf((*MockJobRepository)(nil).DeleteFinished), ctx, doneBefore, deadBefore, limit)
+}
+
+// Enqueue mocks base method.
+func (m *MockJobRepository) Enqueue(ctx context.Context, job *domain.Job) (*domain.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", ctx, job)
+	ret0, _ := ret[0].(*domain.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockJobRepositoryMockRecorder) Enqueue(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockJobRepository)(nil).Enqueue), ctx, job)
+}
+
+// Update mocks base method.
+func (m *MockJobRepository) Update(ctx context.Context, job *domain.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockJobRepositoryMockRecorder) Update(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockJobRepository)(nil).Update), ctx, job)
+}
+
+// MockFileProvider is a mock of FileProvider interface.
+type MockFileProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockFileProviderMockRecorder
+	isgomock struct{}
+}
+
+// MockFileProviderMockRecorder is the mock recorder for MockFileProvider.
+type MockFileProviderMockRecorder struct {
+	mock *MockFileProvider
+}
+
+// NewMockFileProvider creates a new mock instance.
+func NewMockFileProvider(ctrl *gomock.Controller) *MockFileProvider {
+	mock := &MockFileProvider{ctrl: ctrl}
+	mock.recorder = &MockFileProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFileProvider) EXPECT() *MockFileProviderMockRecorder {
+	return m.recorder
+}
+
+// AbortMultipartUpload mocks base method.
+func (m *MockFileProvider) AbortMultipartUpload(ctx context.Context, s3Path, uploadID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AbortMultipartUpload", ctx, s3Path, uploadID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AbortMultipartUpload indicates an expected call of AbortMultipartUpload.
+func (mr *MockFileProviderMockRecorder) AbortMultipartUpload(ctx, s3Path, uploadID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AbortMultipartUpload", reflect.TypeOf((*MockFileProvider)(nil).AbortMultipartUpload), ctx, s3Path, uploadID)
+}
+
+// CompleteMultipartUpload mocks base method.
+func (m *MockFileProvider) CompleteMultipartUpload(ctx context.Context, s3Path, uploadID string, parts []domain.UploadPart) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteMultipartUpload", ctx, s3Path, uploadID, parts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteMultipartUpload indicates an expected call of CompleteMultipartUpload.
+func (mr *MockFileProviderMockRecorder) CompleteMultipartUpload(ctx, s3Path, uploadID, parts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMultipartUpload", reflect.TypeOf((*MockFileProvider)(nil).CompleteMultipartUpload), ctx, s3Path, uploadID, parts)
+}
+
+// CreateMultipartUpload mocks base method.
+func (m *MockFileProvider) CreateMultipartUpload(ctx context.Context, s3Path, contentType string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMultipartUpload", ctx, s3Path, contentType)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMultipartUpload indicates an expected call of CreateMultipartUpload.
+func (mr *MockFileProviderMockRecorder) CreateMultipartUpload(ctx, s3Path, contentType any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMultipartUpload", reflect.TypeOf((*MockFileProvider)(nil).CreateMultipartUpload), ctx, s3Path, contentType)
+}
+
+// DeleteObject mocks base method.
+func (m *MockFileProvider) DeleteObject(ctx context.Context, s3Path string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteObject", ctx, s3Path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteObject indicates an expected call of DeleteObject.
+func (mr *MockFileProviderMockRecorder) DeleteObject(ctx, s3Path any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteObject", reflect.TypeOf((*MockFileProvider)(nil).DeleteObject), ctx, s3Path)
+}
+
+// GenerateDownloadURL mocks base method.
+func (m *MockFileProvider) GenerateDownloadURL(ctx context.Context, s3Path string, ttl time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateDownloadURL", ctx, s3Path, ttl)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateDownloadURL indicates an expected call of GenerateDownloadURL.
+func (mr *MockFileProviderMockRecorder) GenerateDownloadURL(ctx, s3Path, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateDownloadURL", reflect.TypeOf((*MockFileProvider)(nil).GenerateDownloadURL), ctx, s3Path, ttl)
+}
+
+// GeneratePartUploadURL mocks base method.
+func (m *MockFileProvider) GeneratePartUploadURL(ctx context.Context, s3Path, uploadID string, partNumber int, ttl time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GeneratePartUploadURL", ctx, s3Path, uploadID, partNumber, ttl)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GeneratePartUploadURL indicates an expected call of GeneratePartUploadURL.
+func (mr *MockFileProviderMockRecorder) GeneratePartUploadURL(ctx, s3Path, uploadID, partNumber, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GeneratePartUploadURL", reflect.TypeOf((*MockFileProvider)(nil).GeneratePartUploadURL), ctx, s3Path, uploadID, partNumber, ttl)
+}
+
+// GenerateUploadURL mocks base method.
+func (m *MockFileProvider) GenerateUploadURL(ctx context.Context, s3Path, contentType string, maxSize int64, checksum domain.Checksum, ttl time.Duration) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateUploadURL", ctx, s3Path, contentType, maxSize, checksum, ttl)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateUploadURL indicates an expected call of GenerateUploadURL.
+func (mr *MockFileProviderMockRecorder) GenerateUploadURL(ctx, s3Path, contentType, maxSize, checksum, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateUploadURL", reflect.TypeOf((*MockFileProvider)(nil).GenerateUploadURL), ctx, s3Path, contentType, maxSize, checksum, ttl)
+}
+
+// ListObjects mocks base method.
+func (m *MockFileProvider) ListObjects(ctx context.Context, prefix string, recursive bool) ([]domain.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListObjects", ctx, prefix, recursive)
+	ret0, _ := ret[0].([]domain.ObjectInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListObjects indicates an expected call of ListObjects.
+func (mr *MockFileProviderMockRecorder) ListObjects(ctx, prefix, recursive any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListObjects", reflect.TypeOf((*MockFileProvider)(nil).ListObjects), ctx, prefix, recursive)
+}
+
+// OpenObject mocks base method.
+func (m *MockFileProvider) OpenObject(ctx context.Context, s3Path string) (io.ReadSeekCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenObject", ctx, s3Path)
+	ret0, _ := ret[0].(io.ReadSeekCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenObject indicates an expected call of OpenObject.
+func (mr *MockFileProviderMockRecorder) OpenObject(ctx, s3Path any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenObject", reflect.TypeOf((*MockFileProvider)(nil).OpenObject), ctx, s3Path)
+}
+
+// PutObject mocks base method.
+func (m *MockFileProvider) PutObject(ctx context.Context, s3Path, contentType string, content io.Reader, size int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutObject", ctx, s3Path, contentType, content, size)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PutObject indicates an expected call of PutObject.
+func (mr *MockFileProviderMockRecorder) PutObject(ctx, s3Path, contentType, content, size any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutObject", reflect.TypeOf((*MockFileProvider)(nil).PutObject), ctx, s3Path, contentType, content, size)
+}
+
+// StatObject mocks base method.
+func (m *MockFileProvider) StatObject(ctx context.Context, s3Path string) (*domain.ObjectInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatObject", ctx, s3Path)
+	ret0, _ := ret[0].(*domain.ObjectInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StatObject indicates an expected call of StatObject.
+func (mr *MockFileProviderMockRecorder) StatObject(ctx, s3Path any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatObject", reflect.TypeOf((*MockFileProvider)(nil).StatObject), ctx, s3Path)
+}
+
+// UploadPart mocks base method.
+func (m *MockFileProvider) UploadPart(ctx context.Context, s3Path, uploadID string, partNumber int, content io.Reader, size int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadPart", ctx, s3Path, uploadID, partNumber, content, size)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadPart indicates an expected call of UploadPart.
+func (mr *MockFileProviderMockRecorder) UploadPart(ctx, s3Path, uploadID, partNumber, content, size any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadPart", reflect.TypeOf((*MockFileProvider)(nil).UploadPart), ctx, s3Path, uploadID, partNumber, content, size)
+}
+
+// MockThumbnailer is a mock of Thumbnailer interface.
+type MockThumbnailer struct {
+	ctrl     *gomock.Controller
+	recorder *MockThumbnailerMockRecorder
+	isgomock struct{}
+}
+
+// MockThumbnailerMockRecorder is the mock recorder for MockThumbnailer.
+type MockThumbnailerMockRecorder struct {
+	mock *MockThumbnailer
+}
+
+// NewMockThumbnailer creates a new mock instance.
+func NewMockThumbnailer(ctrl *gomock.Controller) *MockThumbnailer {
+	mock := &MockThumbnailer{ctrl: ctrl}
+	mock.recorder = &MockThumbnailerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockThumbnailer) EXPECT() *MockThumbnailerMockRecorder {
+	return m.recorder
+}
+
+// Thumbnail mocks base method.
+func (m *MockThumbnailer) Thumbnail(ctx context.Context, content io.ReadSeeker, sizes []int) ([]*domain.RenderedThumbnail, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Thumbnail", ctx, content, sizes)
+	ret0, _ := ret[0].([]*domain.RenderedThumbnail)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Thumbnail indicates an expected call of Thumbnail.
+func (mr *MockThumbnailerMockRecorder) Thumbnail(ctx, content, sizes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Thumbnail", reflect.TypeOf((*MockThumbnailer)(nil).Thumbnail), ctx, content, sizes)
+}
+
+// MockMetadataStripper is a mock of MetadataStripper interface.
+type MockMetadataStripper struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetadataStripperMockRecorder
+	isgomock struct{}
+}
+
+// MockMetadataStripperMockRecorder is the mock recorder for MockMetadataStripper.
+type MockMetadataStripperMockRecorder struct {
+	mock *MockMetadataStripper
+}
+
+// NewMockMetadataStripper creates a new mock instance.
+func NewMockMetadataStripper(ctrl *gomock.Controller) *MockMetadataStripper {
+	mock := &MockMetadataStripper{ctrl: ctrl}
+	mock.recorder = &MockMetadataStripperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetadataStripper) EXPECT() *MockMetadataStripperMockRecorder {
+	return m.recorder
+}
+
+// Strip mocks base method.
+func (m *MockMetadataStripper) Strip(ctx context.Context, dst io.Writer, content io.Reader) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Strip", ctx, dst, content)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Strip indicates an expected call of Strip.
+func (mr *MockMetadataStripperMockRecorder) Strip(ctx, dst, content any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Strip", reflect.TypeOf((*MockMetadataStripper)(nil).Strip), ctx, dst, content)
+}
+
+// MockScanner is a mock of Scanner interface.
+type MockScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockScannerMockRecorder
+	isgomock struct{}
+}
+
+// MockScannerMockRecorder is the mock recorder for MockScanner.
+type MockScannerMockRecorder struct {
+	mock *MockScanner
+}
+
+// NewMockScanner creates a new mock instance.
+func NewMockScanner(ctrl *gomock.Controller) *MockScanner {
+	mock := &MockScanner{ctrl: ctrl}
+	mock.recorder = &MockScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScanner) EXPECT() *MockScannerMockRecorder {
+	return m.recorder
+}
+
+// Scan mocks base method.
+func (m *MockScanner) Scan(ctx context.Context, content io.Reader) (*domain.ScanResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", ctx, content)
+	ret0, _ := ret[0].(*domain.ScanResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockScannerMockRecorder) Scan(ctx, content any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateUploadURL", reflect.TypeOf((*MockFileProvider)(nil).GenerateUploadURL), ctx, s3Path, contentType, maxSize, ttl)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockScanner)(nil).Scan), ctx, content)
 }
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+)
+
+// sniffLen is how much of the content media type sniffing looks at.
+const sniffLen = 512
+
+// maxSizeFor narrows the limit of an upload flow to the limit the content
+// policy sets for the type, if that is lower.
+func (s *FileService) maxSizeFor(contentType string, limit int64) int64 {
+	if typeLimit := s.contentPolicy.MaxSize(contentType); typeLimit > 0 && typeLimit < limit {
+		return typeLimit
+	}
+	return limit
+}
+
+// sniffStream refuses a streamed body whose first bytes do not look like the
+// declared type, before anything is stored. The returned reader still yields
+// the whole body.
+func sniffStream(content io.Reader, contentType string) (io.Reader, error) {
+	buffered := bufio.NewReaderSize(content, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%w: failed to read file content: %v", domain.ErrInvalidInput, err)
+	}
+
+	if sniffed := http.DetectContentType(head); !domain.SniffedTypeMatches(contentType, sniffed) {
+		return nil, fmt.Errorf("%w: content looks like %s, not %s", domain.ErrContentMismatch, domain.MediaType(sniffed), domain.MediaType(contentType))
+	}
+
+	return buffered, nil
+}
@@ -2,102 +2,171 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime"
+	"net/http"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/gruzdev-dev/codex-files/core/domain"
 	"github.com/gruzdev-dev/codex-files/core/ports"
 
 	"github.com/gruzdev-dev/codex-files/pkg/identity"
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
 )
 
+const binaryResourceType = "Binary"
+
+type FileServiceConfig struct {
+	UploadMaxSize    int64
+	MultipartMaxSize int64
+	PartSize         int64
+	UploadTTL        time.Duration
+	DownloadTTL      time.Duration
+	RestoreWindow    time.Duration
+	ContentPolicy    domain.ContentPolicy
+	// MetadataPolicy selects the images whose metadata is stripped before
+	// they are scanned or handed out.
+	MetadataPolicy domain.MetadataPolicy
+	// Thumbnails queues thumbnail generation for images once their content
+	// is available.
+	Thumbnails bool
+	// OwnerQuota and TenantQuota are the limits that apply when no override
+	// is stored; only their MaxBytes and MaxFiles are used.
+	OwnerQuota  domain.Quota
+	TenantQuota domain.Quota
+	// Tenants are the configured tenant IDs, which owners may not take as
+	// their IDs.
+	Tenants []string
+}
+
 type FileService struct {
-	repo          ports.FileRepository
-	fileProvider  ports.FileProvider
-	uploadMaxSize int64
-	uploadTTL     time.Duration
-	downloadTTL   time.Duration
+	repo             ports.FileRepository
+	uploads          ports.MultipartUploadRepository
+	grants           ports.FileGrantRepository
+	quotas           ports.QuotaRepository
+	jobs             ports.JobRepository
+	fileProvider     ports.FileProvider
+	scanner          ports.Scanner
+	uploadMaxSize    int64
+	multipartMaxSize int64
+	partSize         int64
+	uploadTTL        time.Duration
+	downloadTTL      time.Duration
+	restoreWindow    time.Duration
+	contentPolicy    domain.ContentPolicy
+	metadataPolicy   domain.MetadataPolicy
+	ownerQuota       domain.Quota
+	tenantQuota      domain.Quota
+	thumbnails       bool
+	tenants          []string
 }
 
+// NewFileService wires the file service. A nil scanner disables antivirus
+// scanning, and stored content is then handed out once it is uploaded.
 func NewFileService(
 	repo ports.FileRepository,
+	uploads ports.MultipartUploadRepository,
+	grants ports.FileGrantRepository,
+	quotas ports.QuotaRepository,
+	jobs ports.JobRepository,
 	fileProvider ports.FileProvider,
-	uploadMaxSize int64,
-	uploadTTL time.Duration,
-	downloadTTL time.Duration,
+	scanner ports.Scanner,
+	cfg FileServiceConfig,
 ) *FileService {
 	return &FileService{
-		repo:          repo,
-		fileProvider:  fileProvider,
-		uploadMaxSize: uploadMaxSize,
-		uploadTTL:     uploadTTL,
-		downloadTTL:   downloadTTL,
+		repo:             repo,
+		uploads:          uploads,
+		grants:           grants,
+		quotas:           quotas,
+		jobs:             jobs,
+		fileProvider:     fileProvider,
+		scanner:          scanner,
+		uploadMaxSize:    cfg.UploadMaxSize,
+		multipartMaxSize: cfg.MultipartMaxSize,
+		partSize:         cfg.PartSize,
+		uploadTTL:        cfg.UploadTTL,
+		downloadTTL:      cfg.DownloadTTL,
+		restoreWindow:    cfg.RestoreWindow,
+		contentPolicy:    cfg.ContentPolicy,
+		metadataPolicy:   cfg.MetadataPolicy,
+		ownerQuota:       cfg.OwnerQuota,
+		tenantQuota:      cfg.TenantQuota,
+		thumbnails:       cfg.Thumbnails,
+		tenants:          cfg.Tenants,
 	}
 }
 
-func (s *FileService) GenerateUploadURL(ctx context.Context, ownerID, contentType string, size int64) (*domain.GenerateUploadURLResult, error) {
+func (s *FileService) GenerateUploadURL(ctx context.Context, ownerID, contentType string, size int64, checksum domain.Checksum) (*domain.GenerateUploadURLResult, error) {
 	if ownerID == "" {
 		return nil, fmt.Errorf("%w: owner ID is required", domain.ErrInvalidInput)
 	}
-	if contentType == "" {
-		return nil, fmt.Errorf("%w: content type is required", domain.ErrInvalidInput)
+	if err := domain.ValidateOwnerID(ownerID, s.tenants); err != nil {
+		return nil, err
 	}
-	if size <= 0 {
-		return nil, fmt.Errorf("%w: file size must be positive", domain.ErrInvalidInput)
+	if err := s.validateContent(contentType, size, s.uploadMaxSize); err != nil {
+		return nil, err
+	}
+	if !checksum.IsZero() {
+		if err := checksum.Validate(); err != nil {
+			return nil, err
+		}
 	}
-	if size > s.uploadMaxSize {
-		return nil, fmt.Errorf("%w: file size exceeds maximum allowed size", domain.ErrInvalidInput)
+	quotas, err := s.checkQuota(ctx, ownerID, size)
+	if err != nil {
+		return nil, err
 	}
 
-	file := domain.NewFile(ownerID, contentType, size)
+	file := domain.NewFile(tenant.FromCtx(ctx), ownerID, contentType, size)
+	file.Checksum = checksum
 
-	created, err := s.repo.Create(ctx, file)
+	created, err := s.createFile(ctx, file, quotas)
 	if err != nil {
-		return nil, fmt.Errorf("%w: failed to create file record: %v", domain.ErrInternal, err)
+		return nil, err
 	}
 
 	uploadURL, err := s.fileProvider.GenerateUploadURL(
 		ctx,
 		created.S3Path,
 		created.ContentType,
-		s.uploadMaxSize,
+		s.maxSizeFor(created.ContentType, s.uploadMaxSize),
+		checksum,
 		s.uploadTTL,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("%w: failed to generate upload URL: %v", domain.ErrInternal, err)
 	}
 
+	// Signed headers must be sent verbatim with the PUT or the store rejects it.
+	uploadHeaders := map[string]string{"Content-Type": created.ContentType}
+	if !checksum.IsZero() {
+		uploadHeaders["x-amz-checksum-"+string(checksum.Algorithm)] = checksum.Base64()
+	}
+
 	downloadURL := fmt.Sprintf("/files/%s/download", created.ID)
 
 	return &domain.GenerateUploadURLResult{
-		FileID:      created.ID,
-		UploadURL:   uploadURL,
-		DownloadURL: downloadURL,
+		FileID:        created.ID,
+		UploadURL:     uploadURL,
+		UploadHeaders: uploadHeaders,
+		DownloadURL:   downloadURL,
 	}, nil
 }
 
 func (s *FileService) GetDownloadURL(ctx context.Context, fileID string) (*domain.GetDownloadURLResult, error) {
-	if fileID == "" {
-		return nil, fmt.Errorf("%w: file ID is required", domain.ErrFileIDRequired)
-	}
-
-	file, err := s.repo.GetByID(ctx, fileID)
+	file, err := s.getAccessibleFile(ctx, fileID, domain.PermissionRead)
 	if err != nil {
-		if err == domain.ErrFileNotFound {
-			return nil, err
-		}
-		return nil, fmt.Errorf("%w: failed to get file: %v", domain.ErrInternal, err)
+		return nil, err
 	}
 
-	user, ok := identity.FromCtx(ctx)
-	if !ok {
-		return nil, domain.ErrAccessDenied
-	}
-
-	if !s.hasAccess(file, user.UserID, user.Scopes) {
-		return nil, domain.ErrAccessDenied
+	// Content is only handed out once the upload has been confirmed, its type
+	// checked and, when scanning is enabled, found clean.
+	if !s.contentAvailable(file) {
+		return nil, fmt.Errorf("%w: file content is not available", domain.ErrFileNotFound)
 	}
 
 	downloadURL, err := s.fileProvider.GenerateDownloadURL(
@@ -130,40 +199,464 @@ func (s *FileService) DeleteFile(ctx context.Context, fileID string) error {
 	return nil
 }
 
-func (s *FileService) hasAccess(file *domain.File, userID string, scopes []string) bool {
-	if file.OwnerID == userID {
-		return true
+func (s *FileService) CreateFile(ctx context.Context, contentType, securityContext string, content io.Reader, size int64) (*domain.File, error) {
+	user, ok := identity.FromCtx(ctx)
+	if !ok || user.UserID == "" {
+		return nil, domain.ErrUnauthenticated
+	}
+	if err := domain.ValidateOwnerID(user.UserID, s.tenants); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateContent(contentType, size, s.uploadMaxSize); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateReference(securityContext); err != nil {
+		return nil, err
+	}
+	quotas, err := s.checkQuota(ctx, user.UserID, size)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err = sniffStream(content, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	file := domain.NewFile(tenant.FromCtx(ctx), user.UserID, contentType, size)
+	file.SecurityContext = securityContext
+
+	if err := s.fileProvider.PutObject(ctx, file.S3Path, file.ContentType, content, file.Size); err != nil {
+		return nil, fmt.Errorf("%w: failed to store file content: %v", domain.ErrInternal, err)
+	}
+
+	s.markStored(file)
+
+	created, err := s.createStoredFile(ctx, file, quotas)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queueStoredProcessing(ctx, created), nil
+}
+
+func (s *FileService) GetFile(ctx context.Context, fileID string) (*domain.File, error) {
+	return s.getAccessibleFile(ctx, fileID, domain.PermissionRead)
+}
+
+func (s *FileService) OpenFile(ctx context.Context, fileID string) (*domain.File, io.ReadSeekCloser, error) {
+	file, err := s.getAccessibleFile(ctx, fileID, domain.PermissionRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !s.contentAvailable(file) {
+		return nil, nil, fmt.Errorf("%w: file content is not available", domain.ErrFileNotFound)
+	}
+
+	content, err := s.fileProvider.OpenObject(ctx, file.S3Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: failed to open file content: %v", domain.ErrInternal, err)
+	}
+
+	return file, content, nil
+}
+
+func (s *FileService) UpdateFile(ctx context.Context, fileID, contentType, securityContext string, content io.Reader, size int64) (*domain.File, error) {
+	file, err := s.getAccessibleFile(ctx, fileID, domain.PermissionWrite)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateContent(contentType, size, s.uploadMaxSize); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateReference(securityContext); err != nil {
+		return nil, err
+	}
+
+	content, err = sniffStream(content, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	// The quotas stay locked until the new size is recorded, as the content
+	// is replaced in place and cannot be taken back.
+	release, err := s.lockGrowth(ctx, file.OwnerID, size-file.Size)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := s.fileProvider.PutObject(ctx, file.S3Path, contentType, content, size); err != nil {
+		return nil, fmt.Errorf("%w: failed to store file content: %v", domain.ErrInternal, err)
+	}
+
+	file.ContentType = contentType
+	file.SecurityContext = securityContext
+	file.Size = size
+	s.markStored(file)
+
+	updated, err := s.repo.Update(ctx, file)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: failed to update file record: %v", domain.ErrInternal, err)
+	}
+
+	return s.queueStoredProcessing(ctx, updated), nil
+}
+
+func (s *FileService) RemoveFile(ctx context.Context, fileID string) error {
+	if _, err := s.getAccessibleFile(ctx, fileID, domain.PermissionDelete); err != nil {
+		return err
+	}
+
+	return s.DeleteFile(ctx, fileID)
+}
+
+func (s *FileService) RestoreFile(ctx context.Context, fileID string) (*domain.File, error) {
+	if fileID == "" {
+		return nil, fmt.Errorf("%w: file ID is required", domain.ErrFileIDRequired)
+	}
+
+	file, err := s.repo.GetDeletedByID(ctx, fileID)
+	if err != nil {
+		if err == domain.ErrFileNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: failed to get file: %v", domain.ErrInternal, err)
+	}
+
+	user, ok := identity.FromCtx(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthenticated
+	}
+
+	// Grants and SMART scopes do not reach deleted files: only the owner or
+	// a holder of the file's delete scope may bring one back.
+	isOwner := user.UserID != "" && file.OwnerID == user.UserID
+	if !isOwner && !user.HasScope(fmt.Sprintf("files:file:%s:%s", file.ID, domain.PermissionDelete)) {
+		return nil, domain.ErrAccessDenied
+	}
+
+	now := time.Now()
+	deletedAfter := now.Add(-s.restoreWindow)
+	if file.DeletedAt.Before(deletedAfter) {
+		return nil, domain.ErrRestoreExpired
+	}
+
+	restored, err := s.repo.Restore(ctx, fileID, deletedAfter, &domain.FileEvent{
+		FileID:    fileID,
+		Action:    domain.FileActionRestored,
+		ActorID:   user.UserID,
+		CreatedAt: now,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			// The purger claimed the file between the check and the update.
+			return nil, domain.ErrRestoreExpired
+		}
+		return nil, fmt.Errorf("%w: failed to restore file: %v", domain.ErrInternal, err)
 	}
 
-	requiredScope := fmt.Sprintf("files:file:%s:read", file.ID)
-	return slices.Contains(scopes, requiredScope)
+	return restored, nil
 }
 
-func (s *FileService) ConfirmUpload(ctx context.Context, fileID string) error {
+func (s *FileService) getAccessibleFile(ctx context.Context, fileID string, permission domain.Permission) (*domain.File, error) {
+	if fileID == "" {
+		return nil, fmt.Errorf("%w: file ID is required", domain.ErrFileIDRequired)
+	}
+
+	file, err := s.repo.GetByID(ctx, fileID)
+	if err != nil {
+		if err == domain.ErrFileNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: failed to get file: %v", domain.ErrInternal, err)
+	}
+
+	user, ok := identity.FromCtx(ctx)
+	if !ok {
+		return nil, domain.ErrUnauthenticated
+	}
+
+	allowed, err := s.hasAccess(ctx, file, user, permission)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, domain.ErrAccessDenied
+	}
+
+	return file, nil
+}
+
+func (s *FileService) validateContent(contentType string, size, maxSize int64) error {
+	if contentType == "" {
+		return fmt.Errorf("%w: content type is required", domain.ErrInvalidInput)
+	}
+	if size <= 0 {
+		return fmt.Errorf("%w: file size must be positive", domain.ErrInvalidInput)
+	}
+	if size > maxSize {
+		return fmt.Errorf("%w: file size exceeds maximum allowed size", domain.ErrInvalidInput)
+	}
+	return s.contentPolicy.Check(contentType, size)
+}
+
+func (s *FileService) hasAccess(ctx context.Context, file *domain.File, user domain.Identity, permission domain.Permission) (bool, error) {
+	if user.UserID != "" && file.OwnerID == user.UserID {
+		return true, nil
+	}
+
+	requiredScope := fmt.Sprintf("files:file:%s:%s", file.ID, permission)
+	if slices.Contains(user.Scopes, requiredScope) {
+		return true, nil
+	}
+
+	if user.CanAccessResource(file.SecurityContext, permission) {
+		return true, nil
+	}
+
+	if s.hasSMARTAccess(file, user, permission) {
+		return true, nil
+	}
+
+	// Grants are only looked up once the token itself has been found
+	// insufficient, so most requests never touch the grants table.
+	grantees := user.Grantees()
+	if len(grantees) == 0 {
+		return false, nil
+	}
+
+	granted, err := s.grants.HasGrant(ctx, file.ID, grantees, permission)
+	if err != nil {
+		return false, fmt.Errorf("%w: failed to check file grants: %v", domain.ErrInternal, err)
+	}
+
+	return granted, nil
+}
+
+// hasSMARTAccess applies SMART on FHIR scopes. Only system scopes reach every
+// file. User scopes only limit what the user may already reach: ownership and
+// grants are checked on their own, so here they only reach files whose
+// security context is in the fhirContext of the token.
+func (s *FileService) hasSMARTAccess(file *domain.File, user domain.Identity, permission domain.Permission) bool {
+	for _, scope := range user.SMARTScopes() {
+		if !scope.Allows(binaryResourceType, permission) {
+			continue
+		}
+
+		switch scope.Context {
+		case domain.SMARTContextSystem:
+			return true
+		case domain.SMARTContextUser:
+			if file.SecurityContext != "" && slices.Contains(user.FHIRContext, file.SecurityContext) {
+				return true
+			}
+		case domain.SMARTContextPatient:
+			if patient := user.PatientReference(); patient != "" && file.SecurityContext == patient {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (s *FileService) ConfirmUpload(ctx context.Context, fileID, eTag string) error {
+	_, err := s.confirmUpload(ctx, fileID, eTag)
+	return err
+}
+
+// QueueConfirmUpload records an upload event for the job workers, so that
+// the event is acknowledged without waiting for the object store.
+func (s *FileService) QueueConfirmUpload(ctx context.Context, fileID, eTag string) error {
 	if fileID == "" {
 		return fmt.Errorf("%w: file ID is required", domain.ErrFileIDRequired)
 	}
 
+	job := domain.NewJob(domain.JobKindConfirmUpload, fileID, map[string]string{"etag": eTag})
+	if _, err := s.jobs.Enqueue(ctx, job); err != nil {
+		return fmt.Errorf("%w: failed to queue upload confirmation: %v", domain.ErrInternal, err)
+	}
+	return nil
+}
+
+// ProcessConfirmUpload is the processor of upload confirmation jobs.
+func (s *FileService) ProcessConfirmUpload(ctx context.Context, job *domain.Job) error {
+	return s.ConfirmUpload(ctx, job.FileID, job.Payload["etag"])
+}
+
+// confirmUpload settles a pending file against the object that actually landed
+// in the bucket. eTag is the ETag reported by the upload event, if any; an
+// event that no longer matches the stored object is stale and ignored.
+func (s *FileService) confirmUpload(ctx context.Context, fileID, eTag string) (*domain.File, error) {
+	if fileID == "" {
+		return nil, fmt.Errorf("%w: file ID is required", domain.ErrFileIDRequired)
+	}
+
 	file, err := s.repo.GetByID(ctx, fileID)
 	if err != nil {
 		if err == domain.ErrFileNotFound {
 			log.Printf("webhook: file not found: %s", fileID)
-			return nil
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get file: %w", err)
+	}
+
+	// Webhooks and workers look files up across tenants, but the object
+	// lives in the storage of the file's own tenant.
+	ctx = tenant.WithCtx(ctx, file.TenantID)
+
+	// A repeated event queues the processing again in case queueing it failed
+	// the first time; a job that finds the file settled does nothing.
+	if file.Status == domain.FileStatusProcessing || file.Status == domain.FileStatusScanning {
+		return file, s.queueProcessing(ctx, file)
+	}
+	if file.Status != domain.FileStatusPending {
+		return file, nil
+	}
+
+	object, err := s.fileProvider.StatObject(ctx, file.S3Path)
+	if err != nil {
+		if errors.Is(err, domain.ErrObjectNotFound) {
+			log.Printf("object for file %s not found, leaving it pending", fileID)
+			return file, nil
 		}
-		return fmt.Errorf("failed to get file: %w", err)
+		return nil, fmt.Errorf("failed to stat file content: %w", err)
+	}
+
+	if eTag != "" && normalizeETag(eTag) != normalizeETag(object.ETag) {
+		log.Printf("stale upload event for file %s: etag %s, stored %s", fileID, eTag, object.ETag)
+		return file, nil
 	}
 
-	if file.Status == domain.FileStatusUploaded {
-		return nil
+	if reason := s.checkObject(file, object); reason != "" {
+		log.Printf("rejecting upload for file %s: %s", fileID, reason)
+		return s.rejectUpload(ctx, file, object)
 	}
 
-	if file.Status == domain.FileStatusPending {
-		file.MarkAsUploaded()
-		_, err := s.repo.Update(ctx, file)
+	file.Size = object.Size
+	file.ContentType = object.ContentType
+	file.ETag = normalizeETag(object.ETag)
+
+	sniffed, checksumMatches, err := s.inspectObject(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.SniffedTypeMatches(file.ContentType, sniffed) {
+		log.Printf("rejecting upload for file %s: content sniffed as %q does not match declared content type %q", fileID, sniffed, file.ContentType)
+		return s.rejectUpload(ctx, file, object)
+	}
+	if !checksumMatches {
+		log.Printf("checksum mismatch for file %s", fileID)
+		file.MarkAsCorrupt()
+		updated, err := s.repo.Update(ctx, file)
 		if err != nil {
-			return fmt.Errorf("failed to update file status: %w", err)
+			return nil, fmt.Errorf("failed to update file status: %w", err)
 		}
+		return updated, nil
 	}
 
-	return nil
+	s.markStored(file)
+	updated, err := s.repo.Update(ctx, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update file status: %w", err)
+	}
+	if err := s.queueProcessing(ctx, updated); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// checkObject returns why the stored object cannot be accepted for the file,
+// or an empty string if it matches what was declared.
+func (s *FileService) checkObject(file *domain.File, object *domain.ObjectInfo) string {
+	// Declared sizes are validated against the limit of the flow that created
+	// the file, and only multipart uploads may declare more than uploadMaxSize.
+	maxSize := s.uploadMaxSize
+	if file.Size > s.uploadMaxSize {
+		maxSize = s.multipartMaxSize
+	}
+
+	switch {
+	case object.Size > maxSize:
+		return fmt.Sprintf("size %d exceeds maximum allowed size %d", object.Size, maxSize)
+	case object.Size != file.Size:
+		return fmt.Sprintf("size %d does not match declared size %d", object.Size, file.Size)
+	case !sameMediaType(object.ContentType, file.ContentType):
+		return fmt.Sprintf("content type %q does not match declared content type %q", object.ContentType, file.ContentType)
+	}
+
+	return ""
+}
+
+func (s *FileService) rejectUpload(ctx context.Context, file *domain.File, object *domain.ObjectInfo) (*domain.File, error) {
+	file.Size = object.Size
+	file.ContentType = object.ContentType
+	file.ETag = normalizeETag(object.ETag)
+	file.MarkAsRejected()
+
+	updated, err := s.repo.Update(ctx, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update file status: %w", err)
+	}
+
+	if err := s.fileProvider.DeleteObject(ctx, file.S3Path); err != nil {
+		log.Printf("failed to delete rejected object for file %s: %v", file.ID, err)
+	}
+
+	return updated, nil
+}
+
+// inspectObject reads the stored object once. Its first bytes are sniffed
+// for the media type, and the whole of it is hashed when a checksum was
+// declared.
+func (s *FileService) inspectObject(ctx context.Context, file *domain.File) (string, bool, error) {
+	content, err := s.fileProvider.OpenObject(ctx, file.S3Path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to open file content: %w", err)
+	}
+	defer content.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", false, fmt.Errorf("failed to read file content: %w", err)
+	}
+	head = head[:n]
+	sniffed := http.DetectContentType(head)
+
+	if file.Checksum.IsZero() {
+		return sniffed, true, nil
+	}
+
+	hasher, err := file.Checksum.Algorithm.New()
+	if err != nil {
+		return "", false, err
+	}
+	hasher.Write(head)
+	if _, err := io.Copy(hasher, content); err != nil {
+		return "", false, fmt.Errorf("failed to read file content: %w", err)
+	}
+
+	return sniffed, file.Checksum.Matches(hasher.Sum(nil)), nil
+}
+
+func normalizeETag(eTag string) string {
+	return strings.Trim(eTag, `"`)
+}
+
+func sameMediaType(a, b string) bool {
+	aType, _, errA := mime.ParseMediaType(a)
+	bType, _, errB := mime.ParseMediaType(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b)
+	}
+	return aType == bType
 }
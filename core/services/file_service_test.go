@@ -1,14 +1,18 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gruzdev-dev/codex-files/core/domain"
 	"github.com/gruzdev-dev/codex-files/core/ports"
 	"github.com/gruzdev-dev/codex-files/pkg/identity"
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -25,15 +29,61 @@ const (
 	testUploadURL   = "https://s3.example.com/bucket/path?signature=upload"
 	testDownloadURL = "https://s3.example.com/bucket/path?signature=download"
 	testUserID      = "test-user-123"
+	testTenantID    = "test-tenant"
 )
 
+const testContent = "%PDF-1.7 test file content"
+
+func testChecksum() domain.Checksum {
+	sum := sha256.Sum256([]byte(testContent))
+	return domain.Checksum{Algorithm: domain.ChecksumSHA256, Value: sum[:]}
+}
+
+type nopSeekCloser struct {
+	*strings.Reader
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+// storedContent expects the stored object to be opened for sniffing and
+// serves testContent from it.
+func storedContent(provider *ports.MockFileProvider) {
+	provider.EXPECT().
+		OpenObject(gomock.Any(), gomock.Any()).
+		Return(nopSeekCloser{strings.NewReader(testContent)}, nil)
+}
+
+// noGrants returns a grant repository in which nobody holds a grant, for tests
+// that only exercise token-based access.
+func noGrants(ctrl *gomock.Controller) *ports.MockFileGrantRepository {
+	grants := ports.NewMockFileGrantRepository(ctrl)
+	grants.EXPECT().HasGrant(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	return grants
+}
+
+// noJobs returns a job queue that expects nothing to be queued.
+func noJobs(ctrl *gomock.Controller) *ports.MockJobRepository {
+	return ports.NewMockJobRepository(ctrl)
+}
+
+// noQuotas returns a quota repository without overrides, so that only the
+// configured default quotas apply.
+func noQuotas(ctrl *gomock.Controller) *ports.MockQuotaRepository {
+	quotas := ports.NewMockQuotaRepository(ctrl)
+	quotas.EXPECT().ListOverrides(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	return quotas
+}
+
 func TestFileService_GenerateUploadURL(t *testing.T) {
 	tests := []struct {
 		name           string
+		tenantID       string
 		ownerID        string
 		contentType    string
 		size           int64
+		checksum       domain.Checksum
 		uploadMaxSize  int64
+		contentPolicy  domain.ContentPolicy
 		setupMocks     func(*ports.MockFileRepository, *ports.MockFileProvider)
 		expectedError  error
 		validateResult func(*testing.T, *domain.GenerateUploadURLResult, error)
@@ -49,6 +99,7 @@ func TestFileService_GenerateUploadURL(t *testing.T) {
 					Create(gomock.Any(), gomock.Any()).
 					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
 						require.NotEmpty(t, file.ID)
+						require.Equal(t, domain.DefaultTenantID, file.TenantID)
 						require.Equal(t, testOwnerID, file.OwnerID)
 						require.Equal(t, testContentType, file.ContentType)
 						require.Equal(t, testFileSize, file.Size)
@@ -64,6 +115,7 @@ func TestFileService_GenerateUploadURL(t *testing.T) {
 						gomock.Any(),
 						testContentType,
 						testMaxSize,
+						domain.Checksum{},
 						gomock.Any(),
 					).
 					Return(testUploadURL, nil)
@@ -76,6 +128,101 @@ func TestFileService_GenerateUploadURL(t *testing.T) {
 				assert.Equal(t, testUploadURL, result.UploadURL)
 			},
 		},
+		{
+			name:          "success path - tenant prefixes the object key",
+			tenantID:      testTenantID,
+			ownerID:       testOwnerID,
+			contentType:   testContentType,
+			size:          testFileSize,
+			uploadMaxSize: testMaxSize,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, testTenantID, file.TenantID)
+						require.Equal(t, testTenantID+"/"+testOwnerID+"/"+file.ID, file.S3Path)
+						return file, nil
+					})
+				provider.EXPECT().
+					GenerateUploadURL(gomock.Any(), gomock.Any(), testContentType, testMaxSize, domain.Checksum{}, gomock.Any()).
+					DoAndReturn(func(ctx context.Context, s3Path, contentType string, maxSize int64, checksum domain.Checksum, ttl time.Duration) (string, error) {
+						require.Equal(t, testTenantID, tenant.FromCtx(ctx))
+						require.True(t, strings.HasPrefix(s3Path, testTenantID+"/"))
+						return testUploadURL, nil
+					})
+			},
+			validateResult: func(t *testing.T, result *domain.GenerateUploadURLResult, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, testUploadURL, result.UploadURL)
+			},
+		},
+		{
+			name:          "success path - with checksum",
+			ownerID:       testOwnerID,
+			contentType:   testContentType,
+			size:          testFileSize,
+			checksum:      testChecksum(),
+			uploadMaxSize: testMaxSize,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, testChecksum(), file.Checksum)
+						return file, nil
+					})
+				provider.EXPECT().
+					GenerateUploadURL(
+						gomock.Any(),
+						gomock.Any(),
+						testContentType,
+						testMaxSize,
+						testChecksum(),
+						gomock.Any(),
+					).
+					Return(testUploadURL, nil)
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, result *domain.GenerateUploadURLResult, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, result)
+				assert.Equal(t, testContentType, result.UploadHeaders["Content-Type"])
+				assert.Equal(t, testChecksum().Base64(), result.UploadHeaders["x-amz-checksum-sha256"])
+			},
+		},
+		{
+			name:        "invalid checksum length",
+			ownerID:     testOwnerID,
+			contentType: testContentType,
+			size:        testFileSize,
+			checksum: domain.Checksum{
+				Algorithm: domain.ChecksumSHA256,
+				Value:     []byte("short"),
+			},
+			uploadMaxSize: testMaxSize,
+			setupMocks:    func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			expectedError: domain.ErrInvalidInput,
+			validateResult: func(t *testing.T, result *domain.GenerateUploadURLResult, err error) {
+				assert.Nil(t, result)
+				assert.ErrorIs(t, err, domain.ErrInvalidInput)
+			},
+		},
+		{
+			name:        "unsupported checksum algorithm",
+			ownerID:     testOwnerID,
+			contentType: testContentType,
+			size:        testFileSize,
+			checksum: domain.Checksum{
+				Algorithm: "md5",
+				Value:     make([]byte, 16),
+			},
+			uploadMaxSize: testMaxSize,
+			setupMocks:    func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			expectedError: domain.ErrInvalidInput,
+			validateResult: func(t *testing.T, result *domain.GenerateUploadURLResult, err error) {
+				assert.Nil(t, result)
+				assert.ErrorIs(t, err, domain.ErrInvalidInput)
+			},
+		},
 		{
 			name:          "empty owner ID",
 			ownerID:       "",
@@ -91,6 +238,32 @@ func TestFileService_GenerateUploadURL(t *testing.T) {
 				assert.Contains(t, err.Error(), "owner ID is required")
 			},
 		},
+		{
+			name:          "owner ID with a slash",
+			ownerID:       "test/owner",
+			contentType:   testContentType,
+			size:          testFileSize,
+			uploadMaxSize: testMaxSize,
+			setupMocks:    func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			expectedError: domain.ErrInvalidInput,
+			validateResult: func(t *testing.T, result *domain.GenerateUploadURLResult, err error) {
+				assert.Nil(t, result)
+				assert.ErrorIs(t, err, domain.ErrInvalidInput)
+			},
+		},
+		{
+			name:          "owner ID of a tenant",
+			ownerID:       domain.DefaultTenantID,
+			contentType:   testContentType,
+			size:          testFileSize,
+			uploadMaxSize: testMaxSize,
+			setupMocks:    func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			expectedError: domain.ErrInvalidInput,
+			validateResult: func(t *testing.T, result *domain.GenerateUploadURLResult, err error) {
+				assert.Nil(t, result)
+				assert.ErrorIs(t, err, domain.ErrInvalidInput)
+			},
+		},
 		{
 			name:          "empty content type",
 			ownerID:       testOwnerID,
@@ -151,6 +324,56 @@ func TestFileService_GenerateUploadURL(t *testing.T) {
 				assert.Contains(t, err.Error(), "file size exceeds maximum allowed size")
 			},
 		},
+		{
+			name:          "content type not allowed",
+			ownerID:       testOwnerID,
+			contentType:   "application/x-msdownload",
+			size:          testFileSize,
+			uploadMaxSize: testMaxSize,
+			contentPolicy: domain.ContentPolicy{Allowed: []string{"application/pdf", "image/*"}},
+			setupMocks:    func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			expectedError: domain.ErrContentTypeNotAllowed,
+			validateResult: func(t *testing.T, result *domain.GenerateUploadURLResult, err error) {
+				assert.Nil(t, result)
+				assert.ErrorIs(t, err, domain.ErrContentTypeNotAllowed)
+			},
+		},
+		{
+			name:          "size exceeds limit for content type",
+			ownerID:       testOwnerID,
+			contentType:   testContentType,
+			size:          testFileSize,
+			uploadMaxSize: testMaxSize,
+			contentPolicy: domain.ContentPolicy{MaxSizes: map[string]int64{testContentType: testFileSize - 1}},
+			setupMocks:    func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			expectedError: domain.ErrFileTooLarge,
+			validateResult: func(t *testing.T, result *domain.GenerateUploadURLResult, err error) {
+				assert.Nil(t, result)
+				assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+			},
+		},
+		{
+			name:          "limit for content type caps the upload URL",
+			ownerID:       testOwnerID,
+			contentType:   testContentType,
+			size:          testFileSize,
+			uploadMaxSize: testMaxSize,
+			contentPolicy: domain.ContentPolicy{MaxSizes: map[string]int64{"application/*": testFileSize}},
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						return file, nil
+					})
+				provider.EXPECT().
+					GenerateUploadURL(gomock.Any(), gomock.Any(), testContentType, testFileSize, domain.Checksum{}, gomock.Any()).
+					Return(testUploadURL, nil)
+			},
+			validateResult: func(t *testing.T, result *domain.GenerateUploadURLResult, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, testUploadURL, result.UploadURL)
+			},
+		},
 		{
 			name:          "repository create error",
 			ownerID:       testOwnerID,
@@ -194,6 +417,7 @@ func TestFileService_GenerateUploadURL(t *testing.T) {
 						gomock.Any(),
 						gomock.Any(),
 						gomock.Any(),
+						gomock.Any(),
 					).
 					Return("", errors.New("s3 error"))
 			},
@@ -219,17 +443,31 @@ func TestFileService_GenerateUploadURL(t *testing.T) {
 
 			service := NewFileService(
 				repo,
+				ports.NewMockMultipartUploadRepository(ctrl),
+				noGrants(ctrl),
+				noQuotas(ctrl),
+				noJobs(ctrl),
 				provider,
-				tt.uploadMaxSize,
-				5*time.Minute,
-				15*time.Minute,
+				nil,
+				FileServiceConfig{
+					UploadMaxSize: tt.uploadMaxSize,
+					UploadTTL:     5 * time.Minute,
+					DownloadTTL:   15 * time.Minute,
+					ContentPolicy: tt.contentPolicy,
+				},
 			)
 
+			ctx := context.Background()
+			if tt.tenantID != "" {
+				ctx = tenant.WithCtx(ctx, tt.tenantID)
+			}
+
 			result, err := service.GenerateUploadURL(
-				context.Background(),
+				ctx,
 				tt.ownerID,
 				tt.contentType,
 				tt.size,
+				tt.checksum,
 			)
 
 			if tt.expectedError != nil {
@@ -251,6 +489,8 @@ func TestFileService_GetDownloadURL(t *testing.T) {
 		fileID         string
 		userID         string
 		scopes         []string
+		fhirContext    []string
+		patient        string
 		setupMocks     func(*ports.MockFileRepository, *ports.MockFileProvider)
 		expectedError  error
 		validateResult func(*testing.T, *domain.GetDownloadURLResult, error)
@@ -322,137 +562,356 @@ func TestFileService_GetDownloadURL(t *testing.T) {
 			},
 		},
 		{
-			name:          "empty file ID",
-			fileID:        "",
-			userID:        testUserID,
-			scopes:        []string{},
-			setupMocks:    func(*ports.MockFileRepository, *ports.MockFileProvider) {},
-			expectedError: domain.ErrFileIDRequired,
-			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
-				assert.Error(t, err)
-				assert.Nil(t, result)
-				assert.ErrorIs(t, err, domain.ErrFileIDRequired)
-			},
-		},
-		{
-			name:   "file not found",
+			name:   "success path - security context scope",
 			fileID: testFileID,
 			userID: testUserID,
-			scopes: []string{},
+			scopes: []string{"files:DocumentReference/abc:read"},
 			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
 				repo.EXPECT().
 					GetByID(gomock.Any(), testFileID).
-					Return(nil, domain.ErrFileNotFound)
+					Return(&domain.File{
+						ID:              testFileID,
+						OwnerID:         testOwnerID,
+						S3Path:          testS3Path,
+						SecurityContext: "DocumentReference/abc",
+						Status:          domain.FileStatusUploaded,
+					}, nil)
+				provider.EXPECT().
+					GenerateDownloadURL(gomock.Any(), testS3Path, gomock.Any()).
+					Return(testDownloadURL, nil)
 			},
-			expectedError: domain.ErrFileNotFound,
+			expectedError: nil,
 			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
-				assert.Error(t, err)
-				assert.Nil(t, result)
-				assert.Equal(t, domain.ErrFileNotFound, err)
+				require.NoError(t, err)
+				assert.Equal(t, testDownloadURL, result.DownloadURL)
 			},
 		},
 		{
-			name:   "repository error",
-			fileID: testFileID,
-			userID: testUserID,
-			scopes: []string{},
+			name:        "success path - security context in fhirContext claim",
+			fileID:      testFileID,
+			userID:      testUserID,
+			fhirContext: []string{"DocumentReference/abc"},
 			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
 				repo.EXPECT().
 					GetByID(gomock.Any(), testFileID).
-					Return(nil, errors.New("database error"))
+					Return(&domain.File{
+						ID:              testFileID,
+						OwnerID:         testOwnerID,
+						S3Path:          testS3Path,
+						SecurityContext: "DocumentReference/abc",
+						Status:          domain.FileStatusUploaded,
+					}, nil)
+				provider.EXPECT().
+					GenerateDownloadURL(gomock.Any(), testS3Path, gomock.Any()).
+					Return(testDownloadURL, nil)
 			},
-			expectedError: domain.ErrInternal,
+			expectedError: nil,
 			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
-				assert.Error(t, err)
-				assert.Nil(t, result)
-				assert.ErrorIs(t, err, domain.ErrInternal)
-				assert.Contains(t, err.Error(), "failed to get file")
+				require.NoError(t, err)
+				assert.Equal(t, testDownloadURL, result.DownloadURL)
 			},
 		},
 		{
-			name:   "access denied - not owner and no scopes",
+			name:   "access denied - scope for another security context",
 			fileID: testFileID,
 			userID: testUserID,
-			scopes: []string{},
+			scopes: []string{"files:DocumentReference/other:read"},
 			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
-				file := &domain.File{
-					ID:          testFileID,
-					OwnerID:     testOwnerID,
-					S3Path:      testS3Path,
-					ContentType: testContentType,
-					Size:        testFileSize,
-					Status:      domain.FileStatusUploaded,
-					IsDeleted:   false,
-				}
 				repo.EXPECT().
 					GetByID(gomock.Any(), testFileID).
-					Return(file, nil)
+					Return(&domain.File{
+						ID:              testFileID,
+						OwnerID:         testOwnerID,
+						S3Path:          testS3Path,
+						SecurityContext: "DocumentReference/abc",
+						Status:          domain.FileStatusUploaded,
+					}, nil)
 			},
 			expectedError: domain.ErrAccessDenied,
 			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
-				assert.Error(t, err)
 				assert.Nil(t, result)
 				assert.Equal(t, domain.ErrAccessDenied, err)
 			},
 		},
 		{
-			name:   "access denied - wrong scope",
-			fileID: testFileID,
-			userID: testUserID,
-			scopes: []string{"files:file:other-file-id:read"},
+			name:    "success path - SMART system scope",
+			fileID:  testFileID,
+			userID:  testUserID,
+			scopes:  []string{"system/Binary.read"},
+			patient: "",
 			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
-				file := &domain.File{
-					ID:          testFileID,
-					OwnerID:     testOwnerID,
-					S3Path:      testS3Path,
-					ContentType: testContentType,
-					Size:        testFileSize,
-					Status:      domain.FileStatusUploaded,
-					IsDeleted:   false,
-				}
 				repo.EXPECT().
 					GetByID(gomock.Any(), testFileID).
-					Return(file, nil)
+					Return(&domain.File{
+						ID:              testFileID,
+						OwnerID:         testOwnerID,
+						S3Path:          testS3Path,
+						SecurityContext: "",
+						Status:          domain.FileStatusUploaded,
+					}, nil)
+				provider.EXPECT().
+					GenerateDownloadURL(gomock.Any(), testS3Path, gomock.Any()).
+					Return(testDownloadURL, nil)
 			},
-			expectedError: domain.ErrAccessDenied,
+			expectedError: nil,
 			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
-				assert.Error(t, err)
-				assert.Nil(t, result)
-				assert.Equal(t, domain.ErrAccessDenied, err)
+				require.NoError(t, err)
+				assert.Equal(t, testDownloadURL, result.DownloadURL)
 			},
 		},
 		{
-			name:   "file provider error",
-			fileID: testFileID,
-			userID: testOwnerID,
-			scopes: []string{},
+			name:    "access denied - SMART user scope on another user's file",
+			fileID:  testFileID,
+			userID:  testUserID,
+			scopes:  []string{"user/Binary.read"},
+			patient: "",
 			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
-				file := &domain.File{
-					ID:          testFileID,
-					OwnerID:     testOwnerID,
-					S3Path:      testS3Path,
-					ContentType: testContentType,
-					Size:        testFileSize,
-					Status:      domain.FileStatusUploaded,
-					IsDeleted:   false,
-				}
 				repo.EXPECT().
 					GetByID(gomock.Any(), testFileID).
-					Return(file, nil)
-				provider.EXPECT().
-					GenerateDownloadURL(
-						gomock.Any(),
-						gomock.Any(),
-						gomock.Any(),
-					).
-					Return("", errors.New("s3 error"))
+					Return(&domain.File{
+						ID:              testFileID,
+						OwnerID:         testOwnerID,
+						S3Path:          testS3Path,
+						SecurityContext: "Patient/456",
+						Status:          domain.FileStatusUploaded,
+					}, nil)
 			},
-			expectedError: domain.ErrInternal,
+			expectedError: domain.ErrAccessDenied,
 			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
-				assert.Error(t, err)
 				assert.Nil(t, result)
-				assert.ErrorIs(t, err, domain.ErrInternal)
-				assert.Contains(t, err.Error(), "failed to generate download URL")
+				assert.Equal(t, domain.ErrAccessDenied, err)
+			},
+		},
+		{
+			name:        "success path - SMART user scope in fhirContext",
+			fileID:      testFileID,
+			userID:      testUserID,
+			scopes:      []string{"user/Binary.read"},
+			fhirContext: []string{"Patient/456"},
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{
+						ID:              testFileID,
+						OwnerID:         testOwnerID,
+						S3Path:          testS3Path,
+						SecurityContext: "Patient/456",
+						Status:          domain.FileStatusUploaded,
+					}, nil)
+				provider.EXPECT().
+					GenerateDownloadURL(gomock.Any(), testS3Path, gomock.Any()).
+					Return(testDownloadURL, nil)
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, testDownloadURL, result.DownloadURL)
+			},
+		},
+		{
+			name:    "success path - SMART patient scope in compartment",
+			fileID:  testFileID,
+			userID:  testUserID,
+			scopes:  []string{"patient/Binary.rs"},
+			patient: "123",
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{
+						ID:              testFileID,
+						OwnerID:         testOwnerID,
+						S3Path:          testS3Path,
+						SecurityContext: "Patient/123",
+						Status:          domain.FileStatusUploaded,
+					}, nil)
+				provider.EXPECT().
+					GenerateDownloadURL(gomock.Any(), testS3Path, gomock.Any()).
+					Return(testDownloadURL, nil)
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, testDownloadURL, result.DownloadURL)
+			},
+		},
+		{
+			name:    "access denied - SMART patient scope outside compartment",
+			fileID:  testFileID,
+			userID:  testUserID,
+			scopes:  []string{"patient/Binary.rs"},
+			patient: "123",
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{
+						ID:              testFileID,
+						OwnerID:         testOwnerID,
+						S3Path:          testS3Path,
+						SecurityContext: "Patient/456",
+						Status:          domain.FileStatusUploaded,
+					}, nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
+				assert.Nil(t, result)
+				assert.Equal(t, domain.ErrAccessDenied, err)
+			},
+		},
+		{
+			name:    "access denied - SMART scope for another resource type",
+			fileID:  testFileID,
+			userID:  testUserID,
+			scopes:  []string{"user/Observation.read"},
+			patient: "",
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{
+						ID:              testFileID,
+						OwnerID:         testOwnerID,
+						S3Path:          testS3Path,
+						SecurityContext: "",
+						Status:          domain.FileStatusUploaded,
+					}, nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
+				assert.Nil(t, result)
+				assert.Equal(t, domain.ErrAccessDenied, err)
+			},
+		},
+		{
+			name:          "empty file ID",
+			fileID:        "",
+			userID:        testUserID,
+			scopes:        []string{},
+			setupMocks:    func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			expectedError: domain.ErrFileIDRequired,
+			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				assert.ErrorIs(t, err, domain.ErrFileIDRequired)
+			},
+		},
+		{
+			name:   "file not found",
+			fileID: testFileID,
+			userID: testUserID,
+			scopes: []string{},
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(nil, domain.ErrFileNotFound)
+			},
+			expectedError: domain.ErrFileNotFound,
+			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				assert.Equal(t, domain.ErrFileNotFound, err)
+			},
+		},
+		{
+			name:   "repository error",
+			fileID: testFileID,
+			userID: testUserID,
+			scopes: []string{},
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				assert.ErrorIs(t, err, domain.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to get file")
+			},
+		},
+		{
+			name:   "access denied - not owner and no scopes",
+			fileID: testFileID,
+			userID: testUserID,
+			scopes: []string{},
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				file := &domain.File{
+					ID:          testFileID,
+					OwnerID:     testOwnerID,
+					S3Path:      testS3Path,
+					ContentType: testContentType,
+					Size:        testFileSize,
+					Status:      domain.FileStatusUploaded,
+					IsDeleted:   false,
+				}
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(file, nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				assert.Equal(t, domain.ErrAccessDenied, err)
+			},
+		},
+		{
+			name:   "access denied - wrong scope",
+			fileID: testFileID,
+			userID: testUserID,
+			scopes: []string{"files:file:other-file-id:read"},
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				file := &domain.File{
+					ID:          testFileID,
+					OwnerID:     testOwnerID,
+					S3Path:      testS3Path,
+					ContentType: testContentType,
+					Size:        testFileSize,
+					Status:      domain.FileStatusUploaded,
+					IsDeleted:   false,
+				}
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(file, nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				assert.Equal(t, domain.ErrAccessDenied, err)
+			},
+		},
+		{
+			name:   "file provider error",
+			fileID: testFileID,
+			userID: testOwnerID,
+			scopes: []string{},
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				file := &domain.File{
+					ID:          testFileID,
+					OwnerID:     testOwnerID,
+					S3Path:      testS3Path,
+					ContentType: testContentType,
+					Size:        testFileSize,
+					Status:      domain.FileStatusUploaded,
+					IsDeleted:   false,
+				}
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(file, nil)
+				provider.EXPECT().
+					GenerateDownloadURL(
+						gomock.Any(),
+						gomock.Any(),
+						gomock.Any(),
+					).
+					Return("", errors.New("s3 error"))
+			},
+			expectedError: domain.ErrInternal,
+			validateResult: func(t *testing.T, result *domain.GetDownloadURLResult, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+				assert.ErrorIs(t, err, domain.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to generate download URL")
 			},
 		},
 	}
@@ -469,15 +928,24 @@ func TestFileService_GetDownloadURL(t *testing.T) {
 
 			service := NewFileService(
 				repo,
+				ports.NewMockMultipartUploadRepository(ctrl),
+				noGrants(ctrl),
+				noQuotas(ctrl),
+				noJobs(ctrl),
 				provider,
-				testMaxSize,
-				5*time.Minute,
-				15*time.Minute,
+				nil,
+				FileServiceConfig{
+					UploadMaxSize: testMaxSize,
+					UploadTTL:     5 * time.Minute,
+					DownloadTTL:   15 * time.Minute,
+				},
 			)
 
 			ctx := identity.WithCtx(context.Background(), domain.Identity{
-				UserID: tt.userID,
-				Scopes: tt.scopes,
+				UserID:      tt.userID,
+				Scopes:      tt.scopes,
+				FHIRContext: tt.fhirContext,
+				Patient:     tt.patient,
 			})
 			result, err := service.GetDownloadURL(ctx, tt.fileID)
 
@@ -568,10 +1036,17 @@ func TestFileService_DeleteFile(t *testing.T) {
 
 			service := NewFileService(
 				repo,
+				ports.NewMockMultipartUploadRepository(ctrl),
+				noGrants(ctrl),
+				noQuotas(ctrl),
+				noJobs(ctrl),
 				provider,
-				testMaxSize,
-				5*time.Minute,
-				15*time.Minute,
+				nil,
+				FileServiceConfig{
+					UploadMaxSize: testMaxSize,
+					UploadTTL:     5 * time.Minute,
+					DownloadTTL:   15 * time.Minute,
+				},
 			)
 
 			err := service.DeleteFile(context.Background(), tt.fileID)
@@ -590,9 +1065,29 @@ func TestFileService_DeleteFile(t *testing.T) {
 }
 
 func TestFileService_ConfirmUpload(t *testing.T) {
+	pendingFile := func() *domain.File {
+		return &domain.File{
+			ID:          testFileID,
+			OwnerID:     testOwnerID,
+			S3Path:      testS3Path,
+			ContentType: testContentType,
+			Size:        testFileSize,
+			Status:      domain.FileStatusPending,
+			IsDeleted:   false,
+		}
+	}
+	storedObject := func() *domain.ObjectInfo {
+		return &domain.ObjectInfo{
+			Size:        testFileSize,
+			ContentType: testContentType,
+			ETag:        `"test-etag"`,
+		}
+	}
+
 	tests := []struct {
 		name           string
 		fileID         string
+		eTag           string
 		setupMocks     func(*ports.MockFileRepository, *ports.MockFileProvider)
 		expectedError  error
 		validateResult func(*testing.T, error)
@@ -600,24 +1095,24 @@ func TestFileService_ConfirmUpload(t *testing.T) {
 		{
 			name:   "success path - pending to uploaded",
 			fileID: testFileID,
+			eTag:   "test-etag",
 			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
-				file := &domain.File{
-					ID:          testFileID,
-					OwnerID:     testOwnerID,
-					S3Path:      testS3Path,
-					ContentType: testContentType,
-					Size:        testFileSize,
-					Status:      domain.FileStatusPending,
-					IsDeleted:   false,
-				}
 				repo.EXPECT().
 					GetByID(gomock.Any(), testFileID).
-					Return(file, nil)
+					Return(pendingFile(), nil)
+				object := storedObject()
+				object.ContentType = testContentType + "; charset=binary"
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(object, nil)
+				storedContent(provider)
 				repo.EXPECT().
 					Update(gomock.Any(), gomock.Any()).
 					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
 						require.Equal(t, domain.FileStatusUploaded, file.Status)
 						require.Equal(t, testFileID, file.ID)
+						require.Equal(t, "test-etag", file.ETag)
+						require.Equal(t, testContentType+"; charset=binary", file.ContentType)
 						return file, nil
 					})
 			},
@@ -627,21 +1122,29 @@ func TestFileService_ConfirmUpload(t *testing.T) {
 			},
 		},
 		{
-			name:   "idempotency - already uploaded",
+			name:   "checksum match - pending to uploaded",
 			fileID: testFileID,
 			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
-				file := &domain.File{
-					ID:          testFileID,
-					OwnerID:     testOwnerID,
-					S3Path:      testS3Path,
-					ContentType: testContentType,
-					Size:        testFileSize,
-					Status:      domain.FileStatusUploaded,
-					IsDeleted:   false,
-				}
+				file := pendingFile()
+				file.Size = int64(len(testContent))
+				file.Checksum = testChecksum()
 				repo.EXPECT().
 					GetByID(gomock.Any(), testFileID).
 					Return(file, nil)
+				object := storedObject()
+				object.Size = int64(len(testContent))
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(object, nil)
+				provider.EXPECT().
+					OpenObject(gomock.Any(), testS3Path).
+					Return(nopSeekCloser{strings.NewReader(testContent)}, nil)
+				repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, domain.FileStatusUploaded, file.Status)
+						return file, nil
+					})
 			},
 			expectedError: nil,
 			validateResult: func(t *testing.T, err error) {
@@ -649,22 +1152,29 @@ func TestFileService_ConfirmUpload(t *testing.T) {
 			},
 		},
 		{
-			name:          "empty file ID",
-			fileID:        "",
-			setupMocks:    func(*ports.MockFileRepository, *ports.MockFileProvider) {},
-			expectedError: domain.ErrFileIDRequired,
-			validateResult: func(t *testing.T, err error) {
-				assert.Error(t, err)
-				assert.ErrorIs(t, err, domain.ErrFileIDRequired)
-			},
-		},
-		{
-			name:   "file not found",
+			name:   "checksum mismatch - pending to corrupt",
 			fileID: testFileID,
 			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				file := pendingFile()
+				file.Size = int64(len(testContent))
+				file.Checksum = testChecksum()
 				repo.EXPECT().
 					GetByID(gomock.Any(), testFileID).
-					Return(nil, domain.ErrFileNotFound)
+					Return(file, nil)
+				object := storedObject()
+				object.Size = int64(len(testContent))
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(object, nil)
+				provider.EXPECT().
+					OpenObject(gomock.Any(), testS3Path).
+					Return(nopSeekCloser{strings.NewReader("%PDF-1.7 tampered content!")}, nil)
+				repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, domain.FileStatusCorrupt, file.Status)
+						return file, nil
+					})
 			},
 			expectedError: nil,
 			validateResult: func(t *testing.T, err error) {
@@ -672,35 +1182,251 @@ func TestFileService_ConfirmUpload(t *testing.T) {
 			},
 		},
 		{
-			name:   "repository get error",
+			name:   "checksum verification - open object error",
 			fileID: testFileID,
 			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				file := pendingFile()
+				file.Checksum = testChecksum()
 				repo.EXPECT().
 					GetByID(gomock.Any(), testFileID).
-					Return(nil, errors.New("database error"))
+					Return(file, nil)
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(storedObject(), nil)
+				provider.EXPECT().
+					OpenObject(gomock.Any(), testS3Path).
+					Return(nil, errors.New("s3 error"))
 			},
-			expectedError: errors.New("database error"),
+			expectedError: errors.New("s3 error"),
 			validateResult: func(t *testing.T, err error) {
 				assert.Error(t, err)
-				assert.Contains(t, err.Error(), "failed to get file")
+				assert.Contains(t, err.Error(), "failed to open file content")
 			},
 		},
 		{
-			name:   "repository update error",
+			name:   "sniffed type mismatch - rejected and object deleted",
 			fileID: testFileID,
 			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
-				file := &domain.File{
-					ID:          testFileID,
-					OwnerID:     testOwnerID,
-					S3Path:      testS3Path,
-					ContentType: testContentType,
-					Size:        testFileSize,
-					Status:      domain.FileStatusPending,
-					IsDeleted:   false,
-				}
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(pendingFile(), nil)
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(storedObject(), nil)
+				provider.EXPECT().
+					OpenObject(gomock.Any(), testS3Path).
+					Return(nopSeekCloser{strings.NewReader("MZ\x90\x00\x03\x00\x00\x00")}, nil)
+				repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, domain.FileStatusRejected, file.Status)
+						return file, nil
+					})
+				provider.EXPECT().
+					DeleteObject(gomock.Any(), testS3Path).
+					Return(nil)
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:   "size mismatch - rejected and object deleted",
+			fileID: testFileID,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(pendingFile(), nil)
+				object := storedObject()
+				object.Size = testFileSize + 1
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(object, nil)
+				repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, domain.FileStatusRejected, file.Status)
+						require.Equal(t, testFileSize+1, file.Size)
+						return file, nil
+					})
+				provider.EXPECT().
+					DeleteObject(gomock.Any(), testS3Path).
+					Return(nil)
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:   "size over limit - rejected and object deleted",
+			fileID: testFileID,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(pendingFile(), nil)
+				object := storedObject()
+				object.Size = testMaxSize + 1
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(object, nil)
+				repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, domain.FileStatusRejected, file.Status)
+						return file, nil
+					})
+				provider.EXPECT().
+					DeleteObject(gomock.Any(), testS3Path).
+					Return(nil)
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:   "content type mismatch - rejected even if delete fails",
+			fileID: testFileID,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(pendingFile(), nil)
+				object := storedObject()
+				object.ContentType = "text/html"
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(object, nil)
+				repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, domain.FileStatusRejected, file.Status)
+						require.Equal(t, "text/html", file.ContentType)
+						return file, nil
+					})
+				provider.EXPECT().
+					DeleteObject(gomock.Any(), testS3Path).
+					Return(errors.New("s3 error"))
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:   "object not found - left pending",
+			fileID: testFileID,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(pendingFile(), nil)
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(nil, domain.ErrObjectNotFound)
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:   "stale event etag - left pending",
+			fileID: testFileID,
+			eTag:   "previous-etag",
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(pendingFile(), nil)
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(storedObject(), nil)
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:   "stat object error",
+			fileID: testFileID,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(pendingFile(), nil)
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(nil, errors.New("s3 error"))
+			},
+			expectedError: errors.New("s3 error"),
+			validateResult: func(t *testing.T, err error) {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "failed to stat file content")
+			},
+		},
+		{
+			name:   "idempotency - already uploaded",
+			fileID: testFileID,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				file := pendingFile()
+				file.Status = domain.FileStatusUploaded
 				repo.EXPECT().
 					GetByID(gomock.Any(), testFileID).
 					Return(file, nil)
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:          "empty file ID",
+			fileID:        "",
+			setupMocks:    func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			expectedError: domain.ErrFileIDRequired,
+			validateResult: func(t *testing.T, err error) {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, domain.ErrFileIDRequired)
+			},
+		},
+		{
+			name:   "file not found",
+			fileID: testFileID,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(nil, domain.ErrFileNotFound)
+			},
+			expectedError: nil,
+			validateResult: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name:   "repository get error",
+			fileID: testFileID,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(nil, errors.New("database error"))
+			},
+			expectedError: errors.New("database error"),
+			validateResult: func(t *testing.T, err error) {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), "failed to get file")
+			},
+		},
+		{
+			name:   "repository update error",
+			fileID: testFileID,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(pendingFile(), nil)
+				provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(storedObject(), nil)
+				storedContent(provider)
 				repo.EXPECT().
 					Update(gomock.Any(), gomock.Any()).
 					Return(nil, errors.New("update error"))
@@ -725,13 +1451,20 @@ func TestFileService_ConfirmUpload(t *testing.T) {
 
 			service := NewFileService(
 				repo,
+				ports.NewMockMultipartUploadRepository(ctrl),
+				noGrants(ctrl),
+				noQuotas(ctrl),
+				noJobs(ctrl),
 				provider,
-				testMaxSize,
-				5*time.Minute,
-				15*time.Minute,
+				nil,
+				FileServiceConfig{
+					UploadMaxSize: testMaxSize,
+					UploadTTL:     5 * time.Minute,
+					DownloadTTL:   15 * time.Minute,
+				},
 			)
 
-			err := service.ConfirmUpload(context.Background(), tt.fileID)
+			err := service.ConfirmUpload(context.Background(), tt.fileID, tt.eTag)
 
 			if tt.expectedError != nil {
 				assert.Error(t, err)
@@ -745,3 +1478,517 @@ func TestFileService_ConfirmUpload(t *testing.T) {
 		})
 	}
 }
+
+func TestFileService_CreateFile(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		contentType    string
+		content        []byte
+		setupMocks     func(*ports.MockFileRepository, *ports.MockFileProvider)
+		validateResult func(*testing.T, *domain.File, error)
+	}{
+		{
+			name:        "success path",
+			userID:      testOwnerID,
+			contentType: testContentType,
+			content:     []byte("%PDF-1.7"),
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				provider.EXPECT().
+					PutObject(gomock.Any(), gomock.Any(), testContentType, gomock.Any(), int64(8)).
+					Return(nil)
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, testOwnerID, file.OwnerID)
+						require.Equal(t, "Patient/123", file.SecurityContext)
+						require.Equal(t, domain.FileStatusUploaded, file.Status)
+						return file, nil
+					})
+			},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, file)
+				assert.Equal(t, int64(8), file.Size)
+			},
+		},
+		{
+			name:        "unauthenticated",
+			userID:      "",
+			contentType: testContentType,
+			content:     []byte("%PDF-1.7"),
+			setupMocks:  func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				assert.Nil(t, file)
+				assert.Equal(t, domain.ErrUnauthenticated, err)
+			},
+		},
+		{
+			name:        "empty content",
+			userID:      testOwnerID,
+			contentType: testContentType,
+			content:     nil,
+			setupMocks:  func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				assert.Nil(t, file)
+				assert.ErrorIs(t, err, domain.ErrInvalidInput)
+			},
+		},
+		{
+			name:        "file provider error",
+			userID:      testOwnerID,
+			contentType: testContentType,
+			content:     []byte("%PDF-1.7"),
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				provider.EXPECT().
+					PutObject(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(errors.New("s3 error"))
+			},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				assert.Nil(t, file)
+				assert.ErrorIs(t, err, domain.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to store file content")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := ports.NewMockFileRepository(ctrl)
+			provider := ports.NewMockFileProvider(ctrl)
+
+			tt.setupMocks(repo, provider)
+
+			service := NewFileService(
+				repo,
+				ports.NewMockMultipartUploadRepository(ctrl),
+				noGrants(ctrl),
+				noQuotas(ctrl),
+				noJobs(ctrl),
+				provider,
+				nil,
+				FileServiceConfig{
+					UploadMaxSize: testMaxSize,
+					UploadTTL:     5 * time.Minute,
+					DownloadTTL:   15 * time.Minute,
+				},
+			)
+
+			ctx := context.Background()
+			if tt.userID != "" {
+				ctx = identity.WithCtx(ctx, domain.Identity{UserID: tt.userID})
+			}
+			file, err := service.CreateFile(ctx, tt.contentType, "Patient/123", bytes.NewReader(tt.content), int64(len(tt.content)))
+
+			tt.validateResult(t, file, err)
+		})
+	}
+}
+
+func TestFileService_UpdateFile(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		scopes         []string
+		setupMocks     func(*ports.MockFileRepository, *ports.MockFileProvider)
+		validateResult func(*testing.T, *domain.File, error)
+	}{
+		{
+			name:   "success path - owner",
+			userID: testOwnerID,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{
+						ID:          testFileID,
+						OwnerID:     testOwnerID,
+						S3Path:      testS3Path,
+						ContentType: testContentType,
+						Size:        testFileSize,
+						Status:      domain.FileStatusUploaded,
+					}, nil)
+				provider.EXPECT().
+					PutObject(gomock.Any(), testS3Path, "text/plain", gomock.Any(), int64(5)).
+					Return(nil)
+				repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, "text/plain", file.ContentType)
+						require.Equal(t, int64(5), file.Size)
+						return file, nil
+					})
+			},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "text/plain", file.ContentType)
+			},
+		},
+		{
+			name:   "success path - write scope",
+			userID: testUserID,
+			scopes: []string{"files:file:" + testFileID + ":write"},
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{ID: testFileID, OwnerID: testOwnerID, S3Path: testS3Path}, nil)
+				provider.EXPECT().
+					PutObject(gomock.Any(), testS3Path, gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil)
+				repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						return file, nil
+					})
+			},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				require.NoError(t, err)
+				assert.NotNil(t, file)
+			},
+		},
+		{
+			name:   "access denied - read scope only",
+			userID: testUserID,
+			scopes: []string{"files:file:" + testFileID + ":read"},
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{ID: testFileID, OwnerID: testOwnerID, S3Path: testS3Path}, nil)
+			},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				assert.Nil(t, file)
+				assert.Equal(t, domain.ErrAccessDenied, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := ports.NewMockFileRepository(ctrl)
+			provider := ports.NewMockFileProvider(ctrl)
+
+			tt.setupMocks(repo, provider)
+
+			service := NewFileService(
+				repo,
+				ports.NewMockMultipartUploadRepository(ctrl),
+				noGrants(ctrl),
+				noQuotas(ctrl),
+				noJobs(ctrl),
+				provider,
+				nil,
+				FileServiceConfig{
+					UploadMaxSize: testMaxSize,
+					UploadTTL:     5 * time.Minute,
+					DownloadTTL:   15 * time.Minute,
+				},
+			)
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{
+				UserID: tt.userID,
+				Scopes: tt.scopes,
+			})
+			file, err := service.UpdateFile(ctx, testFileID, "text/plain", "", strings.NewReader("hello"), 5)
+
+			tt.validateResult(t, file, err)
+		})
+	}
+}
+
+func TestFileService_RemoveFile(t *testing.T) {
+	tests := []struct {
+		name          string
+		userID        string
+		scopes        []string
+		setupMocks    func(*ports.MockFileRepository)
+		expectedError error
+	}{
+		{
+			name:   "success path - owner",
+			userID: testOwnerID,
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{ID: testFileID, OwnerID: testOwnerID}, nil)
+				repo.EXPECT().
+					SoftDelete(gomock.Any(), testFileID).
+					Return(nil)
+			},
+		},
+		{
+			name:   "success path - delete scope",
+			userID: testUserID,
+			scopes: []string{"files:file:" + testFileID + ":delete"},
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{ID: testFileID, OwnerID: testOwnerID}, nil)
+				repo.EXPECT().
+					SoftDelete(gomock.Any(), testFileID).
+					Return(nil)
+			},
+		},
+		{
+			name:   "success path - SMART v2 delete scope",
+			userID: testUserID,
+			scopes: []string{"system/Binary.cruds"},
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{ID: testFileID, OwnerID: testOwnerID}, nil)
+				repo.EXPECT().
+					SoftDelete(gomock.Any(), testFileID).
+					Return(nil)
+			},
+		},
+		{
+			name:   "access denied - SMART user scope on another user's file",
+			userID: testUserID,
+			scopes: []string{"user/Binary.cruds"},
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{ID: testFileID, OwnerID: testOwnerID}, nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:   "access denied - SMART read scope",
+			userID: testUserID,
+			scopes: []string{"user/Binary.rs"},
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{ID: testFileID, OwnerID: testOwnerID}, nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:   "access denied",
+			userID: testUserID,
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(&domain.File{ID: testFileID, OwnerID: testOwnerID}, nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:   "file not found",
+			userID: testOwnerID,
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetByID(gomock.Any(), testFileID).
+					Return(nil, domain.ErrFileNotFound)
+			},
+			expectedError: domain.ErrFileNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := ports.NewMockFileRepository(ctrl)
+			provider := ports.NewMockFileProvider(ctrl)
+
+			tt.setupMocks(repo)
+
+			service := NewFileService(
+				repo,
+				ports.NewMockMultipartUploadRepository(ctrl),
+				noGrants(ctrl),
+				noQuotas(ctrl),
+				noJobs(ctrl),
+				provider,
+				nil,
+				FileServiceConfig{
+					UploadMaxSize: testMaxSize,
+					UploadTTL:     5 * time.Minute,
+					DownloadTTL:   15 * time.Minute,
+				},
+			)
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{
+				UserID: tt.userID,
+				Scopes: tt.scopes,
+			})
+			err := service.RemoveFile(ctx, testFileID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestFileService_RestoreFile(t *testing.T) {
+	const restoreWindow = 30 * 24 * time.Hour
+
+	deleted := func(deletedAgo time.Duration) *domain.File {
+		return &domain.File{
+			ID:        testFileID,
+			OwnerID:   testOwnerID,
+			Status:    domain.FileStatusUploaded,
+			IsDeleted: true,
+			DeletedAt: time.Now().Add(-deletedAgo),
+		}
+	}
+
+	tests := []struct {
+		name          string
+		userID        string
+		scopes        []string
+		setupMocks    func(*ports.MockFileRepository)
+		expectedError error
+	}{
+		{
+			name:   "success path - owner",
+			userID: testOwnerID,
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetDeletedByID(gomock.Any(), testFileID).
+					Return(deleted(time.Hour), nil)
+				repo.EXPECT().
+					Restore(gomock.Any(), testFileID, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, deletedAfter time.Time, event *domain.FileEvent) (*domain.File, error) {
+						assert.WithinDuration(t, time.Now().Add(-restoreWindow), deletedAfter, time.Second)
+						assert.Equal(t, testFileID, event.FileID)
+						assert.Equal(t, domain.FileActionRestored, event.Action)
+						assert.Equal(t, testOwnerID, event.ActorID)
+						return &domain.File{ID: testFileID, OwnerID: testOwnerID, Status: domain.FileStatusUploaded}, nil
+					})
+			},
+		},
+		{
+			name:   "success path - delete scope",
+			userID: testUserID,
+			scopes: []string{"files:file:" + testFileID + ":delete"},
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetDeletedByID(gomock.Any(), testFileID).
+					Return(deleted(time.Hour), nil)
+				repo.EXPECT().
+					Restore(gomock.Any(), testFileID, gomock.Any(), gomock.Any()).
+					Return(&domain.File{ID: testFileID, OwnerID: testOwnerID}, nil)
+			},
+		},
+		{
+			name:   "access denied - SMART delete scope",
+			userID: testUserID,
+			scopes: []string{"system/Binary.cruds"},
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetDeletedByID(gomock.Any(), testFileID).
+					Return(deleted(time.Hour), nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:   "access denied - read scope only",
+			userID: testUserID,
+			scopes: []string{"files:file:" + testFileID + ":read"},
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetDeletedByID(gomock.Any(), testFileID).
+					Return(deleted(time.Hour), nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:   "outside retention window",
+			userID: testOwnerID,
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetDeletedByID(gomock.Any(), testFileID).
+					Return(deleted(restoreWindow+time.Hour), nil)
+			},
+			expectedError: domain.ErrRestoreExpired,
+		},
+		{
+			name:   "claimed by purger meanwhile",
+			userID: testOwnerID,
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetDeletedByID(gomock.Any(), testFileID).
+					Return(deleted(time.Hour), nil)
+				repo.EXPECT().
+					Restore(gomock.Any(), testFileID, gomock.Any(), gomock.Any()).
+					Return(nil, domain.ErrFileNotFound)
+			},
+			expectedError: domain.ErrRestoreExpired,
+		},
+		{
+			name:   "not deleted or already purged",
+			userID: testOwnerID,
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetDeletedByID(gomock.Any(), testFileID).
+					Return(nil, domain.ErrFileNotFound)
+			},
+			expectedError: domain.ErrFileNotFound,
+		},
+		{
+			name:   "repository error",
+			userID: testOwnerID,
+			setupMocks: func(repo *ports.MockFileRepository) {
+				repo.EXPECT().
+					GetDeletedByID(gomock.Any(), testFileID).
+					Return(deleted(time.Hour), nil)
+				repo.EXPECT().
+					Restore(gomock.Any(), testFileID, gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := ports.NewMockFileRepository(ctrl)
+
+			tt.setupMocks(repo)
+
+			service := NewFileService(
+				repo,
+				ports.NewMockMultipartUploadRepository(ctrl),
+				noGrants(ctrl),
+				noQuotas(ctrl),
+				noJobs(ctrl),
+				ports.NewMockFileProvider(ctrl),
+				nil,
+				FileServiceConfig{
+					UploadMaxSize: testMaxSize,
+					UploadTTL:     5 * time.Minute,
+					DownloadTTL:   15 * time.Minute,
+					RestoreWindow: restoreWindow,
+				},
+			)
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{
+				UserID: tt.userID,
+				Scopes: tt.scopes,
+			})
+			file, err := service.RestoreFile(ctx, testFileID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, file)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, testFileID, file.ID)
+			}
+		})
+	}
+}
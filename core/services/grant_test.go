@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+	"github.com/gruzdev-dev/codex-files/pkg/identity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testGroupID = "test-group"
+
+type grantMocks struct {
+	repo     *ports.MockFileRepository
+	grants   *ports.MockFileGrantRepository
+	provider *ports.MockFileProvider
+}
+
+func newGrantTestService(t *testing.T, setupMocks func(grantMocks)) *FileService {
+	ctrl := gomock.NewController(t)
+	mocks := grantMocks{
+		repo:     ports.NewMockFileRepository(ctrl),
+		grants:   ports.NewMockFileGrantRepository(ctrl),
+		provider: ports.NewMockFileProvider(ctrl),
+	}
+	setupMocks(mocks)
+
+	return NewFileService(mocks.repo, ports.NewMockMultipartUploadRepository(ctrl), mocks.grants, noQuotas(ctrl), noJobs(ctrl), mocks.provider, nil, FileServiceConfig{
+		UploadMaxSize: testMaxSize,
+		UploadTTL:     5 * time.Minute,
+		DownloadTTL:   15 * time.Minute,
+	})
+}
+
+func grantedFile() *domain.File {
+	return &domain.File{
+		ID:          testFileID,
+		OwnerID:     testOwnerID,
+		S3Path:      testS3Path,
+		Size:        testFileSize,
+		ContentType: testContentType,
+		Status:      domain.FileStatusUploaded,
+	}
+}
+
+func TestFileService_GrantAccess(t *testing.T) {
+	tests := []struct {
+		name          string
+		userID        string
+		grant         domain.FileGrant
+		setupMocks    func(grantMocks)
+		expectedError error
+	}{
+		{
+			name:   "success path - group read grant with expiry",
+			userID: testOwnerID,
+			grant: domain.FileGrant{
+				FileID:     testFileID,
+				Grantee:    domain.Grantee{Type: domain.GranteeGroup, ID: testGroupID},
+				Permission: domain.PermissionRead,
+				ExpiresAt:  time.Now().Add(time.Hour),
+			},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().
+					Upsert(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, grant *domain.FileGrant) (*domain.FileGrant, error) {
+						assert.Equal(t, testOwnerID, grant.GrantedBy)
+						assert.WithinDuration(t, time.Now(), grant.CreatedAt, time.Second)
+						return grant, nil
+					})
+			},
+		},
+		{
+			name:   "not the owner",
+			userID: testUserID,
+			grant: domain.FileGrant{
+				FileID:     testFileID,
+				Grantee:    domain.Grantee{Type: domain.GranteeUser, ID: testUserID},
+				Permission: domain.PermissionWrite,
+			},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:   "unknown permission",
+			userID: testOwnerID,
+			grant: domain.FileGrant{
+				FileID:     testFileID,
+				Grantee:    domain.Grantee{Type: domain.GranteeUser, ID: testUserID},
+				Permission: "admin",
+			},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:   "unknown grantee type",
+			userID: testOwnerID,
+			grant: domain.FileGrant{
+				FileID:     testFileID,
+				Grantee:    domain.Grantee{Type: "role", ID: "admins"},
+				Permission: domain.PermissionRead,
+			},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:   "expiry in the past",
+			userID: testOwnerID,
+			grant: domain.FileGrant{
+				FileID:     testFileID,
+				Grantee:    domain.Grantee{Type: domain.GranteeUser, ID: testUserID},
+				Permission: domain.PermissionRead,
+				ExpiresAt:  time.Now().Add(-time.Minute),
+			},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:   "file not found",
+			userID: testOwnerID,
+			grant: domain.FileGrant{
+				FileID:     testFileID,
+				Grantee:    domain.Grantee{Type: domain.GranteeUser, ID: testUserID},
+				Permission: domain.PermissionRead,
+			},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(nil, domain.ErrFileNotFound)
+			},
+			expectedError: domain.ErrFileNotFound,
+		},
+		{
+			name:   "unauthenticated",
+			userID: "",
+			grant: domain.FileGrant{
+				FileID:     testFileID,
+				Grantee:    domain.Grantee{Type: domain.GranteeUser, ID: testUserID},
+				Permission: domain.PermissionRead,
+			},
+			setupMocks:    func(m grantMocks) {},
+			expectedError: domain.ErrUnauthenticated,
+		},
+		{
+			name:   "repository error",
+			userID: testOwnerID,
+			grant: domain.FileGrant{
+				FileID:     testFileID,
+				Grantee:    domain.Grantee{Type: domain.GranteeUser, ID: testUserID},
+				Permission: domain.PermissionRead,
+			},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().Upsert(gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newGrantTestService(t, tt.setupMocks)
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{UserID: tt.userID})
+			grant, err := service.GrantAccess(ctx, tt.grant)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, grant)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.grant.Grantee, grant.Grantee)
+			assert.Equal(t, tt.grant.Permission, grant.Permission)
+		})
+	}
+}
+
+func TestFileService_RevokeAccess(t *testing.T) {
+	grantee := domain.Grantee{Type: domain.GranteeUser, ID: testUserID}
+
+	tests := []struct {
+		name          string
+		userID        string
+		permission    domain.Permission
+		setupMocks    func(grantMocks)
+		expectedError error
+	}{
+		{
+			name:       "success path - single permission",
+			userID:     testOwnerID,
+			permission: domain.PermissionRead,
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().Delete(gomock.Any(), testFileID, grantee, domain.PermissionRead).Return(nil)
+			},
+		},
+		{
+			name:   "success path - every permission",
+			userID: testOwnerID,
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().Delete(gomock.Any(), testFileID, grantee, domain.Permission("")).Return(nil)
+			},
+		},
+		{
+			name:       "grant not found",
+			userID:     testOwnerID,
+			permission: domain.PermissionWrite,
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().Delete(gomock.Any(), testFileID, grantee, domain.PermissionWrite).Return(domain.ErrGrantNotFound)
+			},
+			expectedError: domain.ErrGrantNotFound,
+		},
+		{
+			name:       "grantee cannot revoke",
+			userID:     testUserID,
+			permission: domain.PermissionRead,
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:       "repository error",
+			userID:     testOwnerID,
+			permission: domain.PermissionRead,
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().Delete(gomock.Any(), testFileID, grantee, domain.PermissionRead).Return(errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newGrantTestService(t, tt.setupMocks)
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{UserID: tt.userID})
+			err := service.RevokeAccess(ctx, testFileID, grantee, tt.permission)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFileService_ListGrants(t *testing.T) {
+	grants := []*domain.FileGrant{
+		{
+			FileID:     testFileID,
+			Grantee:    domain.Grantee{Type: domain.GranteeGroup, ID: testGroupID},
+			Permission: domain.PermissionRead,
+		},
+	}
+
+	tests := []struct {
+		name           string
+		userID         string
+		setupMocks     func(grantMocks)
+		expectedGrants []*domain.FileGrant
+		expectedError  error
+	}{
+		{
+			name:   "success path",
+			userID: testOwnerID,
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().ListByFileID(gomock.Any(), testFileID).Return(grants, nil)
+			},
+			expectedGrants: grants,
+		},
+		{
+			name:   "not the owner",
+			userID: testUserID,
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:   "repository error",
+			userID: testOwnerID,
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().ListByFileID(gomock.Any(), testFileID).Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newGrantTestService(t, tt.setupMocks)
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{UserID: tt.userID})
+			result, err := service.ListGrants(ctx, testFileID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedGrants, result)
+		})
+	}
+}
+
+func TestFileService_GetDownloadURL_Grants(t *testing.T) {
+	tests := []struct {
+		name          string
+		user          domain.Identity
+		setupMocks    func(grantMocks)
+		expectedError error
+	}{
+		{
+			name: "user grant",
+			user: domain.Identity{UserID: testUserID},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().
+					HasGrant(gomock.Any(), testFileID, []domain.Grantee{{Type: domain.GranteeUser, ID: testUserID}}, domain.PermissionRead).
+					Return(true, nil)
+				m.provider.EXPECT().GenerateDownloadURL(gomock.Any(), testS3Path, gomock.Any()).Return(testDownloadURL, nil)
+			},
+		},
+		{
+			name: "group grant",
+			user: domain.Identity{UserID: testUserID, Groups: []string{testGroupID}},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().
+					HasGrant(gomock.Any(), testFileID, []domain.Grantee{
+						{Type: domain.GranteeUser, ID: testUserID},
+						{Type: domain.GranteeGroup, ID: testGroupID},
+					}, domain.PermissionRead).
+					Return(true, nil)
+				m.provider.EXPECT().GenerateDownloadURL(gomock.Any(), testS3Path, gomock.Any()).Return(testDownloadURL, nil)
+			},
+		},
+		{
+			name: "no grant",
+			user: domain.Identity{UserID: testUserID},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().HasGrant(gomock.Any(), testFileID, gomock.Any(), domain.PermissionRead).Return(false, nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name: "owner - grants not consulted",
+			user: domain.Identity{UserID: testOwnerID},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.provider.EXPECT().GenerateDownloadURL(gomock.Any(), testS3Path, gomock.Any()).Return(testDownloadURL, nil)
+			},
+		},
+		{
+			name: "anonymous - grants not consulted",
+			user: domain.Identity{},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name: "grant lookup error",
+			user: domain.Identity{UserID: testUserID},
+			setupMocks: func(m grantMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.grants.EXPECT().HasGrant(gomock.Any(), testFileID, gomock.Any(), domain.PermissionRead).Return(false, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newGrantTestService(t, tt.setupMocks)
+
+			ctx := identity.WithCtx(context.Background(), tt.user)
+			result, err := service.GetDownloadURL(ctx, testFileID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testDownloadURL, result.DownloadURL)
+		})
+	}
+}
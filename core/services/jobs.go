@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+// JobProcessor carries out a job. Returning an error schedules a retry, so
+// processors must be safe to run again for the same job.
+type JobProcessor func(ctx context.Context, job *domain.Job) error
+
+type JobRunnerConfig struct {
+	BatchSize     int
+	Lease         time.Duration
+	MaxAttempts   int
+	Backoff       domain.JobBackoff
+	DoneRetention time.Duration
+	DeadRetention time.Duration
+}
+
+// JobRunner claims jobs from the queue and hands each to the processor
+// registered for its kind. Only kinds with a processor are claimed.
+type JobRunner struct {
+	repo       ports.JobRepository
+	processors map[domain.JobKind]JobProcessor
+	cfg        JobRunnerConfig
+}
+
+func NewJobRunner(repo ports.JobRepository, cfg JobRunnerConfig) *JobRunner {
+	return &JobRunner{
+		repo:       repo,
+		processors: make(map[domain.JobKind]JobProcessor),
+		cfg:        cfg,
+	}
+}
+
+// Register sets the processor for a kind of job, replacing any earlier one.
+func (r *JobRunner) Register(kind domain.JobKind, processor JobProcessor) {
+	r.processors[kind] = processor
+}
+
+// RunBatch runs up to BatchSize due jobs one after another. Each job is
+// claimed just before it runs, so that its lease only has to cover that job.
+func (r *JobRunner) RunBatch(ctx context.Context) (*domain.RunJobsResult, error) {
+	kinds := make([]domain.JobKind, 0, len(r.processors))
+	for kind := range r.processors {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+
+	ctx = tenant.Unscoped(ctx)
+	result := &domain.RunJobsResult{}
+	for result.Claimed < r.cfg.BatchSize {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		jobs, err := r.repo.Claim(ctx, kinds, r.cfg.Lease, 1, r.cfg.MaxAttempts)
+		if err != nil {
+			err = fmt.Errorf("%w: failed to claim jobs: %v", domain.ErrInternal, err)
+			if result.Claimed == 0 {
+				return nil, err
+			}
+			return result, err
+		}
+		if len(jobs) == 0 {
+			break
+		}
+
+		for _, job := range jobs {
+			result.Claimed++
+			r.runJob(ctx, job, result)
+		}
+	}
+
+	return result, nil
+}
+
+// DeleteFinished deletes up to BatchSize done jobs older than DoneRetention
+// and dead-lettered jobs older than DeadRetention, so that the queue does not
+// grow without bound. A zero retention keeps those jobs.
+func (r *JobRunner) DeleteFinished(ctx context.Context) (int, error) {
+	if r.cfg.DoneRetention <= 0 && r.cfg.DeadRetention <= 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	var doneBefore, deadBefore time.Time
+	if r.cfg.DoneRetention > 0 {
+		doneBefore = now.Add(-r.cfg.DoneRetention)
+	}
+	if r.cfg.DeadRetention > 0 {
+		deadBefore = now.Add(-r.cfg.DeadRetention)
+	}
+
+	deleted, err := r.repo.DeleteFinished(tenant.Unscoped(ctx), doneBefore, deadBefore, r.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to delete finished jobs: %v", domain.ErrInternal, err)
+	}
+	return deleted, nil
+}
+
+// runJob runs a claimed job and records its outcome, unless the lease ran
+// out and another worker may have claimed the job in the meantime.
+func (r *JobRunner) runJob(ctx context.Context, job *domain.Job, result *domain.RunJobsResult) {
+	if err := r.run(ctx, job); err != nil {
+		log.Printf("jobs: %s job %s for file %s failed on attempt %d: %v", job.Kind, job.ID, job.FileID, job.Attempts, err)
+		job.MarkAsFailed(err, r.cfg.Backoff, r.cfg.MaxAttempts)
+	} else {
+		job.MarkAsDone()
+	}
+
+	// A job whose outcome is lost runs again once its lease runs out.
+	if err := r.repo.Update(ctx, job); err != nil {
+		if errors.Is(err, domain.ErrJobLeaseLost) {
+			log.Printf("jobs: lease of job %s ran out before its outcome was recorded", job.ID)
+			result.Lost++
+			return
+		}
+		log.Printf("jobs: failed to record outcome of job %s: %v", job.ID, err)
+	}
+
+	switch job.Status {
+	case domain.JobStatusDone:
+		result.Done++
+	case domain.JobStatusDead:
+		result.Dead++
+	default:
+		result.Retried++
+	}
+}
+
+// run calls the processor for no longer than the lease has left, so that a
+// job is not still running when another worker may claim it again.
+func (r *JobRunner) run(ctx context.Context, job *domain.Job) error {
+	processor, ok := r.processors[job.Kind]
+	if !ok {
+		return fmt.Errorf("no processor registered for job kind %q", job.Kind)
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, job.LockedUntil)
+	defer cancel()
+
+	return processor(ctx, job)
+}
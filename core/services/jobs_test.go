@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testJobLease       = time.Minute
+	testJobBatchSize   = 10
+	testJobMaxAttempts = 3
+)
+
+var testJobBackoff = domain.JobBackoff{Base: time.Second, Max: time.Minute}
+
+func newTestJobRunner(t *testing.T, setupMocks func(*ports.MockJobRepository)) *JobRunner {
+	ctrl := gomock.NewController(t)
+	repo := ports.NewMockJobRepository(ctrl)
+	setupMocks(repo)
+
+	return NewJobRunner(repo, JobRunnerConfig{
+		BatchSize:   testJobBatchSize,
+		Lease:       testJobLease,
+		MaxAttempts: testJobMaxAttempts,
+		Backoff:     testJobBackoff,
+	})
+}
+
+func claimedJob(kind domain.JobKind, attempts int) *domain.Job {
+	job := domain.NewJob(kind, testFileID, map[string]string{"etag": "etag"})
+	job.Status = domain.JobStatusRunning
+	job.Attempts = attempts
+	job.LockedUntil = time.Now().Add(testJobLease)
+	return job
+}
+
+func TestJobRunner_RunBatch(t *testing.T) {
+	processorErr := errors.New("clamd unavailable")
+
+	tests := []struct {
+		name           string
+		jobs           []*domain.Job
+		processorErr   error
+		expectedStatus domain.JobStatus
+		expectedResult *domain.RunJobsResult
+	}{
+		{
+			name:           "success path - job done",
+			jobs:           []*domain.Job{claimedJob(domain.JobKindScanFile, 1)},
+			expectedStatus: domain.JobStatusDone,
+			expectedResult: &domain.RunJobsResult{Claimed: 1, Done: 1},
+		},
+		{
+			name:           "failure with attempts left - retried",
+			jobs:           []*domain.Job{claimedJob(domain.JobKindScanFile, 1)},
+			processorErr:   processorErr,
+			expectedStatus: domain.JobStatusQueued,
+			expectedResult: &domain.RunJobsResult{Claimed: 1, Retried: 1},
+		},
+		{
+			name:           "failure on last attempt - dead",
+			jobs:           []*domain.Job{claimedJob(domain.JobKindScanFile, testJobMaxAttempts)},
+			processorErr:   processorErr,
+			expectedStatus: domain.JobStatusDead,
+			expectedResult: &domain.RunJobsResult{Claimed: 1, Dead: 1},
+		},
+		{
+			name:           "unregistered kind - retried",
+			jobs:           []*domain.Job{claimedJob(domain.JobKindConfirmUpload, 1)},
+			expectedStatus: domain.JobStatusQueued,
+			expectedResult: &domain.RunJobsResult{Claimed: 1, Retried: 1},
+		},
+		{
+			name:           "nothing due",
+			expectedResult: &domain.RunJobsResult{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newTestJobRunner(t, func(repo *ports.MockJobRepository) {
+				for _, job := range tt.jobs {
+					repo.EXPECT().
+						Claim(gomock.Any(), []domain.JobKind{domain.JobKindScanFile}, testJobLease, 1, testJobMaxAttempts).
+						Return([]*domain.Job{job}, nil)
+				}
+				repo.EXPECT().
+					Claim(gomock.Any(), []domain.JobKind{domain.JobKindScanFile}, testJobLease, 1, testJobMaxAttempts).
+					Return(nil, nil)
+				for range tt.jobs {
+					repo.EXPECT().
+						Update(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, job *domain.Job) error {
+							assert.Equal(t, tt.expectedStatus, job.Status)
+							assert.True(t, job.LockedUntil.IsZero())
+							if job.Status == domain.JobStatusQueued {
+								assert.NotEmpty(t, job.LastError)
+								assert.True(t, job.RunAt.After(time.Now()))
+							}
+							return nil
+						})
+				}
+			})
+
+			runner.Register(domain.JobKindScanFile, func(ctx context.Context, job *domain.Job) error {
+				deadline, hasDeadline := ctx.Deadline()
+				assert.True(t, hasDeadline)
+				assert.Equal(t, job.LockedUntil, deadline)
+				return tt.processorErr
+			})
+
+			result, err := runner.RunBatch(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
+
+func TestJobRunner_RunBatch_BatchSize(t *testing.T) {
+	runs := 0
+	runner := newTestJobRunner(t, func(repo *ports.MockJobRepository) {
+		repo.EXPECT().
+			Claim(gomock.Any(), gomock.Any(), testJobLease, 1, testJobMaxAttempts).
+			DoAndReturn(func(context.Context, []domain.JobKind, time.Duration, int, int) ([]*domain.Job, error) {
+				return []*domain.Job{claimedJob(domain.JobKindScanFile, 1)}, nil
+			}).
+			Times(testJobBatchSize)
+		repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil).Times(testJobBatchSize)
+	})
+	runner.Register(domain.JobKindScanFile, func(context.Context, *domain.Job) error {
+		runs++
+		return nil
+	})
+
+	result, err := runner.RunBatch(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, testJobBatchSize, runs)
+	assert.Equal(t, &domain.RunJobsResult{Claimed: testJobBatchSize, Done: testJobBatchSize}, result)
+}
+
+func TestJobRunner_RunBatch_LeaseLost(t *testing.T) {
+	runner := newTestJobRunner(t, func(repo *ports.MockJobRepository) {
+		gomock.InOrder(
+			repo.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+				Return([]*domain.Job{claimedJob(domain.JobKindScanFile, 1)}, nil),
+			repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(domain.ErrJobLeaseLost),
+			repo.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil),
+		)
+	})
+	runner.Register(domain.JobKindScanFile, func(context.Context, *domain.Job) error { return nil })
+
+	result, err := runner.RunBatch(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, &domain.RunJobsResult{Claimed: 1, Lost: 1}, result)
+}
+
+func TestJobRunner_RunBatch_ClaimError(t *testing.T) {
+	runner := newTestJobRunner(t, func(repo *ports.MockJobRepository) {
+		repo.EXPECT().Claim(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
+	})
+
+	result, err := runner.RunBatch(context.Background())
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, domain.ErrInternal)
+}
+
+func TestJobRunner_DeleteFinished(t *testing.T) {
+	const doneRetention, deadRetention = time.Hour, 24 * time.Hour
+
+	tests := []struct {
+		name            string
+		doneRetention   time.Duration
+		deadRetention   time.Duration
+		setupMocks      func(*ports.MockJobRepository)
+		expectedDeleted int
+		expectedError   error
+	}{
+		{
+			name:          "success path - done and dead jobs past their retention",
+			doneRetention: doneRetention,
+			deadRetention: deadRetention,
+			setupMocks: func(repo *ports.MockJobRepository) {
+				repo.EXPECT().
+					DeleteFinished(gomock.Any(), gomock.Any(), gomock.Any(), testJobBatchSize).
+					DoAndReturn(func(_ context.Context, doneBefore, deadBefore time.Time, _ int) (int, error) {
+						assert.WithinDuration(t, time.Now().Add(-doneRetention), doneBefore, time.Second)
+						assert.WithinDuration(t, time.Now().Add(-deadRetention), deadBefore, time.Second)
+						return 3, nil
+					})
+			},
+			expectedDeleted: 3,
+		},
+		{
+			name:          "dead jobs kept",
+			doneRetention: doneRetention,
+			setupMocks: func(repo *ports.MockJobRepository) {
+				repo.EXPECT().
+					DeleteFinished(gomock.Any(), gomock.Any(), time.Time{}, testJobBatchSize).
+					Return(1, nil)
+			},
+			expectedDeleted: 1,
+		},
+		{
+			name:       "retention disabled",
+			setupMocks: func(*ports.MockJobRepository) {},
+		},
+		{
+			name:          "repository error",
+			doneRetention: doneRetention,
+			deadRetention: deadRetention,
+			setupMocks: func(repo *ports.MockJobRepository) {
+				repo.EXPECT().
+					DeleteFinished(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(0, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := ports.NewMockJobRepository(ctrl)
+			tt.setupMocks(repo)
+
+			runner := NewJobRunner(repo, JobRunnerConfig{
+				BatchSize:     testJobBatchSize,
+				Lease:         testJobLease,
+				MaxAttempts:   testJobMaxAttempts,
+				Backoff:       testJobBackoff,
+				DoneRetention: tt.doneRetention,
+				DeadRetention: tt.deadRetention,
+			})
+
+			deleted, err := runner.DeleteFinished(context.Background())
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedDeleted, deleted)
+		})
+	}
+}
+
+func TestFileService_QueueConfirmUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	jobs := ports.NewMockJobRepository(ctrl)
+	jobs.EXPECT().
+		Enqueue(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, job *domain.Job) (*domain.Job, error) {
+			assert.Equal(t, domain.JobKindConfirmUpload, job.Kind)
+			assert.Equal(t, testFileID, job.FileID)
+			assert.Equal(t, "test-etag", job.Payload["etag"])
+			assert.Equal(t, domain.JobStatusQueued, job.Status)
+			return job, nil
+		})
+
+	service := NewFileService(ports.NewMockFileRepository(ctrl), ports.NewMockMultipartUploadRepository(ctrl), noGrants(ctrl), noQuotas(ctrl), jobs, ports.NewMockFileProvider(ctrl), nil, FileServiceConfig{})
+
+	require.NoError(t, service.QueueConfirmUpload(context.Background(), testFileID, "test-etag"))
+	assert.ErrorIs(t, service.QueueConfirmUpload(context.Background(), "", ""), domain.ErrFileIDRequired)
+}
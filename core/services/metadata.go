@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+// MetadataService rewrites images selected by the metadata policy without
+// their embedded metadata before they are scanned or handed out.
+type MetadataService struct {
+	files        *FileService
+	stripper     ports.MetadataStripper
+	fileProvider ports.FileProvider
+}
+
+func NewMetadataService(
+	files *FileService,
+	stripper ports.MetadataStripper,
+	fileProvider ports.FileProvider,
+) *MetadataService {
+	return &MetadataService{
+		files:        files,
+		stripper:     stripper,
+		fileProvider: fileProvider,
+	}
+}
+
+// StripMetadata strips the metadata of a file waiting in the processing
+// status and moves it on. Files in any other status are left as they are.
+// The size and checksum are recorded from the stripped content even when
+// nothing was left to strip, so that a run interrupted after the object was
+// rewritten is finished by the next one.
+func (s *MetadataService) StripMetadata(ctx context.Context, fileID string) error {
+	if fileID == "" {
+		return fmt.Errorf("%w: file ID is required", domain.ErrFileIDRequired)
+	}
+
+	file, err := s.files.repo.GetByID(ctx, fileID)
+	if err != nil {
+		if err == domain.ErrFileNotFound {
+			return err
+		}
+		return fmt.Errorf("%w: failed to get file: %v", domain.ErrInternal, err)
+	}
+
+	if file.Status != domain.FileStatusProcessing {
+		return nil
+	}
+
+	ctx = tenant.WithCtx(ctx, file.TenantID)
+	content, err := s.fileProvider.OpenObject(ctx, file.S3Path)
+	if err != nil {
+		return fmt.Errorf("%w: failed to open file content: %v", domain.ErrInternal, err)
+	}
+	defer content.Close()
+
+	algorithm := file.Checksum.Algorithm
+	if algorithm == "" {
+		algorithm = domain.ChecksumSHA256
+	}
+	hasher, err := algorithm.New()
+	if err != nil {
+		return err
+	}
+
+	// The first pass only measures the stripped content: the object is
+	// rewritten with a known size, and only when there is something to strip.
+	counter := &countingWriter{}
+	removed, err := s.stripper.Strip(ctx, io.MultiWriter(hasher, counter), content)
+	if err != nil {
+		// Stripping the same content again cannot succeed, and the content
+		// must not be handed out with its metadata.
+		if errors.Is(err, domain.ErrInvalidInput) {
+			log.Printf("rejecting file %s: %v", file.ID, err)
+			return s.reject(ctx, file)
+		}
+		return fmt.Errorf("%w: failed to strip metadata: %v", domain.ErrInternal, err)
+	}
+
+	if removed > 0 {
+		if err := s.rewrite(ctx, file, content, counter.n); err != nil {
+			return err
+		}
+	}
+
+	file.MarkAsStripped(counter.n, domain.Checksum{Algorithm: algorithm, Value: hasher.Sum(nil)})
+	s.files.markProcessed(file)
+	updated, err := s.files.repo.Update(ctx, file)
+	if err != nil {
+		return fmt.Errorf("%w: failed to update file status: %v", domain.ErrInternal, err)
+	}
+
+	// The stripped content is recorded, so a failure here must not strip it
+	// again.
+	if err := s.files.queueProcessing(ctx, updated); err != nil {
+		log.Printf("failed to queue processing of file %s: %v", updated.ID, err)
+	}
+
+	return nil
+}
+
+// ProcessStripMetadata is the processor of metadata stripping jobs. A file
+// deleted before its turn came needs no stripping.
+func (s *MetadataService) ProcessStripMetadata(ctx context.Context, job *domain.Job) error {
+	if err := s.StripMetadata(ctx, job.FileID); err != nil && !errors.Is(err, domain.ErrFileNotFound) {
+		return err
+	}
+	return nil
+}
+
+// rewrite keeps the original when the policy says so and replaces the object
+// with its stripped content, which is streamed into the store as it is
+// produced.
+func (s *MetadataService) rewrite(ctx context.Context, file *domain.File, content io.ReadSeeker, size int64) error {
+	if s.files.metadataPolicy.KeepOriginal {
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("%w: failed to rewind file content: %v", domain.ErrInternal, err)
+		}
+		if err := s.fileProvider.PutObject(ctx, domain.OriginalKey(file.S3Path), file.ContentType, content, file.Size); err != nil {
+			return fmt.Errorf("%w: failed to store original: %v", domain.ErrInternal, err)
+		}
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("%w: failed to rewind file content: %v", domain.ErrInternal, err)
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := s.stripper.Strip(ctx, pw, content)
+		pw.CloseWithError(err)
+	}()
+
+	err := s.fileProvider.PutObject(ctx, file.S3Path, file.ContentType, pr, size)
+	// Closing the reader stops the stripper if the store gave up early.
+	pr.Close()
+	<-done
+	if err != nil {
+		return fmt.Errorf("%w: failed to store stripped content: %v", domain.ErrInternal, err)
+	}
+
+	object, err := s.fileProvider.StatObject(ctx, file.S3Path)
+	if err != nil {
+		return fmt.Errorf("%w: failed to stat stripped content: %v", domain.ErrInternal, err)
+	}
+	file.ETag = normalizeETag(object.ETag)
+
+	return nil
+}
+
+// reject records a file whose content could not be stripped as rejected and
+// deletes the content, like an upload that does not match its declaration.
+func (s *MetadataService) reject(ctx context.Context, file *domain.File) error {
+	file.MarkAsRejected()
+	if _, err := s.files.repo.Update(ctx, file); err != nil {
+		return fmt.Errorf("%w: failed to update file status: %v", domain.ErrInternal, err)
+	}
+
+	if err := s.fileProvider.DeleteObject(ctx, file.S3Path); err != nil {
+		log.Printf("failed to delete rejected object for file %s: %v", file.ID, err)
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testStrippedContent = "\x89PNG\r\n\x1a\n stripped"
+
+var testMetadataPolicy = domain.MetadataPolicy{Owners: []string{testOwnerID}}
+
+type metadataMocks struct {
+	repo     *ports.MockFileRepository
+	provider *ports.MockFileProvider
+	stripper *ports.MockMetadataStripper
+	scanner  *ports.MockScanner
+	jobs     *ports.MockJobRepository
+}
+
+func newTestMetadataService(t *testing.T, policy domain.MetadataPolicy, setupMocks func(metadataMocks)) *MetadataService {
+	ctrl := gomock.NewController(t)
+	mocks := metadataMocks{
+		repo:     ports.NewMockFileRepository(ctrl),
+		provider: ports.NewMockFileProvider(ctrl),
+		stripper: ports.NewMockMetadataStripper(ctrl),
+		scanner:  ports.NewMockScanner(ctrl),
+		jobs:     ports.NewMockJobRepository(ctrl),
+	}
+	setupMocks(mocks)
+
+	files := NewFileService(mocks.repo, ports.NewMockMultipartUploadRepository(ctrl), noGrants(ctrl), noQuotas(ctrl), mocks.jobs, mocks.provider, mocks.scanner, FileServiceConfig{
+		UploadMaxSize:  testMaxSize,
+		UploadTTL:      5 * time.Minute,
+		DownloadTTL:    15 * time.Minute,
+		MetadataPolicy: policy,
+	})
+
+	return NewMetadataService(files, mocks.stripper, mocks.provider)
+}
+
+// strips expects the stripper to run times times over the image and write
+// testStrippedContent, reporting removed dropped blocks.
+func strips(stripper *ports.MockMetadataStripper, removed, times int) {
+	stripper.EXPECT().
+		Strip(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, dst io.Writer, content io.Reader) (int, error) {
+			if _, err := io.Copy(io.Discard, content); err != nil {
+				return 0, err
+			}
+			_, err := io.WriteString(dst, testStrippedContent)
+			return removed, err
+		}).
+		Times(times)
+}
+
+// expectJobs records the kind of every queued job.
+func expectJobs(jobs *ports.MockJobRepository, kinds *[]domain.JobKind) {
+	jobs.EXPECT().
+		Enqueue(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, job *domain.Job) (*domain.Job, error) {
+			*kinds = append(*kinds, job.Kind)
+			return job, nil
+		}).
+		AnyTimes()
+}
+
+func TestMetadataService_StripMetadata(t *testing.T) {
+	strippedSum := sha256.Sum256([]byte(testStrippedContent))
+
+	tests := []struct {
+		name             string
+		status           domain.FileStatus
+		keepOriginal     bool
+		setupMocks       func(metadataMocks)
+		expectedError    error
+		expectedStatuses []domain.FileStatus
+		expectedJobs     []domain.JobKind
+		expectedSize     int64
+	}{
+		{
+			name:   "metadata removed - object rewritten and queued for scan",
+			status: domain.FileStatusProcessing,
+			setupMocks: func(m metadataMocks) {
+				m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testImageContent)}, nil)
+				strips(m.stripper, 2, 2)
+				m.provider.EXPECT().
+					PutObject(gomock.Any(), testS3Path, "image/png", gomock.Any(), int64(len(testStrippedContent))).
+					DoAndReturn(func(_ context.Context, _, _ string, body io.Reader, _ int64) error {
+						data, err := io.ReadAll(body)
+						assert.Equal(t, testStrippedContent, string(data))
+						return err
+					})
+				m.provider.EXPECT().StatObject(gomock.Any(), testS3Path).Return(&domain.ObjectInfo{ETag: `"stripped"`}, nil)
+			},
+			expectedStatuses: []domain.FileStatus{domain.FileStatusScanning},
+			expectedJobs:     []domain.JobKind{domain.JobKindScanFile},
+			expectedSize:     int64(len(testStrippedContent)),
+		},
+		{
+			name:         "original kept next to the stripped object",
+			status:       domain.FileStatusProcessing,
+			keepOriginal: true,
+			setupMocks: func(m metadataMocks) {
+				m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testImageContent)}, nil)
+				strips(m.stripper, 1, 2)
+				m.provider.EXPECT().
+					PutObject(gomock.Any(), domain.OriginalKey(testS3Path), "image/png", gomock.Any(), int64(len(testImageContent))).
+					DoAndReturn(func(_ context.Context, _, _ string, body io.Reader, _ int64) error {
+						data, err := io.ReadAll(body)
+						assert.Equal(t, testImageContent, string(data))
+						return err
+					})
+				m.provider.EXPECT().PutObject(gomock.Any(), testS3Path, "image/png", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _ string, body io.Reader, _ int64) error {
+						_, err := io.Copy(io.Discard, body)
+						return err
+					})
+				m.provider.EXPECT().StatObject(gomock.Any(), testS3Path).Return(&domain.ObjectInfo{ETag: "stripped"}, nil)
+			},
+			expectedStatuses: []domain.FileStatus{domain.FileStatusScanning},
+			expectedJobs:     []domain.JobKind{domain.JobKindScanFile},
+			expectedSize:     int64(len(testStrippedContent)),
+		},
+		{
+			name:   "nothing to strip - object left as it is",
+			status: domain.FileStatusProcessing,
+			setupMocks: func(m metadataMocks) {
+				m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testImageContent)}, nil)
+				strips(m.stripper, 0, 1)
+			},
+			expectedStatuses: []domain.FileStatus{domain.FileStatusScanning},
+			expectedJobs:     []domain.JobKind{domain.JobKindScanFile},
+			expectedSize:     int64(len(testStrippedContent)),
+		},
+		{
+			name:   "malformed image - rejected without retry",
+			status: domain.FileStatusProcessing,
+			setupMocks: func(m metadataMocks) {
+				m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testImageContent)}, nil)
+				m.stripper.EXPECT().Strip(gomock.Any(), gomock.Any(), gomock.Any()).Return(0, domain.ErrInvalidInput)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testS3Path).Return(nil)
+			},
+			expectedStatuses: []domain.FileStatus{domain.FileStatusRejected},
+		},
+		{
+			name:   "store error - retried",
+			status: domain.FileStatusProcessing,
+			setupMocks: func(m metadataMocks) {
+				m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testImageContent)}, nil)
+				strips(m.stripper, 1, 2)
+				m.provider.EXPECT().PutObject(gomock.Any(), testS3Path, "image/png", gomock.Any(), gomock.Any()).Return(errors.New("s3 error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+		{
+			name:       "already processed - left alone",
+			status:     domain.FileStatusClean,
+			setupMocks: func(metadataMocks) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := testMetadataPolicy
+			policy.KeepOriginal = tt.keepOriginal
+
+			var (
+				statuses []domain.FileStatus
+				jobs     []domain.JobKind
+				updated  *domain.File
+			)
+			service := newTestMetadataService(t, policy, func(m metadataMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(imageFile(tt.status), nil)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						statuses = append(statuses, file.Status)
+						updated = file
+						return file, nil
+					}).
+					AnyTimes()
+				expectJobs(m.jobs, &jobs)
+				tt.setupMocks(m)
+			})
+
+			err := service.StripMetadata(context.Background(), testFileID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedStatuses, statuses)
+			assert.Equal(t, tt.expectedJobs, jobs)
+			if tt.expectedSize != 0 {
+				require.NotNil(t, updated)
+				assert.Equal(t, tt.expectedSize, updated.Size)
+				assert.Equal(t, domain.ChecksumSHA256, updated.Checksum.Algorithm)
+				assert.True(t, updated.Checksum.Matches(strippedSum[:]))
+			}
+		})
+	}
+}
+
+func TestMetadataService_ProcessStripMetadata_DeletedFile(t *testing.T) {
+	service := newTestMetadataService(t, testMetadataPolicy, func(m metadataMocks) {
+		m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(nil, domain.ErrFileNotFound)
+	})
+
+	err := service.ProcessStripMetadata(context.Background(), domain.NewJob(domain.JobKindStripMetadata, testFileID, nil))
+
+	assert.NoError(t, err)
+}
+
+func TestFileService_ConfirmUpload_MetadataPolicy(t *testing.T) {
+	storedObject := &domain.ObjectInfo{Size: int64(len(testImageContent)), ContentType: "image/png", ETag: "etag"}
+
+	tests := []struct {
+		name             string
+		policy           domain.MetadataPolicy
+		status           domain.FileStatus
+		expectedStatuses []domain.FileStatus
+		expectedJobs     []domain.JobKind
+	}{
+		{
+			name:             "selected owner - queued for stripping",
+			policy:           testMetadataPolicy,
+			status:           domain.FileStatusPending,
+			expectedStatuses: []domain.FileStatus{domain.FileStatusProcessing},
+			expectedJobs:     []domain.JobKind{domain.JobKindStripMetadata},
+		},
+		{
+			name:             "every tenant - queued for stripping",
+			policy:           domain.MetadataPolicy{Tenants: []string{domain.PolicyEveryone}},
+			status:           domain.FileStatusPending,
+			expectedStatuses: []domain.FileStatus{domain.FileStatusProcessing},
+			expectedJobs:     []domain.JobKind{domain.JobKindStripMetadata},
+		},
+		{
+			name:             "other owner - queued for scan",
+			policy:           domain.MetadataPolicy{Owners: []string{"other-owner"}},
+			status:           domain.FileStatusPending,
+			expectedStatuses: []domain.FileStatus{domain.FileStatusScanning},
+			expectedJobs:     []domain.JobKind{domain.JobKindScanFile},
+		},
+		{
+			name:         "repeated event queues the stripping again",
+			policy:       testMetadataPolicy,
+			status:       domain.FileStatusProcessing,
+			expectedJobs: []domain.JobKind{domain.JobKindStripMetadata},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				statuses []domain.FileStatus
+				jobs     []domain.JobKind
+			)
+			service := newTestMetadataService(t, tt.policy, func(m metadataMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(imageFile(tt.status), nil)
+				expectStatuses(m.repo, &statuses)
+				expectJobs(m.jobs, &jobs)
+				if tt.status == domain.FileStatusPending {
+					m.provider.EXPECT().StatObject(gomock.Any(), testS3Path).Return(storedObject, nil)
+					m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testImageContent)}, nil)
+				}
+			})
+
+			err := service.files.ConfirmUpload(context.Background(), testFileID, "")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatuses, statuses)
+			assert.Equal(t, tt.expectedJobs, jobs)
+		})
+	}
+}
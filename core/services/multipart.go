@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+const maxPartURLBatch = 100
+
+func (s *FileService) InitiateMultipartUpload(ctx context.Context, ownerID, contentType string, size int64) (*domain.InitiateMultipartUploadResult, error) {
+	return s.initiateMultipartUpload(ctx, ownerID, contentType, size, s.partSize)
+}
+
+func (s *FileService) initiateMultipartUpload(ctx context.Context, ownerID, contentType string, size, preferredPartSize int64) (*domain.InitiateMultipartUploadResult, error) {
+	if ownerID == "" {
+		return nil, fmt.Errorf("%w: owner ID is required", domain.ErrInvalidInput)
+	}
+	if err := domain.ValidateOwnerID(ownerID, s.tenants); err != nil {
+		return nil, err
+	}
+	if err := s.validateContent(contentType, size, s.multipartMaxSize); err != nil {
+		return nil, err
+	}
+
+	partSize, partCount := domain.PlanMultipartUpload(size, preferredPartSize)
+	if partCount > domain.MultipartMaxParts {
+		return nil, fmt.Errorf("%w: file is too large for a multipart upload", domain.ErrInvalidInput)
+	}
+	quotas, err := s.checkQuota(ctx, ownerID, size)
+	if err != nil {
+		return nil, err
+	}
+
+	file := domain.NewFile(tenant.FromCtx(ctx), ownerID, contentType, size)
+
+	created, err := s.createFile(ctx, file, quotas)
+	if err != nil {
+		return nil, err
+	}
+
+	uploadID, err := s.fileProvider.CreateMultipartUpload(ctx, created.S3Path, created.ContentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to create multipart upload: %v", domain.ErrInternal, err)
+	}
+
+	_, err = s.uploads.Create(ctx, &domain.MultipartUpload{
+		FileID:    created.ID,
+		UploadID:  uploadID,
+		PartSize:  partSize,
+		PartCount: partCount,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		if abortErr := s.fileProvider.AbortMultipartUpload(ctx, created.S3Path, uploadID); abortErr != nil {
+			log.Printf("failed to abort multipart upload for file %s: %v", created.ID, abortErr)
+		}
+		return nil, fmt.Errorf("%w: failed to save multipart upload: %v", domain.ErrInternal, err)
+	}
+
+	return &domain.InitiateMultipartUploadResult{
+		FileID:      created.ID,
+		PartSize:    partSize,
+		PartCount:   partCount,
+		DownloadURL: fmt.Sprintf("/files/%s/download", created.ID),
+	}, nil
+}
+
+func (s *FileService) GetPartUploadURLs(ctx context.Context, ownerID, fileID string, firstPart, count int) ([]domain.PartUploadURL, error) {
+	file, upload, err := s.getMultipartUpload(ctx, ownerID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if firstPart < 1 || firstPart > upload.PartCount {
+		return nil, fmt.Errorf("%w: part number must be between 1 and %d", domain.ErrInvalidInput, upload.PartCount)
+	}
+	if count <= 0 || count > maxPartURLBatch {
+		return nil, fmt.Errorf("%w: count must be between 1 and %d", domain.ErrInvalidInput, maxPartURLBatch)
+	}
+
+	lastPart := min(firstPart+count-1, upload.PartCount)
+	urls := make([]domain.PartUploadURL, 0, lastPart-firstPart+1)
+	for partNumber := firstPart; partNumber <= lastPart; partNumber++ {
+		uploadURL, err := s.fileProvider.GeneratePartUploadURL(ctx, file.S3Path, upload.UploadID, partNumber, s.uploadTTL)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to generate part upload URL: %v", domain.ErrInternal, err)
+		}
+		urls = append(urls, domain.PartUploadURL{
+			PartNumber: partNumber,
+			UploadURL:  uploadURL,
+		})
+	}
+
+	return urls, nil
+}
+
+func (s *FileService) CompleteMultipartUpload(ctx context.Context, ownerID, fileID string, parts []domain.UploadPart) error {
+	file, upload, err := s.getMultipartUpload(ctx, ownerID, fileID)
+	if err != nil {
+		return err
+	}
+
+	if len(parts) != upload.PartCount {
+		return fmt.Errorf("%w: expected %d parts, got %d", domain.ErrInvalidInput, upload.PartCount, len(parts))
+	}
+
+	sorted := make([]domain.UploadPart, len(parts))
+	copy(sorted, parts)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].PartNumber < sorted[j].PartNumber
+	})
+	for i, part := range sorted {
+		if part.PartNumber != i+1 {
+			return fmt.Errorf("%w: parts must be numbered from 1 to %d", domain.ErrInvalidInput, upload.PartCount)
+		}
+		if part.ETag == "" {
+			return fmt.Errorf("%w: part %d has no ETag", domain.ErrInvalidInput, part.PartNumber)
+		}
+	}
+
+	return s.finishMultipartUpload(ctx, file, upload, sorted)
+}
+
+func (s *FileService) AbortMultipartUpload(ctx context.Context, ownerID, fileID string) error {
+	file, upload, err := s.getMultipartUpload(ctx, ownerID, fileID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.fileProvider.AbortMultipartUpload(ctx, file.S3Path, upload.UploadID); err != nil {
+		return fmt.Errorf("%w: failed to abort multipart upload: %v", domain.ErrInternal, err)
+	}
+
+	if err := s.uploads.Delete(ctx, file.ID); err != nil && !errors.Is(err, domain.ErrUploadNotFound) {
+		return fmt.Errorf("%w: failed to delete multipart upload: %v", domain.ErrInternal, err)
+	}
+
+	return s.DeleteFile(ctx, file.ID)
+}
+
+func (s *FileService) finishMultipartUpload(ctx context.Context, file *domain.File, upload *domain.MultipartUpload, parts []domain.UploadPart) error {
+	if err := s.fileProvider.CompleteMultipartUpload(ctx, file.S3Path, upload.UploadID, parts); err != nil {
+		return fmt.Errorf("%w: failed to complete multipart upload: %v", domain.ErrInternal, err)
+	}
+
+	if err := s.uploads.Delete(ctx, file.ID); err != nil && !errors.Is(err, domain.ErrUploadNotFound) {
+		return fmt.Errorf("%w: failed to delete multipart upload: %v", domain.ErrInternal, err)
+	}
+
+	confirmed, err := s.confirmUpload(ctx, file.ID, "")
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrInternal, err)
+	}
+
+	switch {
+	case confirmed == nil:
+		return domain.ErrFileNotFound
+	case confirmed.Status == domain.FileStatusRejected:
+		return fmt.Errorf("%w: uploaded object does not match the declared size or content type", domain.ErrInvalidInput)
+	case confirmed.Status == domain.FileStatusCorrupt:
+		return domain.ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+func (s *FileService) getOwnedFile(ctx context.Context, ownerID, fileID string) (*domain.File, error) {
+	if fileID == "" {
+		return nil, fmt.Errorf("%w: file ID is required", domain.ErrFileIDRequired)
+	}
+
+	file, err := s.repo.GetByID(ctx, fileID)
+	if err != nil {
+		if err == domain.ErrFileNotFound {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: failed to get file: %v", domain.ErrInternal, err)
+	}
+
+	if ownerID == "" || file.OwnerID != ownerID {
+		return nil, domain.ErrAccessDenied
+	}
+
+	return file, nil
+}
+
+func (s *FileService) getMultipartUpload(ctx context.Context, ownerID, fileID string) (*domain.File, *domain.MultipartUpload, error) {
+	file, err := s.getOwnedFile(ctx, ownerID, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	upload, err := s.uploads.GetByFileID(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUploadNotFound) {
+			return nil, nil, fmt.Errorf("%w: no multipart upload in progress", domain.ErrFileNotFound)
+		}
+		return nil, nil, fmt.Errorf("%w: failed to get multipart upload: %v", domain.ErrInternal, err)
+	}
+
+	return file, upload, nil
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testUploadID      = "test-upload-id"
+	testPartSize      = int64(64 * 1024 * 1024)
+	testMultipartSize = int64(1024 * 1024 * 1024)
+)
+
+type multipartMocks struct {
+	repo     *ports.MockFileRepository
+	uploads  *ports.MockMultipartUploadRepository
+	provider *ports.MockFileProvider
+}
+
+func newMultipartTestService(t *testing.T, setupMocks func(multipartMocks)) *FileService {
+	ctrl := gomock.NewController(t)
+	mocks := multipartMocks{
+		repo:     ports.NewMockFileRepository(ctrl),
+		uploads:  ports.NewMockMultipartUploadRepository(ctrl),
+		provider: ports.NewMockFileProvider(ctrl),
+	}
+	setupMocks(mocks)
+
+	return NewFileService(mocks.repo, mocks.uploads, noGrants(ctrl), noQuotas(ctrl), noJobs(ctrl), mocks.provider, nil, FileServiceConfig{
+		UploadMaxSize:    testMaxSize,
+		MultipartMaxSize: 10 * testMultipartSize,
+		PartSize:         testPartSize,
+		UploadTTL:        5 * time.Minute,
+		DownloadTTL:      15 * time.Minute,
+	})
+}
+
+func pendingMultipartFile() *domain.File {
+	return &domain.File{
+		ID:          testFileID,
+		OwnerID:     testOwnerID,
+		S3Path:      testS3Path,
+		Size:        testMultipartSize,
+		ContentType: testContentType,
+		Status:      domain.FileStatusPending,
+	}
+}
+
+func testMultipartUpload() *domain.MultipartUpload {
+	return &domain.MultipartUpload{
+		FileID:    testFileID,
+		UploadID:  testUploadID,
+		PartSize:  testPartSize,
+		PartCount: 16,
+	}
+}
+
+func testCompletedParts(count int) []domain.UploadPart {
+	parts := make([]domain.UploadPart, 0, count)
+	for i := count; i >= 1; i-- {
+		parts = append(parts, domain.UploadPart{PartNumber: i, ETag: "etag"})
+	}
+	return parts
+}
+
+func TestFileService_InitiateMultipartUpload(t *testing.T) {
+	tests := []struct {
+		name          string
+		ownerID       string
+		size          int64
+		setupMocks    func(multipartMocks)
+		expectedError error
+	}{
+		{
+			name:    "success path",
+			ownerID: testOwnerID,
+			size:    testMultipartSize,
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusPending, file.Status)
+						file.ID = testFileID
+						file.S3Path = testS3Path
+						return file, nil
+					})
+				m.provider.EXPECT().
+					CreateMultipartUpload(gomock.Any(), testS3Path, testContentType).
+					Return(testUploadID, nil)
+				m.uploads.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, upload *domain.MultipartUpload) (*domain.MultipartUpload, error) {
+						assert.Equal(t, testUploadID, upload.UploadID)
+						assert.Equal(t, 16, upload.PartCount)
+						return upload, nil
+					})
+			},
+		},
+		{
+			name:          "empty owner ID",
+			ownerID:       "",
+			size:          testMultipartSize,
+			setupMocks:    func(multipartMocks) {},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:          "size exceeds multipart limit",
+			ownerID:       testOwnerID,
+			size:          11 * testMultipartSize,
+			setupMocks:    func(multipartMocks) {},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:    "upload state not saved",
+			ownerID: testOwnerID,
+			size:    testMultipartSize,
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						file.ID = testFileID
+						file.S3Path = testS3Path
+						return file, nil
+					})
+				m.provider.EXPECT().
+					CreateMultipartUpload(gomock.Any(), testS3Path, testContentType).
+					Return(testUploadID, nil)
+				m.uploads.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("database error"))
+				m.provider.EXPECT().
+					AbortMultipartUpload(gomock.Any(), testS3Path, testUploadID).
+					Return(nil)
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newMultipartTestService(t, tt.setupMocks)
+
+			result, err := service.InitiateMultipartUpload(context.Background(), tt.ownerID, testContentType, tt.size)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testFileID, result.FileID)
+			assert.Equal(t, testPartSize, result.PartSize)
+			assert.Equal(t, 16, result.PartCount)
+			assert.Equal(t, "/files/"+testFileID+"/download", result.DownloadURL)
+		})
+	}
+}
+
+func TestFileService_GetPartUploadURLs(t *testing.T) {
+	tests := []struct {
+		name          string
+		ownerID       string
+		firstPart     int
+		count         int
+		setupMocks    func(multipartMocks)
+		expectedParts []int
+		expectedError error
+	}{
+		{
+			name:      "batch clipped to part count",
+			ownerID:   testOwnerID,
+			firstPart: 15,
+			count:     10,
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+				m.provider.EXPECT().
+					GeneratePartUploadURL(gomock.Any(), testS3Path, testUploadID, gomock.Any(), 5*time.Minute).
+					Return(testUploadURL, nil).
+					Times(2)
+			},
+			expectedParts: []int{15, 16},
+		},
+		{
+			name:      "part number out of range",
+			ownerID:   testOwnerID,
+			firstPart: 17,
+			count:     1,
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:      "batch too large",
+			ownerID:   testOwnerID,
+			firstPart: 1,
+			count:     maxPartURLBatch + 1,
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:      "not the owner",
+			ownerID:   "someone-else",
+			firstPart: 1,
+			count:     1,
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:      "no upload in progress",
+			ownerID:   testOwnerID,
+			firstPart: 1,
+			count:     1,
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(nil, domain.ErrUploadNotFound)
+			},
+			expectedError: domain.ErrFileNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newMultipartTestService(t, tt.setupMocks)
+
+			urls, err := service.GetPartUploadURLs(context.Background(), tt.ownerID, testFileID, tt.firstPart, tt.count)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			var parts []int
+			for _, url := range urls {
+				parts = append(parts, url.PartNumber)
+				assert.Equal(t, testUploadURL, url.UploadURL)
+			}
+			assert.Equal(t, tt.expectedParts, parts)
+		})
+	}
+}
+
+func TestFileService_CompleteMultipartUpload(t *testing.T) {
+	tests := []struct {
+		name          string
+		parts         []domain.UploadPart
+		setupMocks    func(multipartMocks)
+		expectedError error
+	}{
+		{
+			name:  "success path",
+			parts: testCompletedParts(16),
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil).Times(2)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+				m.provider.EXPECT().
+					CompleteMultipartUpload(gomock.Any(), testS3Path, testUploadID, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _, _ string, parts []domain.UploadPart) error {
+						for i, part := range parts {
+							assert.Equal(t, i+1, part.PartNumber)
+						}
+						return nil
+					})
+				m.uploads.EXPECT().Delete(gomock.Any(), testFileID).Return(nil)
+				m.provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(&domain.ObjectInfo{Size: testMultipartSize, ContentType: testContentType, ETag: "etag-16"}, nil)
+				storedContent(m.provider)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusUploaded, file.Status)
+						return file, nil
+					})
+			},
+		},
+		{
+			name:  "assembled object does not match",
+			parts: testCompletedParts(16),
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil).Times(2)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+				m.provider.EXPECT().CompleteMultipartUpload(gomock.Any(), testS3Path, testUploadID, gomock.Any()).Return(nil)
+				m.uploads.EXPECT().Delete(gomock.Any(), testFileID).Return(nil)
+				m.provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(&domain.ObjectInfo{Size: testMultipartSize - 1, ContentType: testContentType, ETag: "etag-16"}, nil)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusRejected, file.Status)
+						return file, nil
+					})
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testS3Path).Return(nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:  "missing part",
+			parts: testCompletedParts(15),
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:  "duplicate part number",
+			parts: append(testCompletedParts(15), domain.UploadPart{PartNumber: 15, ETag: "etag"}),
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:  "provider error",
+			parts: testCompletedParts(16),
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+				m.provider.EXPECT().
+					CompleteMultipartUpload(gomock.Any(), testS3Path, testUploadID, gomock.Any()).
+					Return(errors.New("s3 error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newMultipartTestService(t, tt.setupMocks)
+
+			err := service.CompleteMultipartUpload(context.Background(), testOwnerID, testFileID, tt.parts)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestFileService_AbortMultipartUpload(t *testing.T) {
+	tests := []struct {
+		name          string
+		setupMocks    func(multipartMocks)
+		expectedError error
+	}{
+		{
+			name: "success path",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+				m.provider.EXPECT().AbortMultipartUpload(gomock.Any(), testS3Path, testUploadID).Return(nil)
+				m.uploads.EXPECT().Delete(gomock.Any(), testFileID).Return(nil)
+				m.repo.EXPECT().SoftDelete(gomock.Any(), testFileID).Return(nil)
+			},
+		},
+		{
+			name: "file not found",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(nil, domain.ErrFileNotFound)
+			},
+			expectedError: domain.ErrFileNotFound,
+		},
+		{
+			name: "provider error",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingMultipartFile(), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+				m.provider.EXPECT().AbortMultipartUpload(gomock.Any(), testS3Path, testUploadID).Return(errors.New("s3 error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newMultipartTestService(t, tt.setupMocks)
+
+			err := service.AbortMultipartUpload(context.Background(), testOwnerID, testFileID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
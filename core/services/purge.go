@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+type FilePurgerConfig struct {
+	Retention time.Duration
+	Lease     time.Duration
+	BatchSize int
+}
+
+// FilePurger removes the content of files that have stayed soft-deleted for
+// longer than the retention window and tombstones their rows. Every step is
+// safe to repeat, so a batch interrupted half way is finished by a later run.
+type FilePurger struct {
+	repo         ports.FileRepository
+	uploads      ports.MultipartUploadRepository
+	thumbnails   ports.ThumbnailRepository
+	fileProvider ports.FileProvider
+	cfg          FilePurgerConfig
+}
+
+func NewFilePurger(
+	repo ports.FileRepository,
+	uploads ports.MultipartUploadRepository,
+	thumbnails ports.ThumbnailRepository,
+	fileProvider ports.FileProvider,
+	cfg FilePurgerConfig,
+) *FilePurger {
+	return &FilePurger{
+		repo:         repo,
+		uploads:      uploads,
+		thumbnails:   thumbnails,
+		fileProvider: fileProvider,
+		cfg:          cfg,
+	}
+}
+
+func (p *FilePurger) PurgeBatch(ctx context.Context) (*domain.PurgeFilesResult, error) {
+	ctx = tenant.Unscoped(ctx)
+	files, err := p.repo.ClaimPurgeable(ctx, time.Now().Add(-p.cfg.Retention), p.cfg.Lease, p.cfg.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to claim files for purge: %v", domain.ErrInternal, err)
+	}
+
+	result := &domain.PurgeFilesResult{Claimed: len(files)}
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		if err := p.purge(ctx, file); err != nil {
+			log.Printf("purger: failed to purge file %s: %v", file.ID, err)
+			result.Failed++
+			continue
+		}
+		result.Purged++
+	}
+
+	return result, nil
+}
+
+func (p *FilePurger) purge(ctx context.Context, file *domain.File) error {
+	ctx = tenant.WithCtx(ctx, file.TenantID)
+	upload, err := p.uploads.GetByFileID(ctx, file.ID)
+	switch {
+	case err == nil:
+		if err := p.fileProvider.AbortMultipartUpload(ctx, file.S3Path, upload.UploadID); err != nil {
+			return fmt.Errorf("failed to abort multipart upload: %w", err)
+		}
+		if err := p.uploads.Delete(ctx, file.ID); err != nil && !errors.Is(err, domain.ErrUploadNotFound) {
+			return fmt.Errorf("failed to delete multipart upload: %w", err)
+		}
+	case !errors.Is(err, domain.ErrUploadNotFound):
+		return fmt.Errorf("failed to get multipart upload: %w", err)
+	}
+
+	thumbnails, err := p.thumbnails.ListByFileID(ctx, file.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list thumbnails: %w", err)
+	}
+	for _, thumbnail := range thumbnails {
+		if err := p.fileProvider.DeleteObject(ctx, thumbnail.S3Path); err != nil {
+			return err
+		}
+	}
+	if err := p.thumbnails.DeleteByFileID(ctx, file.ID); err != nil {
+		return fmt.Errorf("failed to delete thumbnails: %w", err)
+	}
+
+	// Deleting an original that was never kept is a no-op.
+	if err := p.fileProvider.DeleteObject(ctx, domain.OriginalKey(file.S3Path)); err != nil {
+		return err
+	}
+	if err := p.fileProvider.DeleteObject(ctx, file.S3Path); err != nil {
+		return err
+	}
+
+	// Another replica whose lease expired mid-run may have got here first.
+	if err := p.repo.MarkPurged(ctx, file.ID); err != nil && !errors.Is(err, domain.ErrFileNotFound) {
+		return fmt.Errorf("failed to mark file as purged: %w", err)
+	}
+
+	return nil
+}
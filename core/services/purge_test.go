@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testRetention      = 30 * 24 * time.Hour
+	testPurgeLease     = 5 * time.Minute
+	testPurgeBatchSize = 10
+)
+
+func newTestFilePurger(t *testing.T, setupMocks func(multipartMocks)) *FilePurger {
+	ctrl := gomock.NewController(t)
+	mocks := multipartMocks{
+		repo:     ports.NewMockFileRepository(ctrl),
+		uploads:  ports.NewMockMultipartUploadRepository(ctrl),
+		provider: ports.NewMockFileProvider(ctrl),
+	}
+	setupMocks(mocks)
+
+	return NewFilePurger(mocks.repo, mocks.uploads, noThumbnails(ctrl), mocks.provider, FilePurgerConfig{
+		Retention: testRetention,
+		Lease:     testPurgeLease,
+		BatchSize: testPurgeBatchSize,
+	})
+}
+
+// noThumbnails returns a thumbnail repository that holds no thumbnails.
+func noThumbnails(ctrl *gomock.Controller) *ports.MockThumbnailRepository {
+	thumbnails := ports.NewMockThumbnailRepository(ctrl)
+	thumbnails.EXPECT().ListByFileID(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	thumbnails.EXPECT().DeleteByFileID(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	return thumbnails
+}
+
+func deletedFile() *domain.File {
+	return &domain.File{
+		ID:          testFileID,
+		OwnerID:     testOwnerID,
+		S3Path:      testS3Path,
+		Size:        testFileSize,
+		ContentType: testContentType,
+		Status:      domain.FileStatusUploaded,
+		IsDeleted:   true,
+		DeletedAt:   time.Now().Add(-testRetention - time.Hour),
+	}
+}
+
+func TestFilePurger_PurgeBatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMocks     func(multipartMocks)
+		expectedResult *domain.PurgeFilesResult
+		expectedError  error
+	}{
+		{
+			name: "success path - object deleted and row tombstoned",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().
+					ClaimPurgeable(gomock.Any(), gomock.Any(), testPurgeLease, testPurgeBatchSize).
+					DoAndReturn(func(_ context.Context, deletedBefore time.Time, _ time.Duration, _ int) ([]*domain.File, error) {
+						assert.WithinDuration(t, time.Now().Add(-testRetention), deletedBefore, time.Second)
+						return []*domain.File{deletedFile()}, nil
+					})
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(nil, domain.ErrUploadNotFound)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), domain.OriginalKey(testS3Path)).Return(nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testS3Path).Return(nil)
+				m.repo.EXPECT().MarkPurged(gomock.Any(), testFileID).Return(nil)
+			},
+			expectedResult: &domain.PurgeFilesResult{Claimed: 1, Purged: 1},
+		},
+		{
+			name: "unfinished multipart upload - aborted before purge",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ClaimPurgeable(gomock.Any(), gomock.Any(), testPurgeLease, testPurgeBatchSize).Return([]*domain.File{deletedFile()}, nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+				m.provider.EXPECT().AbortMultipartUpload(gomock.Any(), testS3Path, testUploadID).Return(nil)
+				m.uploads.EXPECT().Delete(gomock.Any(), testFileID).Return(nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), domain.OriginalKey(testS3Path)).Return(nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testS3Path).Return(nil)
+				m.repo.EXPECT().MarkPurged(gomock.Any(), testFileID).Return(nil)
+			},
+			expectedResult: &domain.PurgeFilesResult{Claimed: 1, Purged: 1},
+		},
+		{
+			name: "already purged by another replica",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ClaimPurgeable(gomock.Any(), gomock.Any(), testPurgeLease, testPurgeBatchSize).Return([]*domain.File{deletedFile()}, nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(nil, domain.ErrUploadNotFound)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), domain.OriginalKey(testS3Path)).Return(nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testS3Path).Return(nil)
+				m.repo.EXPECT().MarkPurged(gomock.Any(), testFileID).Return(domain.ErrFileNotFound)
+			},
+			expectedResult: &domain.PurgeFilesResult{Claimed: 1, Purged: 1},
+		},
+		{
+			name: "delete object error - row left for the next run",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ClaimPurgeable(gomock.Any(), gomock.Any(), testPurgeLease, testPurgeBatchSize).Return([]*domain.File{deletedFile()}, nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(nil, domain.ErrUploadNotFound)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), domain.OriginalKey(testS3Path)).Return(nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testS3Path).Return(errors.New("s3 error"))
+			},
+			expectedResult: &domain.PurgeFilesResult{Claimed: 1, Failed: 1},
+		},
+		{
+			name: "mark purged error",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ClaimPurgeable(gomock.Any(), gomock.Any(), testPurgeLease, testPurgeBatchSize).Return([]*domain.File{deletedFile()}, nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(nil, domain.ErrUploadNotFound)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), domain.OriginalKey(testS3Path)).Return(nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testS3Path).Return(nil)
+				m.repo.EXPECT().MarkPurged(gomock.Any(), testFileID).Return(errors.New("database error"))
+			},
+			expectedResult: &domain.PurgeFilesResult{Claimed: 1, Failed: 1},
+		},
+		{
+			name: "repository error",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ClaimPurgeable(gomock.Any(), gomock.Any(), testPurgeLease, testPurgeBatchSize).Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			purger := newTestFilePurger(t, tt.setupMocks)
+
+			result, err := purger.PurgeBatch(context.Background())
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
+
+func TestFilePurger_PurgeBatch_Thumbnails(t *testing.T) {
+	thumbnailPath := domain.ThumbnailKey(testS3Path, 128)
+
+	tests := []struct {
+		name           string
+		setupMocks     func(multipartMocks, *ports.MockThumbnailRepository)
+		expectedResult *domain.PurgeFilesResult
+	}{
+		{
+			name: "thumbnails deleted with the original",
+			setupMocks: func(m multipartMocks, thumbnails *ports.MockThumbnailRepository) {
+				thumbnails.EXPECT().ListByFileID(gomock.Any(), testFileID).
+					Return([]*domain.Thumbnail{{FileID: testFileID, Size: 128, S3Path: thumbnailPath}}, nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), thumbnailPath).Return(nil)
+				thumbnails.EXPECT().DeleteByFileID(gomock.Any(), testFileID).Return(nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), domain.OriginalKey(testS3Path)).Return(nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testS3Path).Return(nil)
+				m.repo.EXPECT().MarkPurged(gomock.Any(), testFileID).Return(nil)
+			},
+			expectedResult: &domain.PurgeFilesResult{Claimed: 1, Purged: 1},
+		},
+		{
+			name: "thumbnail delete error - original kept for the next run",
+			setupMocks: func(m multipartMocks, thumbnails *ports.MockThumbnailRepository) {
+				thumbnails.EXPECT().ListByFileID(gomock.Any(), testFileID).
+					Return([]*domain.Thumbnail{{FileID: testFileID, Size: 128, S3Path: thumbnailPath}}, nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), thumbnailPath).Return(errors.New("s3 error"))
+			},
+			expectedResult: &domain.PurgeFilesResult{Claimed: 1, Failed: 1},
+		},
+		{
+			name: "list thumbnails error",
+			setupMocks: func(m multipartMocks, thumbnails *ports.MockThumbnailRepository) {
+				thumbnails.EXPECT().ListByFileID(gomock.Any(), testFileID).Return(nil, errors.New("database error"))
+			},
+			expectedResult: &domain.PurgeFilesResult{Claimed: 1, Failed: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mocks := multipartMocks{
+				repo:     ports.NewMockFileRepository(ctrl),
+				uploads:  ports.NewMockMultipartUploadRepository(ctrl),
+				provider: ports.NewMockFileProvider(ctrl),
+			}
+			thumbnails := ports.NewMockThumbnailRepository(ctrl)
+			mocks.repo.EXPECT().ClaimPurgeable(gomock.Any(), gomock.Any(), testPurgeLease, testPurgeBatchSize).Return([]*domain.File{deletedFile()}, nil)
+			mocks.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(nil, domain.ErrUploadNotFound)
+			tt.setupMocks(mocks, thumbnails)
+
+			purger := NewFilePurger(mocks.repo, mocks.uploads, thumbnails, mocks.provider, FilePurgerConfig{
+				Retention: testRetention,
+				Lease:     testPurgeLease,
+				BatchSize: testPurgeBatchSize,
+			})
+			result, err := purger.PurgeBatch(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
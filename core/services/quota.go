@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+// GetUsage reports the owner quota and the tenant quota that apply to the
+// owner, each with what has been stored and reserved against it.
+func (s *FileService) GetUsage(ctx context.Context, ownerID string) ([]*domain.QuotaUsage, error) {
+	if ownerID == "" {
+		return nil, fmt.Errorf("%w: owner ID is required", domain.ErrInvalidInput)
+	}
+
+	quotas, err := s.quotasFor(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	usages := make([]*domain.QuotaUsage, 0, len(quotas))
+	for _, quota := range quotas {
+		usage, err := s.quotas.GetUsage(ctx, quota.TenantID, quota.OwnerID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to get %s usage: %v", domain.ErrInternal, quota.Scope, err)
+		}
+		usages = append(usages, &domain.QuotaUsage{Quota: quota, Usage: *usage})
+	}
+
+	return usages, nil
+}
+
+// checkQuota refuses a new file of size when it would take the owner or the
+// tenant past a quota, and returns the quotas that set a limit. Pending
+// uploads keep their space reserved until they are confirmed or the reaper
+// expires them. Concurrent uploads may all pass this early check, so the
+// returned quotas are checked again when the file is created.
+func (s *FileService) checkQuota(ctx context.Context, ownerID string, size int64) ([]domain.Quota, error) {
+	quotas, err := s.quotasFor(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var limited []domain.Quota
+	for _, quota := range quotas {
+		if quota.Unlimited() {
+			continue
+		}
+		usage, err := s.quotas.GetUsage(ctx, quota.TenantID, quota.OwnerID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to get %s usage: %v", domain.ErrInternal, quota.Scope, err)
+		}
+		if err := quota.Check(*usage, size); err != nil {
+			return nil, err
+		}
+		limited = append(limited, quota)
+	}
+
+	return limited, nil
+}
+
+// lockGrowth holds the byte quotas of the owner while a stored file grows by
+// delta, and refuses the growth when it would take the owner or the tenant
+// past a quota. Files created in the meantime wait for the returned release.
+func (s *FileService) lockGrowth(ctx context.Context, ownerID string, delta int64) (func(), error) {
+	if delta <= 0 {
+		return func() {}, nil
+	}
+
+	quotas, err := s.quotasFor(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	quotas = slices.DeleteFunc(quotas, func(quota domain.Quota) bool {
+		return quota.MaxBytes <= 0
+	})
+	if len(quotas) == 0 {
+		return func() {}, nil
+	}
+
+	release, err := s.quotas.Lock(ctx, quotas)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to lock quotas: %v", domain.ErrInternal, err)
+	}
+
+	for _, quota := range quotas {
+		usage, err := s.quotas.GetUsage(ctx, quota.TenantID, quota.OwnerID)
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("%w: failed to get %s usage: %v", domain.ErrInternal, quota.Scope, err)
+		}
+		if err := quota.CheckGrowth(*usage, delta); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// createFile creates the record of a new file, checking it against quotas
+// in the same step when any of them sets a limit.
+func (s *FileService) createFile(ctx context.Context, file *domain.File, quotas []domain.Quota) (*domain.File, error) {
+	var (
+		created *domain.File
+		err     error
+	)
+	if len(quotas) == 0 {
+		created, err = s.repo.Create(ctx, file)
+	} else {
+		created, err = s.repo.CreateWithinQuotas(ctx, file, quotas)
+	}
+	if err != nil {
+		if errors.Is(err, domain.ErrQuotaExceeded) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: failed to create file record: %v", domain.ErrInternal, err)
+	}
+
+	return created, nil
+}
+
+// createStoredFile is createFile for content that is already stored: the
+// content is deleted again when the file no longer fits in a quota.
+func (s *FileService) createStoredFile(ctx context.Context, file *domain.File, quotas []domain.Quota) (*domain.File, error) {
+	created, err := s.createFile(ctx, file, quotas)
+	if errors.Is(err, domain.ErrQuotaExceeded) {
+		if err := s.fileProvider.DeleteObject(ctx, file.S3Path); err != nil {
+			log.Printf("failed to delete object of file %s over quota: %v", file.ID, err)
+		}
+	}
+	return created, err
+}
+
+// quotasFor returns the owner quota and the tenant quota, each taken from the
+// stored override when there is one and from the configured default if not.
+func (s *FileService) quotasFor(ctx context.Context, ownerID string) ([]domain.Quota, error) {
+	tenantID := tenant.FromCtx(ctx)
+
+	ownerQuota := domain.Quota{
+		Scope:    domain.QuotaScopeOwner,
+		TenantID: tenantID,
+		OwnerID:  ownerID,
+		MaxBytes: s.ownerQuota.MaxBytes,
+		MaxFiles: s.ownerQuota.MaxFiles,
+	}
+	tenantQuota := domain.Quota{
+		Scope:    domain.QuotaScopeTenant,
+		TenantID: tenantID,
+		MaxBytes: s.tenantQuota.MaxBytes,
+		MaxFiles: s.tenantQuota.MaxFiles,
+	}
+
+	overrides, err := s.quotas.ListOverrides(ctx, tenantID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list quota overrides: %v", domain.ErrInternal, err)
+	}
+	for _, override := range overrides {
+		switch override.Scope {
+		case domain.QuotaScopeOwner:
+			ownerQuota = *override
+		case domain.QuotaScopeTenant:
+			tenantQuota = *override
+		}
+	}
+
+	return []domain.Quota{ownerQuota, tenantQuota}, nil
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+	"github.com/gruzdev-dev/codex-files/pkg/identity"
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type quotaMocks struct {
+	repo     *ports.MockFileRepository
+	quotas   *ports.MockQuotaRepository
+	provider *ports.MockFileProvider
+}
+
+// newQuotaTestService limits owners to 10 files and 10MB and leaves tenants
+// unlimited unless an override says otherwise.
+func newQuotaTestService(t *testing.T, setupMocks func(quotaMocks)) *FileService {
+	ctrl := gomock.NewController(t)
+	mocks := quotaMocks{
+		repo:     ports.NewMockFileRepository(ctrl),
+		quotas:   ports.NewMockQuotaRepository(ctrl),
+		provider: ports.NewMockFileProvider(ctrl),
+	}
+	setupMocks(mocks)
+
+	return NewFileService(mocks.repo, ports.NewMockMultipartUploadRepository(ctrl), noGrants(ctrl), mocks.quotas, noJobs(ctrl), mocks.provider, nil, FileServiceConfig{
+		UploadMaxSize: testMaxSize,
+		UploadTTL:     5 * time.Minute,
+		DownloadTTL:   15 * time.Minute,
+		OwnerQuota:    domain.Quota{MaxBytes: 10 * testFileSize, MaxFiles: 10},
+	})
+}
+
+func TestFileService_GenerateUploadURL_Quota(t *testing.T) {
+	tenantOverride := &domain.Quota{Scope: domain.QuotaScopeTenant, TenantID: testTenantID, MaxFiles: 100}
+
+	tests := []struct {
+		name          string
+		setupMocks    func(quotaMocks)
+		expectedError error
+	}{
+		{
+			name: "within quota - pending file reserves the space",
+			setupMocks: func(m quotaMocks) {
+				m.quotas.EXPECT().ListOverrides(gomock.Any(), testTenantID, testOwnerID).Return(nil, nil)
+				m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, testOwnerID).
+					Return(&domain.Usage{Files: 5, Bytes: 5 * testFileSize, ReservedFiles: 4, ReservedBytes: 4 * testFileSize}, nil)
+				m.repo.EXPECT().CreateWithinQuotas(gomock.Any(), gomock.Any(), gomock.Len(1)).
+					DoAndReturn(func(ctx context.Context, file *domain.File, _ []domain.Quota) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusPending, file.Status)
+						return file, nil
+					})
+				m.provider.EXPECT().GenerateUploadURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(testUploadURL, nil)
+			},
+		},
+		{
+			name: "concurrent upload took the space - rejected at creation",
+			setupMocks: func(m quotaMocks) {
+				m.quotas.EXPECT().ListOverrides(gomock.Any(), testTenantID, testOwnerID).Return(nil, nil)
+				m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, testOwnerID).Return(&domain.Usage{Files: 9}, nil)
+				m.repo.EXPECT().CreateWithinQuotas(gomock.Any(), gomock.Any(), gomock.Len(1)).Return(nil, domain.ErrQuotaExceeded)
+			},
+			expectedError: domain.ErrQuotaExceeded,
+		},
+		{
+			name: "owner bytes exceeded - reservations count",
+			setupMocks: func(m quotaMocks) {
+				m.quotas.EXPECT().ListOverrides(gomock.Any(), testTenantID, testOwnerID).Return(nil, nil)
+				m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, testOwnerID).
+					Return(&domain.Usage{Files: 1, Bytes: 5 * testFileSize, ReservedFiles: 1, ReservedBytes: 5 * testFileSize}, nil)
+			},
+			expectedError: domain.ErrQuotaExceeded,
+		},
+		{
+			name: "owner file count exceeded",
+			setupMocks: func(m quotaMocks) {
+				m.quotas.EXPECT().ListOverrides(gomock.Any(), testTenantID, testOwnerID).Return(nil, nil)
+				m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, testOwnerID).
+					Return(&domain.Usage{Files: 10}, nil)
+			},
+			expectedError: domain.ErrQuotaExceeded,
+		},
+		{
+			name: "tenant override exceeded",
+			setupMocks: func(m quotaMocks) {
+				m.quotas.EXPECT().ListOverrides(gomock.Any(), testTenantID, testOwnerID).
+					Return([]*domain.Quota{tenantOverride}, nil)
+				m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, testOwnerID).Return(&domain.Usage{}, nil)
+				m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, "").Return(&domain.Usage{Files: 100}, nil)
+			},
+			expectedError: domain.ErrQuotaExceeded,
+		},
+		{
+			name: "owner override lifts the default",
+			setupMocks: func(m quotaMocks) {
+				m.quotas.EXPECT().ListOverrides(gomock.Any(), testTenantID, testOwnerID).
+					Return([]*domain.Quota{{Scope: domain.QuotaScopeOwner, TenantID: testTenantID, OwnerID: testOwnerID}}, nil)
+				m.repo.EXPECT().Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) { return file, nil })
+				m.provider.EXPECT().GenerateUploadURL(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(testUploadURL, nil)
+			},
+		},
+		{
+			name: "usage error",
+			setupMocks: func(m quotaMocks) {
+				m.quotas.EXPECT().ListOverrides(gomock.Any(), testTenantID, testOwnerID).Return(nil, nil)
+				m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, testOwnerID).Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newQuotaTestService(t, tt.setupMocks)
+			ctx := tenant.WithCtx(context.Background(), testTenantID)
+
+			result, err := service.GenerateUploadURL(ctx, testOwnerID, testContentType, testFileSize, domain.Checksum{})
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testUploadURL, result.UploadURL)
+		})
+	}
+}
+
+func TestFileService_UploadFile_QuotaExceededAtCreation(t *testing.T) {
+	content := []byte("%PDF-1.7 body")
+
+	service := newQuotaTestService(t, func(m quotaMocks) {
+		m.quotas.EXPECT().ListOverrides(gomock.Any(), testTenantID, testOwnerID).Return(nil, nil)
+		m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, testOwnerID).Return(&domain.Usage{Files: 9}, nil)
+		m.provider.EXPECT().PutObject(gomock.Any(), gomock.Any(), testContentType, gomock.Any(), int64(len(content))).
+			DoAndReturn(func(_ context.Context, _, _ string, body io.Reader, _ int64) error {
+				_, err := io.ReadAll(body)
+				return err
+			})
+		m.repo.EXPECT().CreateWithinQuotas(gomock.Any(), gomock.Any(), gomock.Len(1)).Return(nil, domain.ErrQuotaExceeded)
+		m.provider.EXPECT().DeleteObject(gomock.Any(), gomock.Any()).Return(nil)
+	})
+	ctx := identity.WithCtx(tenant.WithCtx(context.Background(), testTenantID), domain.Identity{UserID: testOwnerID})
+
+	file, err := service.UploadFile(ctx, testContentType, "", bytes.NewReader(content), int64(len(content)))
+
+	assert.ErrorIs(t, err, domain.ErrQuotaExceeded)
+	assert.Nil(t, file)
+}
+
+func TestFileService_UpdateFile_Quota(t *testing.T) {
+	const grown = 3 * testFileSize
+
+	tests := []struct {
+		name          string
+		usage         *domain.Usage
+		expectedError error
+	}{
+		{
+			name:  "growth within quota - stored under the lock",
+			usage: &domain.Usage{Files: 2, Bytes: 2 * testFileSize},
+		},
+		{
+			name:          "growth past quota - rejected before the content is replaced",
+			usage:         &domain.Usage{Files: 9, Bytes: 9 * testFileSize},
+			expectedError: domain.ErrQuotaExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			released := false
+			service := newQuotaTestService(t, func(m quotaMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(&domain.File{
+					ID:          testFileID,
+					TenantID:    testTenantID,
+					OwnerID:     testOwnerID,
+					S3Path:      testS3Path,
+					ContentType: testContentType,
+					Size:        testFileSize,
+					Status:      domain.FileStatusUploaded,
+				}, nil)
+				m.quotas.EXPECT().ListOverrides(gomock.Any(), testTenantID, testOwnerID).Return(nil, nil)
+				m.quotas.EXPECT().Lock(gomock.Any(), gomock.Len(1)).Return(func() { released = true }, nil)
+				m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, testOwnerID).Return(tt.usage, nil)
+				if tt.expectedError == nil {
+					m.provider.EXPECT().PutObject(gomock.Any(), testS3Path, "text/plain", gomock.Any(), grown).
+						DoAndReturn(func(context.Context, string, string, io.Reader, int64) error {
+							assert.False(t, released)
+							return nil
+						})
+					m.repo.EXPECT().Update(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+							assert.False(t, released)
+							return file, nil
+						})
+				}
+			})
+			ctx := identity.WithCtx(tenant.WithCtx(context.Background(), testTenantID), domain.Identity{UserID: testOwnerID})
+
+			file, err := service.UpdateFile(ctx, testFileID, "text/plain", "", strings.NewReader(strings.Repeat("a", int(grown))), grown)
+
+			assert.True(t, released)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, file)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, grown, file.Size)
+		})
+	}
+}
+
+func TestFileService_GetUsage(t *testing.T) {
+	service := newQuotaTestService(t, func(m quotaMocks) {
+		m.quotas.EXPECT().ListOverrides(gomock.Any(), testTenantID, testOwnerID).
+			Return([]*domain.Quota{{Scope: domain.QuotaScopeTenant, TenantID: testTenantID, MaxBytes: 1 << 30}}, nil)
+		m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, testOwnerID).
+			Return(&domain.Usage{Files: 2, Bytes: 2 * testFileSize, ReservedFiles: 1, ReservedBytes: testFileSize}, nil)
+		m.quotas.EXPECT().GetUsage(gomock.Any(), testTenantID, "").
+			Return(&domain.Usage{Files: 20, Bytes: 20 * testFileSize}, nil)
+	})
+	ctx := tenant.WithCtx(context.Background(), testTenantID)
+
+	usages, err := service.GetUsage(ctx, testOwnerID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.QuotaUsage{
+		{
+			Quota: domain.Quota{Scope: domain.QuotaScopeOwner, TenantID: testTenantID, OwnerID: testOwnerID, MaxBytes: 10 * testFileSize, MaxFiles: 10},
+			Usage: domain.Usage{Files: 2, Bytes: 2 * testFileSize, ReservedFiles: 1, ReservedBytes: testFileSize},
+		},
+		{
+			Quota: domain.Quota{Scope: domain.QuotaScopeTenant, TenantID: testTenantID, MaxBytes: 1 << 30},
+			Usage: domain.Usage{Files: 20, Bytes: 20 * testFileSize},
+		},
+	}, usages)
+
+	_, err = service.GetUsage(ctx, "")
+	assert.ErrorIs(t, err, domain.ErrInvalidInput)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+type UploadReaperConfig struct {
+	UploadTTL    time.Duration
+	ResumableTTL time.Duration
+	GracePeriod  time.Duration
+	BatchSize    int
+}
+
+// UploadReaper settles pending files whose upload window has closed: files
+// whose object did land are confirmed as if the webhook had arrived, the rest
+// are marked expired.
+type UploadReaper struct {
+	files        *FileService
+	repo         ports.FileRepository
+	uploads      ports.MultipartUploadRepository
+	fileProvider ports.FileProvider
+	cfg          UploadReaperConfig
+}
+
+func NewUploadReaper(
+	files *FileService,
+	repo ports.FileRepository,
+	uploads ports.MultipartUploadRepository,
+	fileProvider ports.FileProvider,
+	cfg UploadReaperConfig,
+) *UploadReaper {
+	return &UploadReaper{
+		files:        files,
+		repo:         repo,
+		uploads:      uploads,
+		fileProvider: fileProvider,
+		cfg:          cfg,
+	}
+}
+
+func (r *UploadReaper) ReapBatch(ctx context.Context) (*domain.ReapUploadsResult, error) {
+	ctx = tenant.Unscoped(ctx)
+	now := time.Now()
+	cutoff := domain.UploadCutoff{
+		Pending:   now.Add(-r.cfg.UploadTTL - r.cfg.GracePeriod),
+		Multipart: now.Add(-r.cfg.ResumableTTL - r.cfg.GracePeriod),
+		Resumable: now.Add(-r.cfg.GracePeriod),
+	}
+
+	files, err := r.repo.ListAbandoned(ctx, cutoff, r.cfg.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list abandoned uploads: %v", domain.ErrInternal, err)
+	}
+
+	result := &domain.ReapUploadsResult{Scanned: len(files)}
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		status, err := r.reap(ctx, file)
+		switch {
+		case err != nil:
+			log.Printf("reaper: failed to reap file %s: %v", file.ID, err)
+			result.Failed++
+		case status == "":
+			result.Skipped++
+		case status == domain.FileStatusExpired:
+			result.Expired++
+		case status == domain.FileStatusRejected, status == domain.FileStatusCorrupt:
+			result.Rejected++
+		case slices.Contains(domain.StoredStatuses, status):
+			result.Confirmed++
+		default:
+			// Settled some other way since it was listed.
+			result.Skipped++
+		}
+	}
+
+	return result, nil
+}
+
+func (r *UploadReaper) reap(ctx context.Context, file *domain.File) (domain.FileStatus, error) {
+	// The webhook may have been lost, so look at the bucket once more before
+	// giving up on the upload.
+	confirmed, err := r.files.confirmUpload(ctx, file.ID, "")
+	if err != nil {
+		return "", err
+	}
+	if confirmed == nil {
+		return "", nil
+	}
+	if confirmed.Status != domain.FileStatusPending {
+		return confirmed.Status, nil
+	}
+
+	ctx = tenant.WithCtx(ctx, file.TenantID)
+	upload, err := r.uploads.GetByFileID(ctx, file.ID)
+	switch {
+	case err == nil:
+		if err := r.fileProvider.AbortMultipartUpload(ctx, file.S3Path, upload.UploadID); err != nil {
+			return "", fmt.Errorf("failed to abort multipart upload: %w", err)
+		}
+		if err := r.uploads.Delete(ctx, file.ID); err != nil && !errors.Is(err, domain.ErrUploadNotFound) {
+			return "", fmt.Errorf("failed to delete multipart upload: %w", err)
+		}
+	case !errors.Is(err, domain.ErrUploadNotFound):
+		return "", fmt.Errorf("failed to get multipart upload: %w", err)
+	}
+
+	confirmed.MarkAsExpired()
+	if _, err := r.repo.Update(ctx, confirmed); err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to update file status: %w", err)
+	}
+
+	return domain.FileStatusExpired, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testReaperBatchSize = 10
+	testGracePeriod     = 15 * time.Minute
+)
+
+func newTestUploadReaper(t *testing.T, setupMocks func(multipartMocks)) *UploadReaper {
+	ctrl := gomock.NewController(t)
+	mocks := multipartMocks{
+		repo:     ports.NewMockFileRepository(ctrl),
+		uploads:  ports.NewMockMultipartUploadRepository(ctrl),
+		provider: ports.NewMockFileProvider(ctrl),
+	}
+	setupMocks(mocks)
+
+	files := NewFileService(mocks.repo, mocks.uploads, noGrants(ctrl), noQuotas(ctrl), noJobs(ctrl), mocks.provider, nil, FileServiceConfig{
+		UploadMaxSize: testMaxSize,
+		UploadTTL:     5 * time.Minute,
+		DownloadTTL:   15 * time.Minute,
+	})
+
+	return NewUploadReaper(files, mocks.repo, mocks.uploads, mocks.provider, UploadReaperConfig{
+		UploadTTL:    5 * time.Minute,
+		ResumableTTL: 24 * time.Hour,
+		GracePeriod:  testGracePeriod,
+		BatchSize:    testReaperBatchSize,
+	})
+}
+
+func abandonedFile() *domain.File {
+	return &domain.File{
+		ID:          testFileID,
+		OwnerID:     testOwnerID,
+		S3Path:      testS3Path,
+		Size:        testFileSize,
+		ContentType: testContentType,
+		Status:      domain.FileStatusPending,
+	}
+}
+
+func TestUploadReaper_ReapBatch(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMocks     func(multipartMocks)
+		expectedResult *domain.ReapUploadsResult
+		expectedError  error
+	}{
+		{
+			name: "object landed without webhook - confirmed",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().
+					ListAbandoned(gomock.Any(), gomock.Any(), testReaperBatchSize).
+					DoAndReturn(func(_ context.Context, cutoff domain.UploadCutoff, _ int) ([]*domain.File, error) {
+						now := time.Now()
+						assert.WithinDuration(t, now.Add(-5*time.Minute-testGracePeriod), cutoff.Pending, time.Second)
+						assert.WithinDuration(t, now.Add(-24*time.Hour-testGracePeriod), cutoff.Multipart, time.Second)
+						assert.WithinDuration(t, now.Add(-testGracePeriod), cutoff.Resumable, time.Second)
+						return []*domain.File{abandonedFile()}, nil
+					})
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(abandonedFile(), nil)
+				m.provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(&domain.ObjectInfo{Size: testFileSize, ContentType: testContentType, ETag: "etag"}, nil)
+				storedContent(m.provider)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusUploaded, file.Status)
+						return file, nil
+					})
+			},
+			expectedResult: &domain.ReapUploadsResult{Scanned: 1, Confirmed: 1},
+		},
+		{
+			name: "object does not match the declaration - rejected",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListAbandoned(gomock.Any(), gomock.Any(), testReaperBatchSize).Return([]*domain.File{abandonedFile()}, nil)
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(abandonedFile(), nil)
+				m.provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(&domain.ObjectInfo{Size: testFileSize, ContentType: "image/png", ETag: "etag"}, nil)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusRejected, file.Status)
+						return file, nil
+					})
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testS3Path).Return(nil)
+			},
+			expectedResult: &domain.ReapUploadsResult{Scanned: 1, Rejected: 1},
+		},
+		{
+			name: "nothing uploaded - expired",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListAbandoned(gomock.Any(), gomock.Any(), testReaperBatchSize).Return([]*domain.File{abandonedFile()}, nil)
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(abandonedFile(), nil)
+				m.provider.EXPECT().StatObject(gomock.Any(), testS3Path).Return(nil, domain.ErrObjectNotFound)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(nil, domain.ErrUploadNotFound)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusExpired, file.Status)
+						return file, nil
+					})
+			},
+			expectedResult: &domain.ReapUploadsResult{Scanned: 1, Expired: 1},
+		},
+		{
+			name: "abandoned multipart upload - parts aborted and expired",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListAbandoned(gomock.Any(), gomock.Any(), testReaperBatchSize).Return([]*domain.File{abandonedFile()}, nil)
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(abandonedFile(), nil)
+				m.provider.EXPECT().StatObject(gomock.Any(), testS3Path).Return(nil, domain.ErrObjectNotFound)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+				m.provider.EXPECT().AbortMultipartUpload(gomock.Any(), testS3Path, testUploadID).Return(nil)
+				m.uploads.EXPECT().Delete(gomock.Any(), testFileID).Return(nil)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusExpired, file.Status)
+						return file, nil
+					})
+			},
+			expectedResult: &domain.ReapUploadsResult{Scanned: 1, Expired: 1},
+		},
+		{
+			name: "abort failure - left pending for the next run",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListAbandoned(gomock.Any(), gomock.Any(), testReaperBatchSize).Return([]*domain.File{abandonedFile()}, nil)
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(abandonedFile(), nil)
+				m.provider.EXPECT().StatObject(gomock.Any(), testS3Path).Return(nil, domain.ErrObjectNotFound)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testMultipartUpload(), nil)
+				m.provider.EXPECT().AbortMultipartUpload(gomock.Any(), testS3Path, testUploadID).Return(errors.New("s3 error"))
+			},
+			expectedResult: &domain.ReapUploadsResult{Scanned: 1, Failed: 1},
+		},
+		{
+			name: "file deleted meanwhile - skipped",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListAbandoned(gomock.Any(), gomock.Any(), testReaperBatchSize).Return([]*domain.File{abandonedFile()}, nil)
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(nil, domain.ErrFileNotFound)
+			},
+			expectedResult: &domain.ReapUploadsResult{Scanned: 1, Skipped: 1},
+		},
+		{
+			name: "nothing to reap",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListAbandoned(gomock.Any(), gomock.Any(), testReaperBatchSize).Return(nil, nil)
+			},
+			expectedResult: &domain.ReapUploadsResult{},
+		},
+		{
+			name: "repository error",
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListAbandoned(gomock.Any(), gomock.Any(), testReaperBatchSize).Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reaper := newTestUploadReaper(t, tt.setupMocks)
+
+			result, err := reaper.ReapBatch(context.Background())
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedResult, result)
+		})
+	}
+}
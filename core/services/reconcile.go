@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+// Reconciler compares the bucket with the files table tenant by tenant and
+// owner by owner, and reports, and optionally repairs, where the two disagree.
+type Reconciler struct {
+	files        *FileService
+	repo         ports.FileRepository
+	fileProvider ports.FileProvider
+}
+
+func NewReconciler(files *FileService, repo ports.FileRepository, fileProvider ports.FileProvider) *Reconciler {
+	return &Reconciler{
+		files:        files,
+		repo:         repo,
+		fileProvider: fileProvider,
+	}
+}
+
+// bucketLayout tells the owner prefixes of the default tenant's older layout
+// from the other tenants' prefixes at the top of the bucket.
+type bucketLayout struct {
+	tenants []string
+	// legacyOwners are the owners of the default tenant, whose files may be
+	// stored under their owner ID without a tenant prefix.
+	legacyOwners []string
+}
+
+// shares reports whether the prefix of tenantID is also the prefix of the
+// older objects of a default-tenant owner with the same ID.
+func (l bucketLayout) shares(tenantID string) bool {
+	return slices.Contains(l.legacyOwners, tenantID)
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, opts domain.ReconcileOptions) (*domain.ReconcileReport, error) {
+	report := &domain.ReconcileReport{StartedAt: time.Now()}
+
+	// The layout is needed whenever owners are discovered from the bucket,
+	// since the other tenants' prefixes sit next to the older owner
+	// prefixes of the default tenant.
+	var layout bucketLayout
+	if opts.OwnerID == "" {
+		var err error
+		layout.tenants, err = r.repo.ListTenants(tenant.Unscoped(ctx))
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to list tenants: %v", domain.ErrInternal, err)
+		}
+		layout.legacyOwners, err = r.repo.ListOwners(tenant.WithCtx(ctx, domain.DefaultTenantID))
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to list owners of tenant %s: %v", domain.ErrInternal, domain.DefaultTenantID, err)
+		}
+	}
+
+	tenants := []string{opts.TenantID}
+	switch {
+	case opts.TenantID != "":
+	case opts.OwnerID != "":
+		tenants = []string{domain.DefaultTenantID}
+	default:
+		tenants = slices.Clone(layout.tenants)
+		if !slices.Contains(tenants, domain.DefaultTenantID) {
+			tenants = append(tenants, domain.DefaultTenantID)
+		}
+		slices.Sort(tenants)
+	}
+
+	// Where a tenant shares its prefix with an owner, an object the
+	// reconciler cannot place may still be live, so nothing is repaired.
+	if opts.Fixes() {
+		shared := slices.DeleteFunc(slices.Clone(tenants), func(tenantID string) bool {
+			return !layout.shares(tenantID)
+		})
+		if len(shared) > 0 {
+			return nil, fmt.Errorf("%w: tenants %s share their prefix with owners of tenant %s; refusing to fix", domain.ErrInvalidInput, strings.Join(shared, ", "), domain.DefaultTenantID)
+		}
+	}
+
+	for _, tenantID := range tenants {
+		if err := r.reconcileTenant(tenant.WithCtx(ctx, tenantID), tenantID, layout, opts, report); err != nil {
+			return nil, err
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+func (r *Reconciler) reconcileTenant(ctx context.Context, tenantID string, layout bucketLayout, opts domain.ReconcileOptions, report *domain.ReconcileReport) error {
+	owners := []string{opts.OwnerID}
+	if opts.OwnerID == "" {
+		var err error
+		owners, err = r.listOwners(ctx, tenantID, layout, opts, report)
+		if err != nil {
+			return err
+		}
+	}
+
+	report.Tenants++
+	for _, owner := range owners {
+		if err := r.reconcileOwner(ctx, tenantID, owner, layout, opts, report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listOwners merges the owners known to the database with the owner prefixes
+// found under the tenant prefix, and for the default tenant with those found
+// at the top of the bucket, where its files were stored before tenants had
+// prefixes. Objects outside any owner prefix are checked as orphans straight
+// away, unless they are the older objects of an owner named like the tenant.
+func (r *Reconciler) listOwners(ctx context.Context, tenantID string, layout bucketLayout, opts domain.ReconcileOptions, report *domain.ReconcileReport) ([]string, error) {
+	owners := slices.Clone(layout.legacyOwners)
+	if tenantID != domain.DefaultTenantID {
+		var err error
+		owners, err = r.repo.ListOwners(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to list owners of tenant %s: %v", domain.ErrInternal, tenantID, err)
+		}
+	}
+
+	prefixes := []string{domain.TenantPrefix(tenantID)}
+	if tenantID == domain.DefaultTenantID {
+		prefixes = append(prefixes, "")
+	}
+
+	for _, prefix := range prefixes {
+		entries, err := r.fileProvider.ListObjects(ctx, prefix, false)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to list bucket of tenant %s: %v", domain.ErrInternal, tenantID, err)
+		}
+
+		for _, entry := range entries {
+			owner, isPrefix := strings.CutSuffix(strings.TrimPrefix(entry.Key, prefix), "/")
+			if !isPrefix {
+				if prefix != "" && layout.shares(tenantID) {
+					// Reconciled with the owner of the same name.
+					continue
+				}
+				report.Objects++
+				r.checkOrphan(ctx, entry, tenantID, "", nil, opts, report)
+				continue
+			}
+			if prefix == "" && (owner == domain.DefaultTenantID || slices.Contains(layout.tenants, owner)) {
+				// A tenant's prefix, reconciled with that tenant.
+				continue
+			}
+			if !slices.Contains(owners, owner) {
+				owners = append(owners, owner)
+			}
+		}
+	}
+
+	slices.Sort(owners)
+	return owners, nil
+}
+
+func (r *Reconciler) reconcileOwner(ctx context.Context, tenantID, owner string, layout bucketLayout, opts domain.ReconcileOptions, report *domain.ReconcileReport) error {
+	objects, err := r.listObjects(ctx, tenantID, owner)
+	if err != nil {
+		return err
+	}
+	if layout.shares(tenantID) {
+		// The thumbnails and kept originals of the older objects of the
+		// owner named like the tenant look like an owner prefix here.
+		objects = slices.DeleteFunc(objects, func(object domain.ObjectInfo) bool {
+			return domain.IsLegacyOwnerKey(tenantID, object.Key)
+		})
+	}
+
+	files, err := r.repo.ListByOwner(ctx, owner)
+	if err != nil {
+		return fmt.Errorf("%w: failed to list files of owner %s: %v", domain.ErrInternal, owner, err)
+	}
+
+	report.Owners++
+	report.Objects += len(objects)
+	report.Files += len(files)
+
+	byPath := make(map[string]*domain.File, len(files))
+	for _, file := range files {
+		byPath[file.S3Path] = file
+	}
+
+	stored := make(map[string]bool, len(objects))
+	for _, object := range objects {
+		stored[object.Key] = true
+
+		// Thumbnails and kept originals live as long as their file.
+		if parentKey, ok := domain.ParentObjectKey(object.Key); ok {
+			if parent := byPath[parentKey]; parent == nil || isTerminal(parent.Status) {
+				r.checkOrphan(ctx, object, tenantID, owner, parent, opts, report)
+			}
+			continue
+		}
+
+		file := byPath[object.Key]
+		switch {
+		case file == nil, isTerminal(file.Status):
+			r.checkOrphan(ctx, object, tenantID, owner, file, opts, report)
+		case file.IsDeleted:
+			// Kept until the purger removes it after the retention window.
+		case file.Status == domain.FileStatusProcessing:
+			// The object may already be rewritten while the row still has
+			// the size of the upload; the strip job settles both.
+		case file.Status == domain.FileStatusPending:
+			r.record(report, opts, domain.Discrepancy{
+				Kind:         domain.DiscrepancyUnconfirmed,
+				Key:          object.Key,
+				TenantID:     tenantID,
+				OwnerID:      owner,
+				FileID:       file.ID,
+				Status:       file.Status,
+				ExpectedSize: file.Size,
+				ActualSize:   object.Size,
+			}, func() error {
+				return r.files.ConfirmUpload(ctx, file.ID, "")
+			})
+		case file.HasContent() && file.Size != object.Size:
+			r.record(report, opts, domain.Discrepancy{
+				Kind:         domain.DiscrepancySizeMismatch,
+				Key:          object.Key,
+				TenantID:     tenantID,
+				OwnerID:      owner,
+				FileID:       file.ID,
+				Status:       file.Status,
+				ExpectedSize: file.Size,
+				ActualSize:   object.Size,
+			}, func() error {
+				file.MarkAsCorrupt()
+				_, err := r.repo.Update(ctx, file)
+				return err
+			})
+		}
+	}
+
+	for _, file := range files {
+		if stored[file.S3Path] || file.IsDeleted || !file.HasContent() {
+			continue
+		}
+		r.record(report, opts, domain.Discrepancy{
+			Kind:         domain.DiscrepancyMissingObject,
+			Key:          file.S3Path,
+			TenantID:     tenantID,
+			OwnerID:      owner,
+			FileID:       file.ID,
+			Status:       file.Status,
+			ExpectedSize: file.Size,
+		}, func() error {
+			file.MarkAsMissing()
+			_, err := r.repo.Update(ctx, file)
+			return err
+		})
+	}
+
+	return nil
+}
+
+// listObjects lists the objects of an owner, including for the default tenant
+// those stored before tenants had prefixes. Only keys of the older layout are
+// taken from the top of the bucket, since a tenant named like the owner keeps
+// its own objects under the same prefix.
+func (r *Reconciler) listObjects(ctx context.Context, tenantID, owner string) ([]domain.ObjectInfo, error) {
+	objects, err := r.fileProvider.ListObjects(ctx, domain.TenantPrefix(tenantID)+owner+"/", true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list objects of owner %s: %v", domain.ErrInternal, owner, err)
+	}
+	if tenantID != domain.DefaultTenantID {
+		return objects, nil
+	}
+
+	legacy, err := r.fileProvider.ListObjects(ctx, domain.LegacyOwnerPrefix(owner), true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list objects of owner %s: %v", domain.ErrInternal, owner, err)
+	}
+	for _, object := range legacy {
+		if domain.IsLegacyOwnerKey(owner, object.Key) {
+			objects = append(objects, object)
+		}
+	}
+
+	return objects, nil
+}
+
+// checkOrphan records an object that no live row owns. file is the row the
+// key points at, if any, which has already given the object up.
+func (r *Reconciler) checkOrphan(ctx context.Context, object domain.ObjectInfo, tenantID, owner string, file *domain.File, opts domain.ReconcileOptions, report *domain.ReconcileReport) {
+	if opts.MinObjectAge > 0 && time.Since(object.LastModified) < opts.MinObjectAge {
+		return
+	}
+
+	discrepancy := domain.Discrepancy{
+		Kind:       domain.DiscrepancyOrphanObject,
+		Key:        object.Key,
+		TenantID:   tenantID,
+		OwnerID:    owner,
+		ActualSize: object.Size,
+	}
+	if file != nil {
+		discrepancy.FileID = file.ID
+		discrepancy.Status = file.Status
+	}
+
+	r.record(report, opts, discrepancy, func() error {
+		return r.fileProvider.DeleteObject(ctx, object.Key)
+	})
+}
+
+func (r *Reconciler) record(report *domain.ReconcileReport, opts domain.ReconcileOptions, discrepancy domain.Discrepancy, fix func() error) {
+	if opts.Fix[discrepancy.Kind] {
+		if err := fix(); err != nil {
+			discrepancy.FixError = err.Error()
+		} else {
+			discrepancy.Fixed = true
+		}
+	}
+
+	report.Discrepancies = append(report.Discrepancies, discrepancy)
+}
+
+// isTerminal reports whether a file in this status no longer owns an object.
+func isTerminal(status domain.FileStatus) bool {
+	switch status {
+	case domain.FileStatusExpired, domain.FileStatusRejected, domain.FileStatusPurged:
+		return true
+	}
+	return false
+}
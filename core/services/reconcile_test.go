@@ -0,0 +1,522 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testOrphanPath = "test-owner-123/orphan-file-id"
+	// testOwnerPrefix holds the owner's objects in the default tenant;
+	// testS3Path and testOrphanPath have the older layout without it.
+	testOwnerPrefix = "default/" + testOwnerID + "/"
+)
+
+func newTestReconciler(t *testing.T, setupMocks func(multipartMocks)) *Reconciler {
+	ctrl := gomock.NewController(t)
+	mocks := multipartMocks{
+		repo:     ports.NewMockFileRepository(ctrl),
+		uploads:  ports.NewMockMultipartUploadRepository(ctrl),
+		provider: ports.NewMockFileProvider(ctrl),
+	}
+	setupMocks(mocks)
+
+	files := NewFileService(mocks.repo, mocks.uploads, noGrants(ctrl), noQuotas(ctrl), noJobs(ctrl), mocks.provider, nil, FileServiceConfig{
+		UploadMaxSize: testMaxSize,
+		UploadTTL:     5 * time.Minute,
+		DownloadTTL:   15 * time.Minute,
+	})
+
+	return NewReconciler(files, mocks.repo, mocks.provider)
+}
+
+func storedFile(status domain.FileStatus) *domain.File {
+	return &domain.File{
+		ID:          testFileID,
+		TenantID:    domain.DefaultTenantID,
+		OwnerID:     testOwnerID,
+		S3Path:      testS3Path,
+		Size:        testFileSize,
+		ContentType: testContentType,
+		Status:      status,
+	}
+}
+
+func storedObjectInfo(key string, size int64) domain.ObjectInfo {
+	return domain.ObjectInfo{
+		Key:          key,
+		Size:         size,
+		ContentType:  testContentType,
+		ETag:         "etag",
+		LastModified: time.Now().Add(-2 * time.Hour),
+	}
+}
+
+func TestReconciler_Reconcile(t *testing.T) {
+	fixAll := map[domain.DiscrepancyKind]bool{
+		domain.DiscrepancyOrphanObject:  true,
+		domain.DiscrepancyUnconfirmed:   true,
+		domain.DiscrepancyMissingObject: true,
+		domain.DiscrepancySizeMismatch:  true,
+	}
+
+	tests := []struct {
+		name                  string
+		opts                  domain.ReconcileOptions
+		setupMocks            func(multipartMocks)
+		expectedDiscrepancies []domain.Discrepancy
+		expectedError         error
+	}{
+		{
+			name: "bucket and table agree",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(testS3Path, testFileSize)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).
+					Return([]*domain.File{storedFile(domain.FileStatusUploaded)}, nil)
+			},
+		},
+		{
+			name: "report only - nothing is changed",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, MinObjectAge: time.Hour},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{
+						storedObjectInfo(testS3Path, testFileSize),
+						storedObjectInfo(testOrphanPath, 10),
+					}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).
+					Return([]*domain.File{storedFile(domain.FileStatusPending)}, nil)
+			},
+			expectedDiscrepancies: []domain.Discrepancy{
+				{
+					Kind:         domain.DiscrepancyUnconfirmed,
+					Key:          testS3Path,
+					TenantID:     domain.DefaultTenantID,
+					OwnerID:      testOwnerID,
+					FileID:       testFileID,
+					Status:       domain.FileStatusPending,
+					ExpectedSize: testFileSize,
+					ActualSize:   testFileSize,
+				},
+				{
+					Kind:       domain.DiscrepancyOrphanObject,
+					Key:        testOrphanPath,
+					TenantID:   domain.DefaultTenantID,
+					OwnerID:    testOwnerID,
+					ActualSize: 10,
+				},
+			},
+		},
+		{
+			name: "unconfirmed upload - confirmed",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(testS3Path, testFileSize)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).
+					Return([]*domain.File{storedFile(domain.FileStatusPending)}, nil)
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(storedFile(domain.FileStatusPending), nil)
+				m.provider.EXPECT().StatObject(gomock.Any(), testS3Path).
+					Return(&domain.ObjectInfo{Size: testFileSize, ContentType: testContentType, ETag: "etag"}, nil)
+				storedContent(m.provider)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusUploaded, file.Status)
+						return file, nil
+					})
+			},
+			expectedDiscrepancies: []domain.Discrepancy{
+				{
+					Kind:         domain.DiscrepancyUnconfirmed,
+					Key:          testS3Path,
+					TenantID:     domain.DefaultTenantID,
+					OwnerID:      testOwnerID,
+					FileID:       testFileID,
+					Status:       domain.FileStatusPending,
+					ExpectedSize: testFileSize,
+					ActualSize:   testFileSize,
+					Fixed:        true,
+				},
+			},
+		},
+		{
+			name: "uploaded file without object - marked missing",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).Return(nil, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).
+					Return([]*domain.File{storedFile(domain.FileStatusUploaded)}, nil)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusMissing, file.Status)
+						return file, nil
+					})
+			},
+			expectedDiscrepancies: []domain.Discrepancy{
+				{
+					Kind:         domain.DiscrepancyMissingObject,
+					Key:          testS3Path,
+					TenantID:     domain.DefaultTenantID,
+					OwnerID:      testOwnerID,
+					FileID:       testFileID,
+					Status:       domain.FileStatusUploaded,
+					ExpectedSize: testFileSize,
+					Fixed:        true,
+				},
+			},
+		},
+		{
+			name: "stored size differs - marked corrupt",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(testS3Path, testFileSize-1)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).
+					Return([]*domain.File{storedFile(domain.FileStatusUploaded)}, nil)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusCorrupt, file.Status)
+						return file, nil
+					})
+			},
+			expectedDiscrepancies: []domain.Discrepancy{
+				{
+					Kind:         domain.DiscrepancySizeMismatch,
+					Key:          testS3Path,
+					TenantID:     domain.DefaultTenantID,
+					OwnerID:      testOwnerID,
+					FileID:       testFileID,
+					Status:       domain.FileStatusUploaded,
+					ExpectedSize: testFileSize,
+					ActualSize:   testFileSize - 1,
+					Fixed:        true,
+				},
+			},
+		},
+		{
+			name: "object of expired file - orphan deleted",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(testS3Path, testFileSize)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).
+					Return([]*domain.File{storedFile(domain.FileStatusExpired)}, nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testS3Path).Return(nil)
+			},
+			expectedDiscrepancies: []domain.Discrepancy{
+				{
+					Kind:       domain.DiscrepancyOrphanObject,
+					Key:        testS3Path,
+					TenantID:   domain.DefaultTenantID,
+					OwnerID:    testOwnerID,
+					FileID:     testFileID,
+					Status:     domain.FileStatusExpired,
+					ActualSize: testFileSize,
+					Fixed:      true,
+				},
+			},
+		},
+		{
+			name: "thumbnail of a live file - left alone",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{
+						storedObjectInfo(testS3Path, testFileSize),
+						storedObjectInfo(domain.ThumbnailKey(testS3Path, 128), 10),
+					}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).
+					Return([]*domain.File{storedFile(domain.FileStatusUploaded)}, nil)
+			},
+		},
+		{
+			name: "kept original of a live file - left alone",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{
+						storedObjectInfo(testS3Path, testFileSize),
+						storedObjectInfo(domain.OriginalKey(testS3Path), testFileSize+10),
+					}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).
+					Return([]*domain.File{storedFile(domain.FileStatusUploaded)}, nil)
+			},
+		},
+		{
+			name: "rewritten while processing - size left for the strip job",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(testS3Path, testFileSize-10)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).
+					Return([]*domain.File{storedFile(domain.FileStatusProcessing)}, nil)
+			},
+		},
+		{
+			name: "thumbnail without a file - orphan deleted",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(domain.ThumbnailKey(testOrphanPath, 128), 10)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).Return(nil, nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), domain.ThumbnailKey(testOrphanPath, 128)).Return(nil)
+			},
+			expectedDiscrepancies: []domain.Discrepancy{
+				{
+					Kind:       domain.DiscrepancyOrphanObject,
+					Key:        domain.ThumbnailKey(testOrphanPath, 128),
+					TenantID:   domain.DefaultTenantID,
+					OwnerID:    testOwnerID,
+					ActualSize: 10,
+					Fixed:      true,
+				},
+			},
+		},
+		{
+			name: "recent orphan - left for an upload in flight",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll, MinObjectAge: 3 * time.Hour},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(testOrphanPath, 10)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).Return(nil, nil)
+			},
+		},
+		{
+			name: "soft-deleted file - left for the purger",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				file := storedFile(domain.FileStatusUploaded)
+				file.MarkAsDeleted()
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(testS3Path, testFileSize-1)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).Return([]*domain.File{file}, nil)
+			},
+		},
+		{
+			name: "fix error - recorded in the report",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(testOrphanPath, 10)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).Return(nil, nil)
+				m.provider.EXPECT().DeleteObject(gomock.Any(), testOrphanPath).Return(errors.New("s3 error"))
+			},
+			expectedDiscrepancies: []domain.Discrepancy{
+				{
+					Kind:       domain.DiscrepancyOrphanObject,
+					Key:        testOrphanPath,
+					TenantID:   domain.DefaultTenantID,
+					OwnerID:    testOwnerID,
+					ActualSize: 10,
+					FixError:   "s3 error",
+				},
+			},
+		},
+		{
+			name: "new and older layout - both listed for the owner",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID, Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				file := storedFile(domain.FileStatusUploaded)
+				file.S3Path = testOwnerPrefix + testFileID
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).
+					Return([]domain.ObjectInfo{storedObjectInfo(file.S3Path, testFileSize)}, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{
+						storedObjectInfo(testS3Path, testFileSize),
+						// A tenant named like the owner keeps its objects
+						// under the same prefix.
+						storedObjectInfo(testOwnerID+"/tenant-owner/tenant-file", 10),
+					}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).
+					Return([]*domain.File{file, storedFile(domain.FileStatusUploaded)}, nil)
+			},
+		},
+		{
+			name: "whole bucket - owners from table and bucket merged",
+			opts: domain.ReconcileOptions{},
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListTenants(gomock.Any()).Return(nil, nil)
+				m.repo.EXPECT().ListOwners(gomock.Any()).Return([]string{testOwnerID}, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), "default/", false).
+					Return([]domain.ObjectInfo{{Key: "default/new-owner/"}}, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), "", false).
+					Return([]domain.ObjectInfo{
+						{Key: "default/"},
+						{Key: "other-owner/"},
+						storedObjectInfo("stray", 5),
+					}, nil)
+				for _, owner := range []string{"new-owner", "other-owner", testOwnerID} {
+					m.provider.EXPECT().ListObjects(gomock.Any(), "default/"+owner+"/", true).Return(nil, nil)
+					m.provider.EXPECT().ListObjects(gomock.Any(), owner+"/", true).Return(nil, nil)
+					m.repo.EXPECT().ListByOwner(gomock.Any(), owner).Return(nil, nil)
+				}
+			},
+			expectedDiscrepancies: []domain.Discrepancy{
+				{
+					Kind:       domain.DiscrepancyOrphanObject,
+					Key:        "stray",
+					TenantID:   domain.DefaultTenantID,
+					ActualSize: 5,
+				},
+			},
+		},
+		{
+			name: "all tenants - other tenant prefixes left out of the default tenant",
+			opts: domain.ReconcileOptions{},
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListTenants(gomock.Any()).Return([]string{testTenantID}, nil)
+				m.repo.EXPECT().ListOwners(gomock.Any()).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), "default/", false).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), "", false).
+					Return([]domain.ObjectInfo{{Key: testTenantID + "/"}}, nil)
+
+				m.repo.EXPECT().ListOwners(gomock.Any()).Return([]string{testOwnerID}, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testTenantID+"/", false).
+					Return([]domain.ObjectInfo{{Key: testTenantID + "/" + testOwnerID + "/"}}, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testTenantID+"/"+testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(testTenantID+"/"+testOrphanPath, 10)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).Return(nil, nil)
+			},
+			expectedDiscrepancies: []domain.Discrepancy{
+				{
+					Kind:       domain.DiscrepancyOrphanObject,
+					Key:        testTenantID + "/" + testOrphanPath,
+					TenantID:   testTenantID,
+					OwnerID:    testOwnerID,
+					ActualSize: 10,
+				},
+			},
+		},
+		{
+			name: "tenant named like a default-tenant owner - older objects left to the owner",
+			opts: domain.ReconcileOptions{},
+			setupMocks: func(m multipartMocks) {
+				legacyPath := testTenantID + "/legacy-file-id"
+				legacyFile := storedFile(domain.FileStatusUploaded)
+				legacyFile.OwnerID = testTenantID
+				legacyFile.S3Path = legacyPath
+				tenantPath := testTenantID + "/" + testS3Path
+				tenantFile := storedFile(domain.FileStatusUploaded)
+				tenantFile.TenantID = testTenantID
+				tenantFile.S3Path = tenantPath
+
+				m.repo.EXPECT().ListTenants(gomock.Any()).Return([]string{testTenantID}, nil)
+				m.repo.EXPECT().ListOwners(gomock.Any()).Return([]string{testTenantID}, nil)
+
+				m.provider.EXPECT().ListObjects(gomock.Any(), "default/", false).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), "", false).
+					Return([]domain.ObjectInfo{{Key: testTenantID + "/"}}, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), "default/"+testTenantID+"/", true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testTenantID+"/", true).
+					Return([]domain.ObjectInfo{
+						storedObjectInfo(legacyPath, testFileSize),
+						storedObjectInfo(domain.ThumbnailKey(legacyPath, 128), 10),
+						storedObjectInfo(tenantPath, testFileSize),
+					}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testTenantID).Return([]*domain.File{legacyFile}, nil)
+
+				m.repo.EXPECT().ListOwners(gomock.Any()).Return([]string{testOwnerID}, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testTenantID+"/", false).
+					Return([]domain.ObjectInfo{
+						storedObjectInfo(legacyPath, testFileSize),
+						{Key: legacyPath + ".thumbnails/"},
+						{Key: testTenantID + "/" + testOwnerID + "/"},
+					}, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), legacyPath+".thumbnails/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(domain.ThumbnailKey(legacyPath, 128), 10)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), "legacy-file-id.thumbnails").Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testTenantID+"/"+testOwnerID+"/", true).
+					Return([]domain.ObjectInfo{storedObjectInfo(tenantPath, testFileSize)}, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).Return([]*domain.File{tenantFile}, nil)
+			},
+		},
+		{
+			name: "fix with a tenant named like a default-tenant owner - refused",
+			opts: domain.ReconcileOptions{Fix: fixAll},
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListTenants(gomock.Any()).Return([]string{testTenantID}, nil)
+				m.repo.EXPECT().ListOwners(gomock.Any()).Return([]string{testOwnerID, testTenantID}, nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name: "single tenant and owner - listed under the tenant prefix",
+			opts: domain.ReconcileOptions{TenantID: testTenantID, OwnerID: testOwnerID},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testTenantID+"/"+testOwnerID+"/", true).Return(nil, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).Return(nil, nil)
+			},
+		},
+		{
+			name: "list tenants error",
+			opts: domain.ReconcileOptions{},
+			setupMocks: func(m multipartMocks) {
+				m.repo.EXPECT().ListTenants(gomock.Any()).Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+		{
+			name: "list objects error",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).Return(nil, errors.New("s3 error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+		{
+			name: "repository error",
+			opts: domain.ReconcileOptions{OwnerID: testOwnerID},
+			setupMocks: func(m multipartMocks) {
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerPrefix, true).Return(nil, nil)
+				m.provider.EXPECT().ListObjects(gomock.Any(), testOwnerID+"/", true).Return(nil, nil)
+				m.repo.EXPECT().ListByOwner(gomock.Any(), testOwnerID).Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reconciler := newTestReconciler(t, tt.setupMocks)
+
+			report, err := reconciler.Reconcile(context.Background(), tt.opts)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, report)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedDiscrepancies, report.Discrepancies)
+			assert.False(t, report.FinishedAt.Before(report.StartedAt))
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+)
+
+type ResumableUploadService struct {
+	files        *FileService
+	uploads      ports.MultipartUploadRepository
+	resumable    ports.ResumableUploadRepository
+	fileProvider ports.FileProvider
+	ttl          time.Duration
+}
+
+func NewResumableUploadService(
+	files *FileService,
+	uploads ports.MultipartUploadRepository,
+	resumable ports.ResumableUploadRepository,
+	fileProvider ports.FileProvider,
+	ttl time.Duration,
+) *ResumableUploadService {
+	return &ResumableUploadService{
+		files:        files,
+		uploads:      uploads,
+		resumable:    resumable,
+		fileProvider: fileProvider,
+		ttl:          ttl,
+	}
+}
+
+func (s *ResumableUploadService) CreateUpload(ctx context.Context, ownerID, contentType string, length int64, metadata string) (*domain.ResumableUpload, error) {
+	// The tail of a chunk that does not fill a part is stored with the upload
+	// state until later chunks complete the part, so resumable uploads use
+	// the smallest part size S3 accepts.
+	result, err := s.files.initiateMultipartUpload(ctx, ownerID, contentType, length, domain.MultipartMinPartSize)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	upload, err := s.resumable.Create(ctx, &domain.ResumableUpload{
+		FileID:    result.FileID,
+		Length:    length,
+		Metadata:  metadata,
+		ExpiresAt: now.Add(s.ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		if abortErr := s.files.AbortMultipartUpload(ctx, ownerID, result.FileID); abortErr != nil {
+			log.Printf("failed to abort resumable upload for file %s: %v", result.FileID, abortErr)
+		}
+		return nil, fmt.Errorf("%w: failed to save resumable upload: %v", domain.ErrInternal, err)
+	}
+
+	return upload, nil
+}
+
+func (s *ResumableUploadService) GetUpload(ctx context.Context, ownerID, fileID string) (*domain.ResumableUpload, error) {
+	file, err := s.files.getOwnedFile(ctx, ownerID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	upload, err := s.getUpload(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	return upload, nil
+}
+
+func (s *ResumableUploadService) WriteChunk(ctx context.Context, ownerID, fileID string, offset int64, content io.Reader, checksum *domain.Checksum) (*domain.ResumableUpload, error) {
+	file, err := s.files.getOwnedFile(ctx, ownerID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parts are uploaded before the offset is moved, so two requests at the
+	// same offset would overwrite each other's parts in the bucket.
+	release, err := s.resumable.Lock(ctx, file.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUploadConflict) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: failed to lock resumable upload: %v", domain.ErrInternal, err)
+	}
+	defer release()
+
+	upload, err := s.getUpload(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		return nil, domain.ErrUploadConflict
+	}
+	if file.HasContent() {
+		return upload, nil
+	}
+
+	multipart, err := s.uploads.GetByFileID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get multipart upload: %v", domain.ErrInternal, err)
+	}
+
+	var hasher hash.Hash
+	if checksum != nil {
+		hasher, err = checksum.Algorithm.New()
+		if err != nil {
+			return nil, err
+		}
+		content = io.TeeReader(content, hasher)
+	}
+	body := io.LimitReader(content, upload.Length-upload.Offset+1)
+
+	buffer := make([]byte, multipart.PartSize)
+	filled := copy(buffer, upload.Buffer)
+	partNumber := len(upload.Parts) + 1
+
+	var parts []domain.UploadPart
+	var received int64
+	var readErr error
+	for partNumber <= multipart.PartCount && readErr == nil {
+		target := int(min(multipart.PartSize, upload.Length-int64(partNumber-1)*multipart.PartSize))
+
+		var n int
+		n, readErr = io.ReadFull(body, buffer[filled:target])
+		filled += n
+		received += int64(n)
+		if filled < target {
+			break
+		}
+
+		etag, err := s.fileProvider.UploadPart(ctx, file.S3Path, multipart.UploadID, partNumber, bytes.NewReader(buffer[:target]), int64(target))
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to upload part: %v", domain.ErrInternal, err)
+		}
+		parts = append(parts, domain.UploadPart{PartNumber: partNumber, ETag: etag})
+		partNumber++
+		filled = 0
+	}
+
+	if partNumber > multipart.PartCount && readErr == nil {
+		if n, _ := io.ReadFull(body, make([]byte, 1)); n > 0 {
+			return nil, fmt.Errorf("%w: chunk exceeds upload length", domain.ErrInvalidInput)
+		}
+	}
+
+	interrupted := readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF)
+	if checksum != nil {
+		if interrupted {
+			return nil, fmt.Errorf("%w: failed to read chunk: %v", domain.ErrInvalidInput, readErr)
+		}
+		if !checksum.Matches(hasher.Sum(nil)) {
+			return nil, domain.ErrChecksumMismatch
+		}
+	}
+
+	if received > 0 {
+		progressed := *upload
+		progressed.Offset += received
+		progressed.Buffer = buffer[:filled]
+		progressed.Parts = append(upload.Parts, parts...)
+		progressed.ExpiresAt = time.Now().Add(s.ttl)
+
+		if err := s.resumable.Advance(ctx, &progressed, upload.Offset, parts); err != nil {
+			if errors.Is(err, domain.ErrUploadConflict) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: failed to save upload progress: %v", domain.ErrInternal, err)
+		}
+		upload = &progressed
+	}
+
+	if interrupted {
+		return upload, fmt.Errorf("%w: upload interrupted at offset %d: %v", domain.ErrInvalidInput, upload.Offset, readErr)
+	}
+
+	if upload.IsComplete() {
+		if err := s.files.finishMultipartUpload(ctx, file, multipart, upload.Parts); err != nil {
+			return nil, err
+		}
+	}
+
+	return upload, nil
+}
+
+func (s *ResumableUploadService) TerminateUpload(ctx context.Context, ownerID, fileID string) error {
+	return s.files.AbortMultipartUpload(ctx, ownerID, fileID)
+}
+
+func (s *ResumableUploadService) getUpload(ctx context.Context, file *domain.File) (*domain.ResumableUpload, error) {
+	upload, err := s.resumable.GetByFileID(ctx, file.ID)
+	if err != nil {
+		if !errors.Is(err, domain.ErrUploadNotFound) {
+			return nil, fmt.Errorf("%w: failed to get resumable upload: %v", domain.ErrInternal, err)
+		}
+		if !file.HasContent() {
+			return nil, fmt.Errorf("%w: no resumable upload in progress", domain.ErrFileNotFound)
+		}
+		// The upload state is dropped once the object is assembled, so a
+		// finished upload is reported from the file itself.
+		return &domain.ResumableUpload{
+			FileID:    file.ID,
+			Length:    file.Size,
+			Offset:    file.Size,
+			CreatedAt: file.CreatedAt,
+		}, nil
+	}
+
+	if upload.IsExpired(time.Now()) {
+		return nil, domain.ErrUploadExpired
+	}
+
+	return upload, nil
+}
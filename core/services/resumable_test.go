@@ -0,0 +1,304 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type resumableMocks struct {
+	repo      *ports.MockFileRepository
+	uploads   *ports.MockMultipartUploadRepository
+	resumable *ports.MockResumableUploadRepository
+	provider  *ports.MockFileProvider
+}
+
+func newResumableTestService(t *testing.T, setupMocks func(resumableMocks)) *ResumableUploadService {
+	ctrl := gomock.NewController(t)
+	mocks := resumableMocks{
+		repo:      ports.NewMockFileRepository(ctrl),
+		uploads:   ports.NewMockMultipartUploadRepository(ctrl),
+		resumable: ports.NewMockResumableUploadRepository(ctrl),
+		provider:  ports.NewMockFileProvider(ctrl),
+	}
+	setupMocks(mocks)
+
+	files := NewFileService(mocks.repo, mocks.uploads, noGrants(ctrl), noQuotas(ctrl), noJobs(ctrl), mocks.provider, nil, FileServiceConfig{
+		UploadMaxSize:    testMaxSize,
+		MultipartMaxSize: testMaxSize,
+		PartSize:         testPartSize,
+		UploadTTL:        5 * time.Minute,
+		DownloadTTL:      15 * time.Minute,
+	})
+	return NewResumableUploadService(files, mocks.uploads, mocks.resumable, mocks.provider, time.Hour)
+}
+
+// The fixtures use a ten byte upload split into four byte parts so chunk
+// boundaries are easy to follow.
+func pendingResumableFile() *domain.File {
+	file := pendingMultipartFile()
+	file.Size = 10
+	return file
+}
+
+func testResumableMultipart() *domain.MultipartUpload {
+	return &domain.MultipartUpload{
+		FileID:    testFileID,
+		UploadID:  testUploadID,
+		PartSize:  4,
+		PartCount: 3,
+	}
+}
+
+func testResumableUpload(offset int64, buffer string, parts int) *domain.ResumableUpload {
+	upload := &domain.ResumableUpload{
+		FileID:    testFileID,
+		Length:    10,
+		Offset:    offset,
+		Buffer:    []byte(buffer),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	for i := 1; i <= parts; i++ {
+		upload.Parts = append(upload.Parts, domain.UploadPart{PartNumber: i, ETag: "etag"})
+	}
+	return upload
+}
+
+func TestResumableUploadService_WriteChunk(t *testing.T) {
+	tests := []struct {
+		name           string
+		offset         int64
+		content        string
+		checksum       func() *domain.Checksum
+		setupMocks     func(resumableMocks)
+		expectedError  error
+		expectedOffset int64
+	}{
+		{
+			name:    "chunk smaller than a part is buffered",
+			offset:  0,
+			content: "abc",
+			setupMocks: func(m resumableMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingResumableFile(), nil)
+				m.resumable.EXPECT().Lock(gomock.Any(), testFileID).Return(func() {}, nil)
+				m.resumable.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableUpload(0, "", 0), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableMultipart(), nil)
+				m.resumable.EXPECT().
+					Advance(gomock.Any(), gomock.Any(), int64(0), gomock.Len(0)).
+					DoAndReturn(func(_ context.Context, upload *domain.ResumableUpload, _ int64, _ []domain.UploadPart) error {
+						assert.Equal(t, int64(3), upload.Offset)
+						assert.Equal(t, []byte("abc"), upload.Buffer)
+						return nil
+					})
+			},
+			expectedOffset: 3,
+		},
+		{
+			name:    "buffered bytes and chunk fill a part",
+			offset:  3,
+			content: "defg",
+			setupMocks: func(m resumableMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingResumableFile(), nil)
+				m.resumable.EXPECT().Lock(gomock.Any(), testFileID).Return(func() {}, nil)
+				m.resumable.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableUpload(3, "abc", 0), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableMultipart(), nil)
+				m.provider.EXPECT().
+					UploadPart(gomock.Any(), testS3Path, testUploadID, 1, gomock.Any(), int64(4)).
+					DoAndReturn(func(_ context.Context, _, _ string, _ int, content io.Reader, _ int64) (string, error) {
+						data, err := io.ReadAll(content)
+						require.NoError(t, err)
+						assert.Equal(t, "abcd", string(data))
+						return "etag-1", nil
+					})
+				m.resumable.EXPECT().
+					Advance(gomock.Any(), gomock.Any(), int64(3), []domain.UploadPart{{PartNumber: 1, ETag: "etag-1"}}).
+					DoAndReturn(func(_ context.Context, upload *domain.ResumableUpload, _ int64, _ []domain.UploadPart) error {
+						assert.Equal(t, []byte("efg"), upload.Buffer)
+						return nil
+					})
+			},
+			expectedOffset: 7,
+		},
+		{
+			name:    "final chunk completes the upload",
+			offset:  8,
+			content: "ij",
+			setupMocks: func(m resumableMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingResumableFile(), nil).Times(2)
+				m.resumable.EXPECT().Lock(gomock.Any(), testFileID).Return(func() {}, nil)
+				m.resumable.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableUpload(8, "", 2), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableMultipart(), nil)
+				m.provider.EXPECT().
+					UploadPart(gomock.Any(), testS3Path, testUploadID, 3, gomock.Any(), int64(2)).
+					Return("etag-3", nil)
+				m.resumable.EXPECT().Advance(gomock.Any(), gomock.Any(), int64(8), gomock.Len(1)).Return(nil)
+				m.provider.EXPECT().
+					CompleteMultipartUpload(gomock.Any(), testS3Path, testUploadID, gomock.Len(3)).
+					Return(nil)
+				m.uploads.EXPECT().Delete(gomock.Any(), testFileID).Return(nil)
+				m.provider.EXPECT().
+					StatObject(gomock.Any(), testS3Path).
+					Return(&domain.ObjectInfo{Size: 10, ContentType: testContentType, ETag: "etag"}, nil)
+				storedContent(m.provider)
+				m.repo.EXPECT().
+					Update(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+						assert.Equal(t, domain.FileStatusUploaded, file.Status)
+						return file, nil
+					})
+			},
+			expectedOffset: 10,
+		},
+		{
+			name:    "offset mismatch",
+			offset:  2,
+			content: "cd",
+			setupMocks: func(m resumableMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingResumableFile(), nil)
+				m.resumable.EXPECT().Lock(gomock.Any(), testFileID).Return(func() {}, nil)
+				m.resumable.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableUpload(3, "abc", 0), nil)
+			},
+			expectedError: domain.ErrUploadConflict,
+		},
+		{
+			name:    "chunk longer than the upload",
+			offset:  8,
+			content: "ijk",
+			setupMocks: func(m resumableMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingResumableFile(), nil)
+				m.resumable.EXPECT().Lock(gomock.Any(), testFileID).Return(func() {}, nil)
+				m.resumable.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableUpload(8, "", 2), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableMultipart(), nil)
+				m.provider.EXPECT().
+					UploadPart(gomock.Any(), testS3Path, testUploadID, 3, gomock.Any(), int64(2)).
+					Return("etag-3", nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:    "checksum mismatch discards the chunk",
+			offset:  0,
+			content: "abc",
+			checksum: func() *domain.Checksum {
+				sum := sha1.Sum([]byte("xyz"))
+				return &domain.Checksum{Algorithm: domain.ChecksumSHA1, Value: sum[:]}
+			},
+			setupMocks: func(m resumableMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingResumableFile(), nil)
+				m.resumable.EXPECT().Lock(gomock.Any(), testFileID).Return(func() {}, nil)
+				m.resumable.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableUpload(0, "", 0), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableMultipart(), nil)
+			},
+			expectedError: domain.ErrChecksumMismatch,
+		},
+		{
+			name:    "matching checksum",
+			offset:  0,
+			content: "abc",
+			checksum: func() *domain.Checksum {
+				sum := sha1.Sum([]byte("abc"))
+				return &domain.Checksum{Algorithm: domain.ChecksumSHA1, Value: sum[:]}
+			},
+			setupMocks: func(m resumableMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingResumableFile(), nil)
+				m.resumable.EXPECT().Lock(gomock.Any(), testFileID).Return(func() {}, nil)
+				m.resumable.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableUpload(0, "", 0), nil)
+				m.uploads.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(testResumableMultipart(), nil)
+				m.resumable.EXPECT().Advance(gomock.Any(), gomock.Any(), int64(0), gomock.Len(0)).Return(nil)
+			},
+			expectedOffset: 3,
+		},
+		{
+			name:    "expired upload",
+			offset:  0,
+			content: "abc",
+			setupMocks: func(m resumableMocks) {
+				upload := testResumableUpload(0, "", 0)
+				upload.ExpiresAt = time.Now().Add(-time.Minute)
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingResumableFile(), nil)
+				m.resumable.EXPECT().Lock(gomock.Any(), testFileID).Return(func() {}, nil)
+				m.resumable.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(upload, nil)
+			},
+			expectedError: domain.ErrUploadExpired,
+		},
+		{
+			name:    "another request writing - conflict",
+			offset:  0,
+			content: "abc",
+			setupMocks: func(m resumableMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingResumableFile(), nil)
+				m.resumable.EXPECT().Lock(gomock.Any(), testFileID).Return(nil, domain.ErrUploadConflict)
+			},
+			expectedError: domain.ErrUploadConflict,
+		},
+		{
+			name:    "not the owner",
+			offset:  0,
+			content: "abc",
+			setupMocks: func(m resumableMocks) {
+				file := pendingResumableFile()
+				file.OwnerID = "someone-else"
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(file, nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newResumableTestService(t, tt.setupMocks)
+
+			var checksum *domain.Checksum
+			if tt.checksum != nil {
+				checksum = tt.checksum()
+			}
+
+			upload, err := service.WriteChunk(context.Background(), testOwnerID, testFileID, tt.offset, bytes.NewReader([]byte(tt.content)), checksum)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOffset, upload.Offset)
+		})
+	}
+}
+
+func TestResumableUploadService_GetUpload(t *testing.T) {
+	t.Run("finished upload is reported from the file", func(t *testing.T) {
+		service := newResumableTestService(t, func(m resumableMocks) {
+			file := pendingResumableFile()
+			file.Status = domain.FileStatusUploaded
+			m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(file, nil)
+			m.resumable.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(nil, domain.ErrUploadNotFound)
+		})
+
+		upload, err := service.GetUpload(context.Background(), testOwnerID, testFileID)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), upload.Offset)
+		assert.Equal(t, int64(10), upload.Length)
+	})
+
+	t.Run("pending file without upload state", func(t *testing.T) {
+		service := newResumableTestService(t, func(m resumableMocks) {
+			m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(pendingResumableFile(), nil)
+			m.resumable.EXPECT().GetByFileID(gomock.Any(), testFileID).Return(nil, domain.ErrUploadNotFound)
+		})
+
+		_, err := service.GetUpload(context.Background(), testOwnerID, testFileID)
+
+		assert.ErrorIs(t, err, domain.ErrFileNotFound)
+	})
+}
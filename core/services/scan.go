@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+// ScanFile runs the antivirus scan of a file waiting in the scanning status,
+// or of an uploaded file stored before scanning was enabled. Files in any
+// other status are left as they are.
+func (s *FileService) ScanFile(ctx context.Context, fileID string) error {
+	if fileID == "" {
+		return fmt.Errorf("%w: file ID is required", domain.ErrFileIDRequired)
+	}
+
+	file, err := s.repo.GetByID(ctx, fileID)
+	if err != nil {
+		if err == domain.ErrFileNotFound {
+			return err
+		}
+		return fmt.Errorf("%w: failed to get file: %v", domain.ErrInternal, err)
+	}
+
+	if s.scanner == nil || file.Status != domain.FileStatusScanning && file.Status != domain.FileStatusUploaded {
+		return nil
+	}
+
+	_, err = s.scanFile(tenant.WithCtx(ctx, file.TenantID), file)
+	return err
+}
+
+// markStored moves a file whose content has been stored and verified on to
+// metadata stripping when the policy applies to it, and to markProcessed
+// otherwise.
+func (s *FileService) markStored(file *domain.File) {
+	if s.metadataPolicy.Applies(file) {
+		file.MarkAsProcessing()
+		return
+	}
+	s.markProcessed(file)
+}
+
+// markProcessed moves a file whose content is final on to the antivirus
+// scan, or straight to uploaded when scanning is disabled.
+func (s *FileService) markProcessed(file *domain.File) {
+	if s.scanner == nil {
+		file.MarkAsUploaded()
+		return
+	}
+	file.MarkAsScanning()
+}
+
+// scanFile streams the content of the file to the scanner and records the
+// verdict. On failure the file stays in the scanning status, so that the
+// job can run the scan again.
+func (s *FileService) scanFile(ctx context.Context, file *domain.File) (*domain.File, error) {
+	content, err := s.fileProvider.OpenObject(ctx, file.S3Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open file content: %v", domain.ErrInternal, err)
+	}
+	defer content.Close()
+
+	result, err := s.scanner.Scan(ctx, content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to scan file content: %v", domain.ErrInternal, err)
+	}
+	switch {
+	case result.Infected:
+		log.Printf("quarantining file %s: %s found", file.ID, result.Signature)
+	case result.Refused != "":
+		log.Printf("rejecting file %s: scanner refused it: %s", file.ID, result.Refused)
+	}
+
+	file.MarkAsScanned(*result)
+	updated, err := s.repo.Update(ctx, file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to update file status: %v", domain.ErrInternal, err)
+	}
+
+	// The verdict is recorded, so a failure here must not run the scan again.
+	if err := s.queueProcessing(ctx, updated); err != nil {
+		log.Printf("failed to queue processing of file %s: %v", updated.ID, err)
+	}
+
+	return updated, nil
+}
+
+// QueueUnscanned queues scan jobs for up to limit files stored before
+// scanning was enabled. Until scanned they stay uploaded, which no longer
+// makes their content available. It reports how many scans it queued.
+func (s *FileService) QueueUnscanned(ctx context.Context, limit int) (int, error) {
+	if s.scanner == nil {
+		return 0, nil
+	}
+
+	files, err := s.repo.ListUnscanned(tenant.Unscoped(ctx), limit)
+	if err != nil {
+		return 0, fmt.Errorf("%w: failed to list unscanned files: %v", domain.ErrInternal, err)
+	}
+
+	queued := 0
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return queued, err
+		}
+		if _, err := s.jobs.Enqueue(tenant.WithCtx(ctx, file.TenantID), domain.NewJob(domain.JobKindScanFile, file.ID, nil)); err != nil {
+			return queued, fmt.Errorf("%w: failed to queue %s job: %v", domain.ErrInternal, domain.JobKindScanFile, err)
+		}
+		queued++
+	}
+
+	return queued, nil
+}
+
+// ProcessScanFile is the processor of scan jobs. A file deleted before its
+// turn came needs no scan.
+func (s *FileService) ProcessScanFile(ctx context.Context, job *domain.Job) error {
+	if err := s.ScanFile(ctx, job.FileID); err != nil && !errors.Is(err, domain.ErrFileNotFound) {
+		return err
+	}
+	return nil
+}
+
+// queueProcessing hands a file to the job workers for what comes next: the
+// metadata stripping or the scan of a file waiting for them, or the
+// thumbnails of an image whose content has become available.
+func (s *FileService) queueProcessing(ctx context.Context, file *domain.File) error {
+	var kind domain.JobKind
+	switch {
+	case file.Status == domain.FileStatusProcessing:
+		kind = domain.JobKindStripMetadata
+	case file.Status == domain.FileStatusScanning:
+		kind = domain.JobKindScanFile
+	case s.thumbnails && s.contentAvailable(file) && domain.IsThumbnailSource(file.ContentType):
+		kind = domain.JobKindThumbnails
+	default:
+		return nil
+	}
+
+	if _, err := s.jobs.Enqueue(ctx, domain.NewJob(kind, file.ID, nil)); err != nil {
+		return fmt.Errorf("%w: failed to queue %s job: %v", domain.ErrInternal, kind, err)
+	}
+	return nil
+}
+
+// queueStoredProcessing queues the processing of a file just stored by a
+// streamed upload. The upload itself has succeeded, so a failure is only
+// logged.
+func (s *FileService) queueStoredProcessing(ctx context.Context, file *domain.File) *domain.File {
+	if err := s.queueProcessing(ctx, file); err != nil {
+		log.Printf("failed to queue processing of file %s: %v", file.ID, err)
+	}
+	return file
+}
+
+// contentAvailable reports whether the content of the file may be handed
+// out. Once scanning is enabled only files scanned clean qualify; files
+// uploaded before that wait for the scan QueueUnscanned queues for them.
+func (s *FileService) contentAvailable(file *domain.File) bool {
+	if s.scanner != nil {
+		return file.Status == domain.FileStatusClean
+	}
+	return file.Status == domain.FileStatusUploaded || file.Status == domain.FileStatusClean
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+	"github.com/gruzdev-dev/codex-files/pkg/identity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+type scanMocks struct {
+	repo     *ports.MockFileRepository
+	provider *ports.MockFileProvider
+	scanner  *ports.MockScanner
+	jobs     *ports.MockJobRepository
+}
+
+func newScanTestService(t *testing.T, setupMocks func(scanMocks)) *FileService {
+	ctrl := gomock.NewController(t)
+	mocks := scanMocks{
+		repo:     ports.NewMockFileRepository(ctrl),
+		provider: ports.NewMockFileProvider(ctrl),
+		scanner:  ports.NewMockScanner(ctrl),
+		jobs:     ports.NewMockJobRepository(ctrl),
+	}
+	setupMocks(mocks)
+
+	return NewFileService(mocks.repo, ports.NewMockMultipartUploadRepository(ctrl), noGrants(ctrl), noQuotas(ctrl), mocks.jobs, mocks.provider, mocks.scanner, FileServiceConfig{
+		UploadMaxSize: testMaxSize,
+		UploadTTL:     5 * time.Minute,
+		DownloadTTL:   15 * time.Minute,
+	})
+}
+
+func scanTestFile(status domain.FileStatus) *domain.File {
+	return &domain.File{
+		ID:          testFileID,
+		TenantID:    domain.DefaultTenantID,
+		OwnerID:     testOwnerID,
+		S3Path:      testS3Path,
+		Size:        int64(len(testContent)),
+		ContentType: testContentType,
+		Status:      status,
+	}
+}
+
+// expectStatuses records the status of every update so a test can check the
+// sequence a file went through.
+func expectStatuses(repo *ports.MockFileRepository, statuses *[]domain.FileStatus) {
+	repo.EXPECT().
+		Update(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+			*statuses = append(*statuses, file.Status)
+			updated := *file
+			return &updated, nil
+		}).
+		AnyTimes()
+}
+
+// expectScanQueued expects a scan job to be queued for the test file.
+func expectScanQueued(jobs *ports.MockJobRepository, err error) {
+	jobs.EXPECT().
+		Enqueue(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, job *domain.Job) (*domain.Job, error) {
+			if job.Kind != domain.JobKindScanFile || job.FileID != testFileID {
+				return nil, errors.New("unexpected job")
+			}
+			return job, err
+		})
+}
+
+func TestFileService_ConfirmUpload_Scan(t *testing.T) {
+	storedObject := &domain.ObjectInfo{Size: int64(len(testContent)), ContentType: testContentType, ETag: "etag"}
+
+	tests := []struct {
+		name             string
+		status           domain.FileStatus
+		setupMocks       func(scanMocks)
+		expectedError    error
+		expectedStatuses []domain.FileStatus
+	}{
+		{
+			name:   "accepted upload - queued for scan",
+			status: domain.FileStatusPending,
+			setupMocks: func(m scanMocks) {
+				m.provider.EXPECT().StatObject(gomock.Any(), testS3Path).Return(storedObject, nil)
+				storedContent(m.provider)
+				expectScanQueued(m.jobs, nil)
+			},
+			expectedStatuses: []domain.FileStatus{domain.FileStatusScanning},
+		},
+		{
+			name:   "queueing fails - left scanning",
+			status: domain.FileStatusPending,
+			setupMocks: func(m scanMocks) {
+				m.provider.EXPECT().StatObject(gomock.Any(), testS3Path).Return(storedObject, nil)
+				storedContent(m.provider)
+				expectScanQueued(m.jobs, errors.New("database error"))
+			},
+			expectedError:    domain.ErrInternal,
+			expectedStatuses: []domain.FileStatus{domain.FileStatusScanning},
+		},
+		{
+			name:   "repeated event queues the scan again",
+			status: domain.FileStatusScanning,
+			setupMocks: func(m scanMocks) {
+				expectScanQueued(m.jobs, nil)
+			},
+		},
+		{
+			name:       "already scanned - left alone",
+			status:     domain.FileStatusClean,
+			setupMocks: func(scanMocks) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statuses []domain.FileStatus
+			service := newScanTestService(t, func(m scanMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(scanTestFile(tt.status), nil)
+				expectStatuses(m.repo, &statuses)
+				tt.setupMocks(m)
+			})
+
+			err := service.ConfirmUpload(context.Background(), testFileID, "")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedStatuses, statuses)
+		})
+	}
+}
+
+func TestFileService_ScanFile(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           domain.FileStatus
+		setupMocks       func(scanMocks)
+		expectedError    error
+		expectedStatuses []domain.FileStatus
+	}{
+		{
+			name:   "clean",
+			status: domain.FileStatusScanning,
+			setupMocks: func(m scanMocks) {
+				storedContent(m.provider)
+				m.scanner.EXPECT().Scan(gomock.Any(), gomock.Any()).Return(&domain.ScanResult{}, nil)
+			},
+			expectedStatuses: []domain.FileStatus{domain.FileStatusClean},
+		},
+		{
+			name:   "infected - quarantined",
+			status: domain.FileStatusScanning,
+			setupMocks: func(m scanMocks) {
+				storedContent(m.provider)
+				m.scanner.EXPECT().Scan(gomock.Any(), gomock.Any()).
+					Return(&domain.ScanResult{Infected: true, Signature: "Eicar-Test-Signature"}, nil)
+			},
+			expectedStatuses: []domain.FileStatus{domain.FileStatusQuarantined},
+		},
+		{
+			name:   "refused by the scanner - rejected",
+			status: domain.FileStatusScanning,
+			setupMocks: func(m scanMocks) {
+				storedContent(m.provider)
+				m.scanner.EXPECT().Scan(gomock.Any(), gomock.Any()).
+					Return(&domain.ScanResult{Refused: "INSTREAM size limit exceeded."}, nil)
+			},
+			expectedStatuses: []domain.FileStatus{domain.FileStatusRejected},
+		},
+		{
+			name:   "scanner failure - left scanning",
+			status: domain.FileStatusScanning,
+			setupMocks: func(m scanMocks) {
+				storedContent(m.provider)
+				m.scanner.EXPECT().Scan(gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+		{
+			name:   "uploaded before scanning was enabled - scanned",
+			status: domain.FileStatusUploaded,
+			setupMocks: func(m scanMocks) {
+				storedContent(m.provider)
+				m.scanner.EXPECT().Scan(gomock.Any(), gomock.Any()).Return(&domain.ScanResult{}, nil)
+			},
+			expectedStatuses: []domain.FileStatus{domain.FileStatusClean},
+		},
+		{
+			name:       "not waiting for a scan - left alone",
+			status:     domain.FileStatusClean,
+			setupMocks: func(scanMocks) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var statuses []domain.FileStatus
+			service := newScanTestService(t, func(m scanMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(scanTestFile(tt.status), nil)
+				expectStatuses(m.repo, &statuses)
+				tt.setupMocks(m)
+			})
+
+			err := service.ScanFile(context.Background(), testFileID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedStatuses, statuses)
+		})
+	}
+}
+
+func TestFileService_ScanFile_RecordsVerdict(t *testing.T) {
+	service := newScanTestService(t, func(m scanMocks) {
+		m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(scanTestFile(domain.FileStatusScanning), nil)
+		storedContent(m.provider)
+		m.scanner.EXPECT().Scan(gomock.Any(), gomock.Any()).
+			Return(&domain.ScanResult{Infected: true, Signature: "Win.Test.EICAR_HDB-1"}, nil)
+		m.repo.EXPECT().
+			Update(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+				assert.Equal(t, domain.FileStatusQuarantined, file.Status)
+				assert.Equal(t, "Win.Test.EICAR_HDB-1", file.ScanSignature)
+				assert.WithinDuration(t, time.Now(), file.ScannedAt, time.Second)
+				return file, nil
+			})
+	})
+
+	require.NoError(t, service.ScanFile(context.Background(), testFileID))
+}
+
+func TestFileService_QueueUnscanned(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMocks     func(scanMocks)
+		expectedQueued int
+		expectedError  error
+	}{
+		{
+			name: "scans queued",
+			setupMocks: func(m scanMocks) {
+				m.repo.EXPECT().ListUnscanned(gomock.Any(), 10).Return([]*domain.File{scanTestFile(domain.FileStatusUploaded)}, nil)
+				expectScanQueued(m.jobs, nil)
+			},
+			expectedQueued: 1,
+		},
+		{
+			name: "nothing to queue",
+			setupMocks: func(m scanMocks) {
+				m.repo.EXPECT().ListUnscanned(gomock.Any(), 10).Return(nil, nil)
+			},
+		},
+		{
+			name: "queue failure",
+			setupMocks: func(m scanMocks) {
+				m.repo.EXPECT().ListUnscanned(gomock.Any(), 10).Return([]*domain.File{scanTestFile(domain.FileStatusUploaded)}, nil)
+				expectScanQueued(m.jobs, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(m scanMocks) {
+				m.repo.EXPECT().ListUnscanned(gomock.Any(), 10).Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newScanTestService(t, tt.setupMocks)
+
+			queued, err := service.QueueUnscanned(context.Background(), 10)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedQueued, queued)
+		})
+	}
+}
+
+func TestFileService_ProcessScanFile_DeletedFile(t *testing.T) {
+	service := newScanTestService(t, func(m scanMocks) {
+		m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(nil, domain.ErrFileNotFound)
+	})
+
+	err := service.ProcessScanFile(context.Background(), domain.NewJob(domain.JobKindScanFile, testFileID, nil))
+
+	assert.NoError(t, err)
+}
+
+func TestFileService_GetDownloadURL_Scan(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        domain.FileStatus
+		expectedError error
+	}{
+		{name: "clean", status: domain.FileStatusClean},
+		{name: "uploaded but not scanned", status: domain.FileStatusUploaded, expectedError: domain.ErrFileNotFound},
+		{name: "scanning", status: domain.FileStatusScanning, expectedError: domain.ErrFileNotFound},
+		{name: "quarantined", status: domain.FileStatusQuarantined, expectedError: domain.ErrFileNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newScanTestService(t, func(m scanMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(scanTestFile(tt.status), nil)
+				if tt.expectedError == nil {
+					m.provider.EXPECT().GenerateDownloadURL(gomock.Any(), testS3Path, gomock.Any()).Return(testDownloadURL, nil)
+				}
+			})
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{UserID: testOwnerID})
+			result, err := service.GetDownloadURL(ctx, testFileID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testDownloadURL, result.DownloadURL)
+		})
+	}
+}
+
+func TestFileService_UploadFile_QueueFailure(t *testing.T) {
+	service := newScanTestService(t, func(m scanMocks) {
+		m.provider.EXPECT().
+			PutObject(gomock.Any(), gomock.Any(), testContentType, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _, _ string, body io.Reader, _ int64) error {
+				_, err := io.ReadAll(body)
+				return err
+			})
+		m.repo.EXPECT().
+			Create(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+				return file, nil
+			})
+		m.jobs.EXPECT().Enqueue(gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
+	})
+
+	ctx := identity.WithCtx(context.Background(), domain.Identity{UserID: testOwnerID})
+	file, err := service.UploadFile(ctx, testContentType, "", strings.NewReader(testContent), int64(len(testContent)))
+
+	require.NoError(t, err)
+	assert.Equal(t, domain.FileStatusScanning, file.Status)
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+type ShareLinkConfig struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+// ShareLinkService manages links that let someone without an account
+// download a single file.
+type ShareLinkService struct {
+	files        *FileService
+	shares       ports.ShareLinkRepository
+	fileProvider ports.FileProvider
+	cfg          ShareLinkConfig
+}
+
+func NewShareLinkService(
+	files *FileService,
+	shares ports.ShareLinkRepository,
+	fileProvider ports.FileProvider,
+	cfg ShareLinkConfig,
+) *ShareLinkService {
+	return &ShareLinkService{
+		files:        files,
+		shares:       shares,
+		fileProvider: fileProvider,
+		cfg:          cfg,
+	}
+}
+
+// CreateShareLink issues a link for a file the caller owns. A zero ttl uses
+// the configured default; a zero maxDownloads leaves the count unlimited.
+func (s *ShareLinkService) CreateShareLink(ctx context.Context, fileID string, ttl time.Duration, maxDownloads int, password string) (*domain.CreateShareLinkResult, error) {
+	user, err := s.files.requireOwner(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ttl == 0 {
+		ttl = s.cfg.DefaultTTL
+	}
+	if ttl < 0 || ttl > s.cfg.MaxTTL {
+		return nil, fmt.Errorf("%w: share link lifetime must be between 0 and %s", domain.ErrInvalidInput, s.cfg.MaxTTL)
+	}
+	if maxDownloads < 0 {
+		return nil, fmt.Errorf("%w: maximum downloads must not be negative", domain.ErrInvalidInput)
+	}
+
+	link, token, err := domain.NewShareLink(fileID, user.UserID, time.Now().Add(ttl), maxDownloads, password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to generate share link: %v", domain.ErrInternal, err)
+	}
+
+	created, err := s.shares.Create(ctx, link)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to create share link: %v", domain.ErrInternal, err)
+	}
+
+	return &domain.CreateShareLinkResult{
+		Link:     created,
+		Token:    token,
+		ShareURL: fmt.Sprintf("/s/%s", token),
+	}, nil
+}
+
+func (s *ShareLinkService) ListShareLinks(ctx context.Context, fileID string) ([]*domain.ShareLink, error) {
+	if _, err := s.files.requireOwner(ctx, fileID); err != nil {
+		return nil, err
+	}
+
+	links, err := s.shares.ListByFileID(ctx, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list share links: %v", domain.ErrInternal, err)
+	}
+
+	return links, nil
+}
+
+func (s *ShareLinkService) RevokeShareLink(ctx context.Context, fileID, linkID string) error {
+	if _, err := s.files.requireOwner(ctx, fileID); err != nil {
+		return err
+	}
+
+	if err := s.shares.Revoke(ctx, fileID, linkID); err != nil {
+		if errors.Is(err, domain.ErrShareNotFound) {
+			return err
+		}
+		return fmt.Errorf("%w: failed to revoke share link: %v", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+// OpenShareLink resolves a share token to the file's content. client carries
+// the caller's address and user agent for the log. Failed attempts on an
+// existing link are logged; attempts with an unknown token have no link to
+// log against. The download is counted and logged by ConsumeShareLink, which
+// callers leave out for requests that do not transfer the whole file.
+func (s *ShareLinkService) OpenShareLink(ctx context.Context, token, password string, client domain.ShareLinkUse) (*domain.SharedDownload, error) {
+	if token == "" {
+		return nil, domain.ErrShareNotFound
+	}
+
+	// The link is anonymous and may belong to any tenant; once resolved, the
+	// rest of the request is scoped to the tenant of the shared file.
+	link, err := s.shares.GetByTokenHash(ctx, domain.HashShareToken(token))
+	if err != nil {
+		if errors.Is(err, domain.ErrShareNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: failed to get share link: %v", domain.ErrInternal, err)
+	}
+
+	use := client
+	use.LinkID = link.ID
+	use.CreatedAt = time.Now()
+
+	if outcome := link.Status(use.CreatedAt); outcome != "" {
+		s.logUse(ctx, &use, outcome)
+		return nil, domain.ErrShareExpired
+	}
+
+	if !link.CheckPassword(password) {
+		s.logUse(ctx, &use, domain.ShareLinkDenied)
+		return nil, fmt.Errorf("%w: share link password is missing or incorrect", domain.ErrAccessDenied)
+	}
+
+	file, err := s.files.repo.GetByID(ctx, link.FileID)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			s.logUse(ctx, &use, domain.ShareLinkUnavailable)
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: failed to get file: %v", domain.ErrInternal, err)
+	}
+	if !s.files.contentAvailable(file) {
+		s.logUse(ctx, &use, domain.ShareLinkUnavailable)
+		return nil, fmt.Errorf("%w: file content is not available", domain.ErrFileNotFound)
+	}
+	ctx = tenant.WithCtx(ctx, file.TenantID)
+
+	content, err := s.fileProvider.OpenObject(ctx, file.S3Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open file content: %v", domain.ErrInternal, err)
+	}
+
+	return &domain.SharedDownload{File: file, Content: content, Use: use}, nil
+}
+
+// ConsumeShareLink counts a download of the whole file against its link.
+func (s *ShareLinkService) ConsumeShareLink(ctx context.Context, download *domain.SharedDownload) error {
+	ctx = tenant.WithCtx(ctx, download.File.TenantID)
+
+	use := download.Use
+	use.Outcome = domain.ShareLinkDownloaded
+	if err := s.shares.Consume(ctx, &use); err != nil {
+		if errors.Is(err, domain.ErrShareExpired) {
+			// Another download used up the link since it was read.
+			s.logUse(ctx, &use, domain.ShareLinkExhausted)
+			return err
+		}
+		return fmt.Errorf("%w: failed to record share link download: %v", domain.ErrInternal, err)
+	}
+
+	return nil
+}
+
+func (s *ShareLinkService) logUse(ctx context.Context, use *domain.ShareLinkUse, outcome domain.ShareLinkOutcome) {
+	use.Outcome = outcome
+	if err := s.shares.LogUse(ctx, use); err != nil {
+		log.Printf("failed to log use of share link %s: %v", use.LinkID, err)
+	}
+}
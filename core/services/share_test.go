@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+	"github.com/gruzdev-dev/codex-files/pkg/identity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const (
+	testShareID         = "test-share-id"
+	testShareDefaultTTL = 7 * 24 * time.Hour
+	testShareMaxTTL     = 30 * 24 * time.Hour
+	testSharePassword   = "correct horse"
+)
+
+type shareMocks struct {
+	repo     *ports.MockFileRepository
+	shares   *ports.MockShareLinkRepository
+	provider *ports.MockFileProvider
+}
+
+func newShareTestService(t *testing.T, setupMocks func(shareMocks)) *ShareLinkService {
+	ctrl := gomock.NewController(t)
+	mocks := shareMocks{
+		repo:     ports.NewMockFileRepository(ctrl),
+		shares:   ports.NewMockShareLinkRepository(ctrl),
+		provider: ports.NewMockFileProvider(ctrl),
+	}
+	setupMocks(mocks)
+
+	files := NewFileService(mocks.repo, ports.NewMockMultipartUploadRepository(ctrl), noGrants(ctrl), noQuotas(ctrl), noJobs(ctrl), mocks.provider, nil, FileServiceConfig{
+		UploadMaxSize: testMaxSize,
+		UploadTTL:     5 * time.Minute,
+		DownloadTTL:   15 * time.Minute,
+	})
+
+	return NewShareLinkService(files, mocks.shares, mocks.provider, ShareLinkConfig{
+		DefaultTTL: testShareDefaultTTL,
+		MaxTTL:     testShareMaxTTL,
+	})
+}
+
+func TestShareLinkService_CreateShareLink(t *testing.T) {
+	tests := []struct {
+		name          string
+		userID        string
+		ttl           time.Duration
+		maxDownloads  int
+		password      string
+		setupMocks    func(shareMocks)
+		expectedError error
+	}{
+		{
+			name:         "success path - default expiry",
+			userID:       testOwnerID,
+			maxDownloads: 3,
+			password:     testSharePassword,
+			setupMocks: func(m shareMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.shares.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, link *domain.ShareLink) (*domain.ShareLink, error) {
+						assert.Equal(t, testFileID, link.FileID)
+						assert.Equal(t, testOwnerID, link.CreatedBy)
+						assert.Equal(t, 3, link.MaxDownloads)
+						assert.WithinDuration(t, time.Now().Add(testShareDefaultTTL), link.ExpiresAt, time.Second)
+						assert.True(t, link.CheckPassword(testSharePassword))
+						return link, nil
+					})
+			},
+		},
+		{
+			name:   "lifetime over the maximum",
+			userID: testOwnerID,
+			ttl:    testShareMaxTTL + time.Hour,
+			setupMocks: func(m shareMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:         "negative download limit",
+			userID:       testOwnerID,
+			maxDownloads: -1,
+			setupMocks: func(m shareMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:   "not the owner",
+			userID: testUserID,
+			setupMocks: func(m shareMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:   "repository error",
+			userID: testOwnerID,
+			setupMocks: func(m shareMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.shares.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newShareTestService(t, tt.setupMocks)
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{UserID: tt.userID})
+			result, err := service.CreateShareLink(ctx, testFileID, tt.ttl, tt.maxDownloads, tt.password)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, result)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "/s/"+result.Token, result.ShareURL)
+			assert.Equal(t, domain.HashShareToken(result.Token), result.Link.TokenHash)
+		})
+	}
+}
+
+func TestShareLinkService_RevokeShareLink(t *testing.T) {
+	tests := []struct {
+		name          string
+		userID        string
+		setupMocks    func(shareMocks)
+		expectedError error
+	}{
+		{
+			name:   "success path",
+			userID: testOwnerID,
+			setupMocks: func(m shareMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.shares.EXPECT().Revoke(gomock.Any(), testFileID, testShareID).Return(nil)
+			},
+		},
+		{
+			name:   "already revoked or unknown",
+			userID: testOwnerID,
+			setupMocks: func(m shareMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.shares.EXPECT().Revoke(gomock.Any(), testFileID, testShareID).Return(domain.ErrShareNotFound)
+			},
+			expectedError: domain.ErrShareNotFound,
+		},
+		{
+			name:   "not the owner",
+			userID: testUserID,
+			setupMocks: func(m shareMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newShareTestService(t, tt.setupMocks)
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{UserID: tt.userID})
+			err := service.RevokeShareLink(ctx, testFileID, testShareID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestShareLinkService_OpenShareLink(t *testing.T) {
+	link, token, err := domain.NewShareLink(testFileID, testOwnerID, time.Now().Add(time.Hour), 2, "")
+	require.NoError(t, err)
+	protected, protectedToken, err := domain.NewShareLink(testFileID, testOwnerID, time.Now().Add(time.Hour), 0, testSharePassword)
+	require.NoError(t, err)
+
+	withLink := func(m shareMocks, link domain.ShareLink) {
+		m.shares.EXPECT().GetByTokenHash(gomock.Any(), link.TokenHash).Return(&link, nil)
+	}
+	expectLog := func(m shareMocks, outcome domain.ShareLinkOutcome) {
+		m.shares.EXPECT().
+			LogUse(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, use *domain.ShareLinkUse) error {
+				assert.Equal(t, outcome, use.Outcome)
+				assert.Equal(t, "203.0.113.7:5000", use.RemoteAddr)
+				return nil
+			})
+	}
+
+	tests := []struct {
+		name          string
+		token         string
+		password      string
+		setupMocks    func(shareMocks)
+		expectedError error
+	}{
+		{
+			name:  "success path - download not counted until consumed",
+			token: token,
+			setupMocks: func(m shareMocks) {
+				withLink(m, *link)
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testContent)}, nil)
+			},
+		},
+		{
+			name:     "success path - password",
+			token:    protectedToken,
+			password: testSharePassword,
+			setupMocks: func(m shareMocks) {
+				withLink(m, *protected)
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(grantedFile(), nil)
+				m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testContent)}, nil)
+			},
+		},
+		{
+			name:     "wrong password",
+			token:    protectedToken,
+			password: "guess",
+			setupMocks: func(m shareMocks) {
+				withLink(m, *protected)
+				expectLog(m, domain.ShareLinkDenied)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:  "unknown token",
+			token: "not-a-token",
+			setupMocks: func(m shareMocks) {
+				m.shares.EXPECT().GetByTokenHash(gomock.Any(), domain.HashShareToken("not-a-token")).Return(nil, domain.ErrShareNotFound)
+			},
+			expectedError: domain.ErrShareNotFound,
+		},
+		{
+			name:  "revoked",
+			token: token,
+			setupMocks: func(m shareMocks) {
+				revoked := *link
+				revoked.RevokedAt = time.Now()
+				withLink(m, revoked)
+				expectLog(m, domain.ShareLinkRevoked)
+			},
+			expectedError: domain.ErrShareExpired,
+		},
+		{
+			name:  "download limit reached",
+			token: token,
+			setupMocks: func(m shareMocks) {
+				exhausted := *link
+				exhausted.Downloads = exhausted.MaxDownloads
+				withLink(m, exhausted)
+				expectLog(m, domain.ShareLinkExhausted)
+			},
+			expectedError: domain.ErrShareExpired,
+		},
+		{
+			name:  "file deleted",
+			token: token,
+			setupMocks: func(m shareMocks) {
+				withLink(m, *link)
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(nil, domain.ErrFileNotFound)
+				expectLog(m, domain.ShareLinkUnavailable)
+			},
+			expectedError: domain.ErrFileNotFound,
+		},
+		{
+			name:  "file not uploaded",
+			token: token,
+			setupMocks: func(m shareMocks) {
+				withLink(m, *link)
+				file := grantedFile()
+				file.Status = domain.FileStatusPending
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(file, nil)
+				expectLog(m, domain.ShareLinkUnavailable)
+			},
+			expectedError: domain.ErrFileNotFound,
+		},
+		{
+			name:  "repository error",
+			token: token,
+			setupMocks: func(m shareMocks) {
+				m.shares.EXPECT().GetByTokenHash(gomock.Any(), link.TokenHash).Return(nil, errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newShareTestService(t, tt.setupMocks)
+
+			client := domain.ShareLinkUse{RemoteAddr: "203.0.113.7:5000", UserAgent: "test"}
+			download, err := service.OpenShareLink(context.Background(), tt.token, tt.password, client)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, download)
+				return
+			}
+			require.NoError(t, err)
+			defer download.Content.Close()
+
+			body, err := io.ReadAll(download.Content)
+			require.NoError(t, err)
+			assert.Equal(t, testContent, string(body))
+			assert.Equal(t, testFileID, download.File.ID)
+			assert.Equal(t, "203.0.113.7:5000", download.Use.RemoteAddr)
+		})
+	}
+}
+
+func TestShareLinkService_ConsumeShareLink(t *testing.T) {
+	link, _, err := domain.NewShareLink(testFileID, testOwnerID, time.Now().Add(time.Hour), 2, "")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		setupMocks    func(shareMocks)
+		expectedError error
+	}{
+		{
+			name: "success path - download counted and logged",
+			setupMocks: func(m shareMocks) {
+				m.shares.EXPECT().
+					Consume(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, use *domain.ShareLinkUse) error {
+						assert.Equal(t, link.ID, use.LinkID)
+						assert.Equal(t, domain.ShareLinkDownloaded, use.Outcome)
+						return nil
+					})
+			},
+		},
+		{
+			name: "last download taken concurrently",
+			setupMocks: func(m shareMocks) {
+				m.shares.EXPECT().Consume(gomock.Any(), gomock.Any()).Return(domain.ErrShareExpired)
+				m.shares.EXPECT().
+					LogUse(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, use *domain.ShareLinkUse) error {
+						assert.Equal(t, domain.ShareLinkExhausted, use.Outcome)
+						return nil
+					})
+			},
+			expectedError: domain.ErrShareExpired,
+		},
+		{
+			name: "repository error",
+			setupMocks: func(m shareMocks) {
+				m.shares.EXPECT().Consume(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newShareTestService(t, tt.setupMocks)
+
+			download := &domain.SharedDownload{
+				File:    grantedFile(),
+				Content: nopSeekCloser{strings.NewReader(testContent)},
+				Use:     domain.ShareLinkUse{LinkID: link.ID, RemoteAddr: "203.0.113.7:5000"},
+			}
+			err := service.ConsumeShareLink(context.Background(), download)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+type ThumbnailConfig struct {
+	// Sizes are the longest edges, in pixels, thumbnails are rendered at.
+	// No sizes disables thumbnails.
+	Sizes []int
+}
+
+// ThumbnailService renders thumbnails of image files and serves them with
+// the same access checks as the originals.
+type ThumbnailService struct {
+	files        *FileService
+	thumbnails   ports.ThumbnailRepository
+	thumbnailer  ports.Thumbnailer
+	fileProvider ports.FileProvider
+	cfg          ThumbnailConfig
+}
+
+func NewThumbnailService(
+	files *FileService,
+	thumbnails ports.ThumbnailRepository,
+	thumbnailer ports.Thumbnailer,
+	fileProvider ports.FileProvider,
+	cfg ThumbnailConfig,
+) *ThumbnailService {
+	return &ThumbnailService{
+		files:        files,
+		thumbnails:   thumbnails,
+		thumbnailer:  thumbnailer,
+		fileProvider: fileProvider,
+		cfg:          cfg,
+	}
+}
+
+// GenerateThumbnails renders and stores every configured size of an image
+// whose content is available. Thumbnails that already exist are replaced,
+// so that replaced content gets new ones. Other files are left alone.
+func (s *ThumbnailService) GenerateThumbnails(ctx context.Context, fileID string) error {
+	if fileID == "" {
+		return fmt.Errorf("%w: file ID is required", domain.ErrFileIDRequired)
+	}
+
+	file, err := s.files.repo.GetByID(ctx, fileID)
+	if err != nil {
+		if err == domain.ErrFileNotFound {
+			return err
+		}
+		return fmt.Errorf("%w: failed to get file: %v", domain.ErrInternal, err)
+	}
+
+	if len(s.cfg.Sizes) == 0 || !s.files.contentAvailable(file) || !domain.IsThumbnailSource(file.ContentType) {
+		return nil
+	}
+
+	ctx = tenant.WithCtx(ctx, file.TenantID)
+	content, err := s.fileProvider.OpenObject(ctx, file.S3Path)
+	if err != nil {
+		return fmt.Errorf("%w: failed to open file content: %v", domain.ErrInternal, err)
+	}
+	defer content.Close()
+
+	rendered, err := s.thumbnailer.Thumbnail(ctx, content, s.cfg.Sizes)
+	if err != nil {
+		// Rendering the same content again cannot succeed.
+		if errors.Is(err, domain.ErrInvalidInput) {
+			log.Printf("skipping thumbnails of file %s: %v", file.ID, err)
+			return nil
+		}
+		return fmt.Errorf("%w: failed to render thumbnails: %v", domain.ErrInternal, err)
+	}
+
+	for _, image := range rendered {
+		thumbnail := domain.NewThumbnail(file, image)
+		if err := s.fileProvider.PutObject(ctx, thumbnail.S3Path, thumbnail.ContentType, bytes.NewReader(image.Data), thumbnail.ByteSize); err != nil {
+			return fmt.Errorf("%w: failed to store thumbnail: %v", domain.ErrInternal, err)
+		}
+		if _, err := s.thumbnails.Save(ctx, thumbnail); err != nil {
+			return fmt.Errorf("%w: failed to save thumbnail: %v", domain.ErrInternal, err)
+		}
+	}
+
+	return nil
+}
+
+// ProcessThumbnails is the processor of thumbnail jobs. A file deleted
+// before its turn came needs no thumbnails.
+func (s *ThumbnailService) ProcessThumbnails(ctx context.Context, job *domain.Job) error {
+	if err := s.GenerateThumbnails(ctx, job.FileID); err != nil && !errors.Is(err, domain.ErrFileNotFound) {
+		return err
+	}
+	return nil
+}
+
+// GetThumbnailURL returns a presigned URL of a thumbnail. A zero size picks
+// the smallest configured size.
+func (s *ThumbnailService) GetThumbnailURL(ctx context.Context, fileID string, size int) (*domain.GetDownloadURLResult, error) {
+	thumbnail, err := s.getThumbnail(ctx, fileID, size)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadURL, err := s.fileProvider.GenerateDownloadURL(ctx, thumbnail.S3Path, s.files.downloadTTL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to generate download URL: %v", domain.ErrInternal, err)
+	}
+
+	return &domain.GetDownloadURLResult{
+		DownloadURL: downloadURL,
+	}, nil
+}
+
+// OpenThumbnail opens the content of a thumbnail. A zero size picks the
+// smallest configured size.
+func (s *ThumbnailService) OpenThumbnail(ctx context.Context, fileID string, size int) (*domain.Thumbnail, io.ReadSeekCloser, error) {
+	thumbnail, err := s.getThumbnail(ctx, fileID, size)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.fileProvider.OpenObject(ctx, thumbnail.S3Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: failed to open thumbnail: %v", domain.ErrInternal, err)
+	}
+
+	return thumbnail, content, nil
+}
+
+// getThumbnail applies the checks of the original: a thumbnail is only
+// handed out to whoever may read the file, and only while its content is.
+func (s *ThumbnailService) getThumbnail(ctx context.Context, fileID string, size int) (*domain.Thumbnail, error) {
+	if len(s.cfg.Sizes) == 0 {
+		return nil, fmt.Errorf("%w: thumbnails are disabled", domain.ErrThumbnailNotFound)
+	}
+	if size == 0 {
+		size = slices.Min(s.cfg.Sizes)
+	}
+	if !slices.Contains(s.cfg.Sizes, size) {
+		return nil, fmt.Errorf("%w: thumbnail size must be one of %v", domain.ErrInvalidInput, s.cfg.Sizes)
+	}
+
+	file, err := s.files.getAccessibleFile(ctx, fileID, domain.PermissionRead)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.files.contentAvailable(file) {
+		return nil, fmt.Errorf("%w: file content is not available", domain.ErrFileNotFound)
+	}
+	if !domain.IsThumbnailSource(file.ContentType) {
+		return nil, fmt.Errorf("%w: file is not an image", domain.ErrThumbnailNotFound)
+	}
+
+	thumbnail, err := s.thumbnails.Get(ctx, file.ID, size)
+	if err != nil {
+		if errors.Is(err, domain.ErrThumbnailNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: failed to get thumbnail: %v", domain.ErrInternal, err)
+	}
+
+	return thumbnail, nil
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+	"github.com/gruzdev-dev/codex-files/pkg/identity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+const testImageContent = "\x89PNG\r\n\x1a\n test image content"
+
+var testThumbnailSizes = []int{128, 512}
+
+type thumbnailMocks struct {
+	repo        *ports.MockFileRepository
+	provider    *ports.MockFileProvider
+	thumbnails  *ports.MockThumbnailRepository
+	thumbnailer *ports.MockThumbnailer
+}
+
+func newTestThumbnailService(t *testing.T, setupMocks func(thumbnailMocks)) *ThumbnailService {
+	ctrl := gomock.NewController(t)
+	mocks := thumbnailMocks{
+		repo:        ports.NewMockFileRepository(ctrl),
+		provider:    ports.NewMockFileProvider(ctrl),
+		thumbnails:  ports.NewMockThumbnailRepository(ctrl),
+		thumbnailer: ports.NewMockThumbnailer(ctrl),
+	}
+	setupMocks(mocks)
+
+	files := NewFileService(mocks.repo, ports.NewMockMultipartUploadRepository(ctrl), noGrants(ctrl), noQuotas(ctrl), noJobs(ctrl), mocks.provider, nil, FileServiceConfig{
+		UploadMaxSize: testMaxSize,
+		UploadTTL:     5 * time.Minute,
+		DownloadTTL:   15 * time.Minute,
+		Thumbnails:    true,
+	})
+
+	return NewThumbnailService(files, mocks.thumbnails, mocks.thumbnailer, mocks.provider, ThumbnailConfig{
+		Sizes: testThumbnailSizes,
+	})
+}
+
+func imageFile(status domain.FileStatus) *domain.File {
+	return &domain.File{
+		ID:          testFileID,
+		TenantID:    domain.DefaultTenantID,
+		OwnerID:     testOwnerID,
+		S3Path:      testS3Path,
+		Size:        int64(len(testImageContent)),
+		ContentType: "image/png",
+		Status:      status,
+	}
+}
+
+func renderedThumbnails() []*domain.RenderedThumbnail {
+	return []*domain.RenderedThumbnail{
+		{Size: 128, ContentType: "image/png", Width: 128, Height: 96, Data: []byte("small")},
+		{Size: 512, ContentType: "image/png", Width: 512, Height: 384, Data: []byte("large")},
+	}
+}
+
+func testThumbnail(size int) *domain.Thumbnail {
+	return &domain.Thumbnail{
+		FileID:      testFileID,
+		Size:        size,
+		S3Path:      domain.ThumbnailKey(testS3Path, size),
+		ContentType: "image/png",
+	}
+}
+
+func TestThumbnailService_GenerateThumbnails(t *testing.T) {
+	tests := []struct {
+		name          string
+		file          *domain.File
+		setupMocks    func(thumbnailMocks)
+		expectedError error
+	}{
+		{
+			name: "image - every size stored",
+			file: imageFile(domain.FileStatusUploaded),
+			setupMocks: func(m thumbnailMocks) {
+				m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testImageContent)}, nil)
+				m.thumbnailer.EXPECT().Thumbnail(gomock.Any(), gomock.Any(), testThumbnailSizes).Return(renderedThumbnails(), nil)
+				for _, size := range testThumbnailSizes {
+					path := domain.ThumbnailKey(testS3Path, size)
+					m.provider.EXPECT().
+						PutObject(gomock.Any(), path, "image/png", gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, _, _ string, body io.Reader, size int64) error {
+							data, err := io.ReadAll(body)
+							assert.Equal(t, size, int64(len(data)))
+							return err
+						})
+					m.thumbnails.EXPECT().
+						Save(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, thumbnail *domain.Thumbnail) (*domain.Thumbnail, error) {
+							assert.Equal(t, testFileID, thumbnail.FileID)
+							assert.Equal(t, path, thumbnail.S3Path)
+							return thumbnail, nil
+						})
+				}
+			},
+		},
+		{
+			name:       "not an image - left alone",
+			file:       storedFile(domain.FileStatusUploaded),
+			setupMocks: func(thumbnailMocks) {},
+		},
+		{
+			name:       "content not available - left alone",
+			file:       imageFile(domain.FileStatusPending),
+			setupMocks: func(thumbnailMocks) {},
+		},
+		{
+			name: "undecodable image - skipped without retry",
+			file: imageFile(domain.FileStatusUploaded),
+			setupMocks: func(m thumbnailMocks) {
+				m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testImageContent)}, nil)
+				m.thumbnailer.EXPECT().Thumbnail(gomock.Any(), gomock.Any(), testThumbnailSizes).
+					Return(nil, domain.ErrInvalidInput)
+			},
+		},
+		{
+			name: "store error - retried",
+			file: imageFile(domain.FileStatusUploaded),
+			setupMocks: func(m thumbnailMocks) {
+				m.provider.EXPECT().OpenObject(gomock.Any(), testS3Path).Return(nopSeekCloser{strings.NewReader(testImageContent)}, nil)
+				m.thumbnailer.EXPECT().Thumbnail(gomock.Any(), gomock.Any(), testThumbnailSizes).Return(renderedThumbnails(), nil)
+				m.provider.EXPECT().PutObject(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("s3 error"))
+			},
+			expectedError: domain.ErrInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestThumbnailService(t, func(m thumbnailMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(tt.file, nil)
+				tt.setupMocks(m)
+			})
+
+			err := service.GenerateThumbnails(context.Background(), testFileID)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestThumbnailService_ProcessThumbnails_DeletedFile(t *testing.T) {
+	service := newTestThumbnailService(t, func(m thumbnailMocks) {
+		m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(nil, domain.ErrFileNotFound)
+	})
+
+	err := service.ProcessThumbnails(context.Background(), domain.NewJob(domain.JobKindThumbnails, testFileID, nil))
+
+	assert.NoError(t, err)
+}
+
+func TestThumbnailService_OpenThumbnail(t *testing.T) {
+	tests := []struct {
+		name          string
+		userID        string
+		size          int
+		setupMocks    func(thumbnailMocks)
+		expectedSize  int
+		expectedError error
+	}{
+		{
+			name:   "owner - thumbnail opened",
+			userID: testOwnerID,
+			size:   512,
+			setupMocks: func(m thumbnailMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(imageFile(domain.FileStatusUploaded), nil)
+				m.thumbnails.EXPECT().Get(gomock.Any(), testFileID, 512).Return(testThumbnail(512), nil)
+				m.provider.EXPECT().OpenObject(gomock.Any(), domain.ThumbnailKey(testS3Path, 512)).Return(nopSeekCloser{strings.NewReader("large")}, nil)
+			},
+			expectedSize: 512,
+		},
+		{
+			name:   "no size - smallest size",
+			userID: testOwnerID,
+			setupMocks: func(m thumbnailMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(imageFile(domain.FileStatusUploaded), nil)
+				m.thumbnails.EXPECT().Get(gomock.Any(), testFileID, 128).Return(testThumbnail(128), nil)
+				m.provider.EXPECT().OpenObject(gomock.Any(), domain.ThumbnailKey(testS3Path, 128)).Return(nopSeekCloser{strings.NewReader("small")}, nil)
+			},
+			expectedSize: 128,
+		},
+		{
+			name:          "size not configured",
+			userID:        testOwnerID,
+			size:          300,
+			setupMocks:    func(thumbnailMocks) {},
+			expectedError: domain.ErrInvalidInput,
+		},
+		{
+			name:   "another user - access denied",
+			userID: "other-user",
+			size:   128,
+			setupMocks: func(m thumbnailMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(imageFile(domain.FileStatusUploaded), nil)
+			},
+			expectedError: domain.ErrAccessDenied,
+		},
+		{
+			name:   "content not available",
+			userID: testOwnerID,
+			size:   128,
+			setupMocks: func(m thumbnailMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(imageFile(domain.FileStatusPending), nil)
+			},
+			expectedError: domain.ErrFileNotFound,
+		},
+		{
+			name:   "not an image",
+			userID: testOwnerID,
+			size:   128,
+			setupMocks: func(m thumbnailMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(storedFile(domain.FileStatusUploaded), nil)
+			},
+			expectedError: domain.ErrThumbnailNotFound,
+		},
+		{
+			name:   "not generated yet",
+			userID: testOwnerID,
+			size:   128,
+			setupMocks: func(m thumbnailMocks) {
+				m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(imageFile(domain.FileStatusUploaded), nil)
+				m.thumbnails.EXPECT().Get(gomock.Any(), testFileID, 128).Return(nil, domain.ErrThumbnailNotFound)
+			},
+			expectedError: domain.ErrThumbnailNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestThumbnailService(t, tt.setupMocks)
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{UserID: tt.userID})
+			thumbnail, content, err := service.OpenThumbnail(ctx, testFileID, tt.size)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, thumbnail)
+				assert.Nil(t, content)
+				return
+			}
+			require.NoError(t, err)
+			defer content.Close()
+			assert.Equal(t, tt.expectedSize, thumbnail.Size)
+		})
+	}
+}
+
+func TestThumbnailService_GetThumbnailURL(t *testing.T) {
+	service := newTestThumbnailService(t, func(m thumbnailMocks) {
+		m.repo.EXPECT().GetByID(gomock.Any(), testFileID).Return(imageFile(domain.FileStatusUploaded), nil)
+		m.thumbnails.EXPECT().Get(gomock.Any(), testFileID, 128).Return(testThumbnail(128), nil)
+		m.provider.EXPECT().
+			GenerateDownloadURL(gomock.Any(), domain.ThumbnailKey(testS3Path, 128), 15*time.Minute).
+			Return(testDownloadURL, nil)
+	})
+
+	ctx := identity.WithCtx(context.Background(), domain.Identity{UserID: testOwnerID})
+	result, err := service.GetThumbnailURL(ctx, testFileID, 128)
+
+	require.NoError(t, err)
+	assert.Equal(t, testDownloadURL, result.DownloadURL)
+}
+
+func TestFileService_UploadFile_QueuesThumbnails(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		content     string
+		expectedJob bool
+	}{
+		{name: "image", contentType: "image/png", content: testImageContent, expectedJob: true},
+		{name: "not an image", contentType: testContentType, content: testContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := ports.NewMockFileRepository(ctrl)
+			provider := ports.NewMockFileProvider(ctrl)
+			jobs := ports.NewMockJobRepository(ctrl)
+
+			provider.EXPECT().
+				PutObject(gomock.Any(), gomock.Any(), tt.contentType, gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _, _ string, body io.Reader, _ int64) error {
+					_, err := io.ReadAll(body)
+					return err
+				})
+			repo.EXPECT().
+				Create(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, file *domain.File) (*domain.File, error) {
+					return file, nil
+				})
+			if tt.expectedJob {
+				jobs.EXPECT().
+					Enqueue(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, job *domain.Job) (*domain.Job, error) {
+						assert.Equal(t, domain.JobKindThumbnails, job.Kind)
+						return job, nil
+					})
+			}
+
+			service := NewFileService(repo, ports.NewMockMultipartUploadRepository(ctrl), noGrants(ctrl), noQuotas(ctrl), jobs, provider, nil, FileServiceConfig{
+				UploadMaxSize: testMaxSize,
+				Thumbnails:    true,
+			})
+
+			ctx := identity.WithCtx(context.Background(), domain.Identity{UserID: testOwnerID})
+			file, err := service.UploadFile(ctx, tt.contentType, "", strings.NewReader(tt.content), int64(len(tt.content)))
+
+			require.NoError(t, err)
+			assert.Equal(t, domain.FileStatusUploaded, file.Status)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/pkg/identity"
+	"github.com/gruzdev-dev/codex-files/pkg/tenant"
+)
+
+// UploadFile streams content straight into the object store and records the
+// file as uploaded. A negative size means the length is not known upfront;
+// the limit is then enforced while reading.
+func (s *FileService) UploadFile(ctx context.Context, contentType, securityContext string, content io.Reader, size int64) (*domain.File, error) {
+	user, ok := identity.FromCtx(ctx)
+	if !ok || user.UserID == "" {
+		return nil, domain.ErrUnauthenticated
+	}
+	if err := domain.ValidateOwnerID(user.UserID, s.tenants); err != nil {
+		return nil, err
+	}
+
+	if contentType == "" {
+		return nil, fmt.Errorf("%w: content type is required", domain.ErrInvalidInput)
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("%w: file size must be positive", domain.ErrInvalidInput)
+	}
+	if size > s.uploadMaxSize {
+		return nil, domain.ErrFileTooLarge
+	}
+	if err := s.contentPolicy.Check(contentType, size); err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateReference(securityContext); err != nil {
+		return nil, err
+	}
+
+	maxSize := s.maxSizeFor(contentType, s.uploadMaxSize)
+
+	// A body of unknown length is charged as if it reached the largest size
+	// it is allowed to grow to.
+	reserved := size
+	if reserved < 0 {
+		reserved = maxSize
+	}
+	quotas, err := s.checkQuota(ctx, user.UserID, reserved)
+	if err != nil {
+		return nil, err
+	}
+
+	file := domain.NewFile(tenant.FromCtx(ctx), user.UserID, contentType, size)
+	file.SecurityContext = securityContext
+
+	// An empty body is refused before anything is stored, so that it leaves
+	// no object behind. sniffStream reuses the buffer.
+	buffered := bufio.NewReaderSize(content, sniffLen)
+	if _, err := buffered.Peek(1); err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: file size must be positive", domain.ErrInvalidInput)
+		}
+		return nil, fmt.Errorf("%w: failed to read file content: %v", domain.ErrInvalidInput, err)
+	}
+
+	content, err = sniffStream(buffered, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := sha256.New()
+	body := &sizeLimitReader{reader: content, limit: maxSize}
+
+	if err := s.fileProvider.PutObject(ctx, file.S3Path, file.ContentType, io.TeeReader(body, hasher), size); err != nil {
+		if body.exceeded {
+			return nil, domain.ErrFileTooLarge
+		}
+		return nil, fmt.Errorf("%w: failed to store file content: %v", domain.ErrInternal, err)
+	}
+
+	file.Size = body.read
+	file.Checksum = domain.Checksum{
+		Algorithm: domain.ChecksumSHA256,
+		Value:     hasher.Sum(nil),
+	}
+	s.markStored(file)
+
+	created, err := s.createStoredFile(ctx, file, quotas)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.queueStoredProcessing(ctx, created), nil
+}
+
+// sizeLimitReader counts the bytes it passes through and fails with
+// domain.ErrFileTooLarge as soon as the stream goes past limit.
+type sizeLimitReader struct {
+	reader   io.Reader
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (r *sizeLimitReader) Read(p []byte) (int, error) {
+	if r.read >= r.limit {
+		var probe [1]byte
+		n, err := r.reader.Read(probe[:])
+		if n > 0 {
+			r.exceeded = true
+			return 0, domain.ErrFileTooLarge
+		}
+		return 0, err
+	}
+
+	if remaining := r.limit - r.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	return n, err
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+	"github.com/gruzdev-dev/codex-files/pkg/identity"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestFileService_UploadFile(t *testing.T) {
+	const uploadLimit = int64(16)
+	content := []byte("%PDF-1.7 body")
+	checksum := sha256.Sum256(content)
+
+	// drainObject mimics the object store by consuming the whole stream.
+	drainObject := func(_ context.Context, _, _ string, body io.Reader, _ int64) error {
+		_, err := io.ReadAll(body)
+		return err
+	}
+
+	tests := []struct {
+		name           string
+		userID         string
+		contentType    string
+		content        []byte
+		size           int64
+		setupMocks     func(*ports.MockFileRepository, *ports.MockFileProvider)
+		validateResult func(*testing.T, *domain.File, error)
+	}{
+		{
+			name:    "success path with known length",
+			userID:  testOwnerID,
+			content: content,
+			size:    int64(len(content)),
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				provider.EXPECT().
+					PutObject(gomock.Any(), gomock.Any(), testContentType, gomock.Any(), int64(len(content))).
+					DoAndReturn(drainObject)
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						require.Equal(t, domain.FileStatusUploaded, file.Status)
+						return file, nil
+					})
+			},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(content)), file.Size)
+				assert.Equal(t, domain.ChecksumSHA256, file.Checksum.Algorithm)
+				assert.Equal(t, checksum[:], file.Checksum.Value)
+			},
+		},
+		{
+			name:    "success path with unknown length",
+			userID:  testOwnerID,
+			content: content,
+			size:    -1,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				provider.EXPECT().
+					PutObject(gomock.Any(), gomock.Any(), testContentType, gomock.Any(), int64(-1)).
+					DoAndReturn(drainObject)
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						return file, nil
+					})
+			},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, int64(len(content)), file.Size)
+				assert.Equal(t, checksum[:], file.Checksum.Value)
+			},
+		},
+		{
+			name:    "stream exceeds limit",
+			userID:  testOwnerID,
+			content: append([]byte("%PDF-"), bytes.Repeat([]byte("a"), int(uploadLimit)-4)...),
+			size:    -1,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				provider.EXPECT().
+					PutObject(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), int64(-1)).
+					DoAndReturn(drainObject)
+			},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				assert.Nil(t, file)
+				assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+			},
+		},
+		{
+			name:    "stream of exactly the limit",
+			userID:  testOwnerID,
+			content: append([]byte("%PDF-"), bytes.Repeat([]byte("a"), int(uploadLimit)-5)...),
+			size:    -1,
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				provider.EXPECT().
+					PutObject(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), int64(-1)).
+					DoAndReturn(drainObject)
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, file *domain.File) (*domain.File, error) {
+						return file, nil
+					})
+			},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, uploadLimit, file.Size)
+			},
+		},
+		{
+			name:       "declared length exceeds limit",
+			userID:     testOwnerID,
+			content:    content,
+			size:       uploadLimit + 1,
+			setupMocks: func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				assert.Nil(t, file)
+				assert.ErrorIs(t, err, domain.ErrFileTooLarge)
+			},
+		},
+		{
+			name:       "content does not match declared type",
+			userID:     testOwnerID,
+			content:    []byte("just some text"),
+			size:       -1,
+			setupMocks: func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				assert.Nil(t, file)
+				assert.ErrorIs(t, err, domain.ErrContentMismatch)
+			},
+		},
+		{
+			name:        "empty body with unknown length",
+			userID:      testOwnerID,
+			contentType: "text/plain",
+			size:        -1,
+			// No object is stored for it.
+			setupMocks: func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				assert.Nil(t, file)
+				assert.ErrorIs(t, err, domain.ErrInvalidInput)
+				assert.Contains(t, err.Error(), "file size must be positive")
+			},
+		},
+		{
+			name:       "unauthenticated",
+			userID:     "",
+			content:    content,
+			size:       int64(len(content)),
+			setupMocks: func(*ports.MockFileRepository, *ports.MockFileProvider) {},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				assert.Nil(t, file)
+				assert.Equal(t, domain.ErrUnauthenticated, err)
+			},
+		},
+		{
+			name:    "repository error",
+			userID:  testOwnerID,
+			content: content,
+			size:    int64(len(content)),
+			setupMocks: func(repo *ports.MockFileRepository, provider *ports.MockFileProvider) {
+				provider.EXPECT().
+					PutObject(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(drainObject)
+				repo.EXPECT().
+					Create(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("database error"))
+			},
+			validateResult: func(t *testing.T, file *domain.File, err error) {
+				assert.Nil(t, file)
+				assert.ErrorIs(t, err, domain.ErrInternal)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := ports.NewMockFileRepository(ctrl)
+			provider := ports.NewMockFileProvider(ctrl)
+
+			tt.setupMocks(repo, provider)
+
+			service := NewFileService(
+				repo,
+				ports.NewMockMultipartUploadRepository(ctrl),
+				noGrants(ctrl),
+				noQuotas(ctrl),
+				noJobs(ctrl),
+				provider,
+				nil,
+				FileServiceConfig{
+					UploadMaxSize: uploadLimit,
+					UploadTTL:     5 * time.Minute,
+					DownloadTTL:   15 * time.Minute,
+				},
+			)
+
+			ctx := context.Background()
+			if tt.userID != "" {
+				ctx = identity.WithCtx(ctx, domain.Identity{UserID: tt.userID})
+			}
+			contentType := tt.contentType
+			if contentType == "" {
+				contentType = testContentType
+			}
+			file, err := service.UploadFile(ctx, contentType, "", bytes.NewReader(tt.content), tt.size)
+
+			tt.validateResult(t, file, err)
+		})
+	}
+}
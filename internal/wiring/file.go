@@ -0,0 +1,45 @@
+package wiring
+
+import (
+	"github.com/gruzdev-dev/codex-files/configs"
+	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/ports"
+	"github.com/gruzdev-dev/codex-files/core/services"
+)
+
+// NewFileService builds the file service from the configuration. The server
+// and the reconciler both use it, so that they apply the same limits and
+// policies to the files they touch.
+func NewFileService(
+	repo ports.FileRepository,
+	uploads ports.MultipartUploadRepository,
+	grants ports.FileGrantRepository,
+	quotas ports.QuotaRepository,
+	jobs ports.JobRepository,
+	fileProvider ports.FileProvider,
+	scanner ports.Scanner,
+	cfg *configs.Config,
+) *services.FileService {
+	return services.NewFileService(repo, uploads, grants, quotas, jobs, fileProvider, scanner, services.FileServiceConfig{
+		UploadMaxSize:    cfg.Upload.MaxSize,
+		MultipartMaxSize: cfg.Upload.MultipartMaxSize,
+		PartSize:         cfg.Upload.PartSize,
+		UploadTTL:        cfg.Upload.TTL,
+		DownloadTTL:      cfg.Download.TTL,
+		RestoreWindow:    cfg.Purge.Retention,
+		Thumbnails:       len(cfg.Thumbnail.Sizes) > 0,
+		OwnerQuota:       domain.Quota{MaxBytes: cfg.Quota.OwnerMaxBytes, MaxFiles: cfg.Quota.OwnerMaxFiles},
+		TenantQuota:      domain.Quota{MaxBytes: cfg.Quota.TenantMaxBytes, MaxFiles: cfg.Quota.TenantMaxFiles},
+		Tenants:          cfg.TenantIDs(),
+		ContentPolicy: domain.ContentPolicy{
+			Allowed:  cfg.Upload.AllowedTypes,
+			Denied:   cfg.Upload.DeniedTypes,
+			MaxSizes: cfg.Upload.TypeMaxSizes,
+		},
+		MetadataPolicy: domain.MetadataPolicy{
+			Tenants:      cfg.Metadata.StripTenants,
+			Owners:       cfg.Metadata.StripOwners,
+			KeepOriginal: cfg.Metadata.KeepOriginal,
+		},
+	})
+}
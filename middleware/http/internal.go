@@ -0,0 +1,24 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+const internalTokenHeader = "X-Internal-Token"
+
+// InternalToken only lets through requests carrying the internal service
+// secret, the same one the gRPC API checks. Without a secret configured every
+// request is turned away.
+func InternalToken(secret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(internalTokenHeader)
+			if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+				http.Error(w, "invalid internal token", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1 @@
+ALTER TABLE files ADD COLUMN security_context VARCHAR(255) NOT NULL DEFAULT '';
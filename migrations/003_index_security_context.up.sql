@@ -0,0 +1 @@
+CREATE INDEX idx_files_security_context ON files(security_context) WHERE security_context <> '';
@@ -0,0 +1,7 @@
+CREATE TABLE multipart_uploads (
+    file_id UUID PRIMARY KEY REFERENCES files(id),
+    upload_id VARCHAR(1024) NOT NULL,
+    part_size BIGINT NOT NULL,
+    part_count INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,16 @@
+CREATE TABLE resumable_uploads (
+    file_id UUID PRIMARY KEY REFERENCES multipart_uploads(file_id) ON DELETE CASCADE,
+    upload_length BIGINT NOT NULL,
+    upload_offset BIGINT NOT NULL DEFAULT 0,
+    metadata TEXT NOT NULL DEFAULT '',
+    buffer BYTEA NOT NULL DEFAULT ''::bytea,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE upload_parts (
+    file_id UUID NOT NULL REFERENCES multipart_uploads(file_id) ON DELETE CASCADE,
+    part_number INTEGER NOT NULL,
+    etag VARCHAR(255) NOT NULL,
+    PRIMARY KEY (file_id, part_number)
+);
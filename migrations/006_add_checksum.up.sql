@@ -0,0 +1,2 @@
+ALTER TABLE files ADD COLUMN checksum_algorithm VARCHAR(16) NOT NULL DEFAULT '';
+ALTER TABLE files ADD COLUMN checksum VARCHAR(128) NOT NULL DEFAULT '';
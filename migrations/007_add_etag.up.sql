@@ -0,0 +1 @@
+ALTER TABLE files ADD COLUMN etag VARCHAR(128) NOT NULL DEFAULT '';
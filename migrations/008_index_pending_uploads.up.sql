@@ -0,0 +1 @@
+CREATE INDEX idx_files_pending_created_at ON files(created_at) WHERE status = 'pending' AND is_deleted = false;
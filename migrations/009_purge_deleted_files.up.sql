@@ -0,0 +1,7 @@
+ALTER TABLE files ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE files ADD COLUMN purge_lease_until TIMESTAMP;
+ALTER TABLE files ADD COLUMN purged_at TIMESTAMP;
+
+UPDATE files SET deleted_at = updated_at WHERE is_deleted = true;
+
+CREATE INDEX idx_files_purgeable ON files(deleted_at) WHERE is_deleted = true AND purged_at IS NULL;
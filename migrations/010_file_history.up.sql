@@ -0,0 +1,9 @@
+CREATE TABLE file_history (
+    id BIGSERIAL PRIMARY KEY,
+    file_id UUID NOT NULL REFERENCES files(id),
+    action VARCHAR(50) NOT NULL,
+    actor_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_file_history_file_id ON file_history(file_id, created_at);
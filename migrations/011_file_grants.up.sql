@@ -0,0 +1,10 @@
+CREATE TABLE file_grants (
+    file_id UUID NOT NULL REFERENCES files(id),
+    grantee_type VARCHAR(16) NOT NULL,
+    grantee_id VARCHAR(255) NOT NULL,
+    permission VARCHAR(16) NOT NULL,
+    expires_at TIMESTAMP,
+    granted_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (file_id, grantee_type, grantee_id, permission)
+);
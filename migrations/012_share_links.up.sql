@@ -0,0 +1,25 @@
+CREATE TABLE share_links (
+    id UUID PRIMARY KEY,
+    file_id UUID NOT NULL REFERENCES files(id),
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL DEFAULT '',
+    max_downloads INTEGER NOT NULL DEFAULT 0,
+    downloads INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_share_links_file_id ON share_links(file_id);
+
+CREATE TABLE share_link_uses (
+    id BIGSERIAL PRIMARY KEY,
+    link_id UUID NOT NULL REFERENCES share_links(id),
+    outcome VARCHAR(32) NOT NULL,
+    remote_addr VARCHAR(255) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_share_link_uses_link_id ON share_link_uses(link_id, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE files ADD COLUMN tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+CREATE INDEX idx_files_tenant_owner ON files(tenant_id, owner_id);
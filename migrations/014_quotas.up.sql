@@ -0,0 +1,9 @@
+-- An empty owner_id sets the quota of the whole tenant. A zero limit is
+-- unlimited.
+CREATE TABLE quotas (
+    tenant_id VARCHAR(64) NOT NULL,
+    owner_id VARCHAR(255) NOT NULL DEFAULT '',
+    max_bytes BIGINT NOT NULL DEFAULT 0,
+    max_files BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (tenant_id, owner_id)
+);
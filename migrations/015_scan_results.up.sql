@@ -0,0 +1,2 @@
+ALTER TABLE files ADD COLUMN scan_signature VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE files ADD COLUMN scanned_at TIMESTAMP;
@@ -0,0 +1,17 @@
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY,
+    kind VARCHAR(64) NOT NULL,
+    file_id VARCHAR(255) NOT NULL DEFAULT '',
+    payload JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(32) NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_jobs_queued_run_at ON jobs(run_at) WHERE status = 'queued';
+CREATE INDEX idx_jobs_running_locked_until ON jobs(locked_until) WHERE status = 'running';
+CREATE INDEX idx_jobs_file_id ON jobs(file_id);
@@ -0,0 +1,11 @@
+CREATE TABLE file_thumbnails (
+    file_id UUID NOT NULL REFERENCES files(id),
+    size INTEGER NOT NULL,
+    s3_path VARCHAR(512) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    byte_size BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (file_id, size)
+);
@@ -0,0 +1,2 @@
+CREATE INDEX idx_jobs_done_updated_at ON jobs(updated_at) WHERE status = 'done';
+CREATE INDEX idx_jobs_dead_updated_at ON jobs(updated_at) WHERE status = 'dead';
@@ -0,0 +1,42 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/gruzdev-dev/codex-files/core/domain"
+)
+
+type ctxKey int
+
+const scopeKey ctxKey = iota
+
+type scope struct {
+	tenantID string
+	all      bool
+}
+
+// WithCtx scopes ctx to a single tenant. Repositories only see that
+// tenant's rows and the storage provider uses that tenant's bucket.
+func WithCtx(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, scopeKey, scope{tenantID: tenantID})
+}
+
+// Unscoped lifts the tenant restriction for system work such as the
+// background workers and storage webhooks, which act on every tenant.
+func Unscoped(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey, scope{all: true})
+}
+
+// FromCtx returns the tenant ctx is scoped to, the default tenant when it
+// names none.
+func FromCtx(ctx context.Context) string {
+	if s, ok := ctx.Value(scopeKey).(scope); ok && s.tenantID != "" {
+		return s.tenantID
+	}
+	return domain.DefaultTenantID
+}
+
+func IsUnscoped(ctx context.Context) bool {
+	s, ok := ctx.Value(scopeKey).(scope)
+	return ok && s.all
+}
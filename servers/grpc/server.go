@@ -19,7 +19,10 @@ type Server struct {
 
 func NewServer(cfg *configs.Config, handler *grpcAdapter.FilesHandler) *Server {
 	opts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(grpcAdapter.AuthInterceptor(cfg.Auth.InternalSecret)),
+		grpc.ChainUnaryInterceptor(
+			grpcAdapter.AuthInterceptor(cfg.Auth.InternalSecret),
+			grpcAdapter.ErrorInterceptor(),
+		),
 	}
 
 	s := grpc.NewServer(opts...)
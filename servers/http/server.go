@@ -2,6 +2,7 @@ package http
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log"
 	nethttp "net/http"
@@ -43,6 +44,10 @@ func (s *Server) Start() error {
 		_, _ = w.Write([]byte("OK"))
 	}).Methods("GET")
 
+	// The metrics expose process internals, so only other services may read
+	// them.
+	router.Handle("/debug/vars", middleware.InternalToken(s.cfg.Auth.InternalSecret)(expvar.Handler())).Methods("GET")
+
 	api := router.PathPrefix("/api/v1").Subrouter()
 	s.handler.RegisterRoutes(api)
 
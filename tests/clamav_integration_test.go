@@ -0,0 +1,150 @@
+//go:build integration
+
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	clamavAdapter "github.com/gruzdev-dev/codex-files/adapters/clamav"
+	"github.com/gruzdev-dev/codex-files/configs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eicar is the standard antivirus test file.
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd speaks just enough of the clamd INSTREAM protocol for tests: it
+// reports the EICAR signature when the stream contains it and refuses
+// streams longer than maxLength, like clamd's StreamMaxLength.
+type fakeClamd struct {
+	listener  net.Listener
+	maxLength int
+}
+
+func startFakeClamd(t *testing.T, maxLength int) *fakeClamd {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	daemon := &fakeClamd{listener: listener, maxLength: maxLength}
+	go daemon.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return daemon
+}
+
+func (d *fakeClamd) Address() string {
+	return d.listener.Addr().String()
+}
+
+func (d *fakeClamd) serve() {
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(conn)
+	}
+}
+
+func (d *fakeClamd) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	command, err := reader.ReadString(0)
+	if err != nil {
+		return
+	}
+	if command != "zINSTREAM\x00" {
+		_, _ = io.WriteString(conn, "UNKNOWN COMMAND\x00")
+		return
+	}
+
+	var content bytes.Buffer
+	for {
+		var size uint32
+		if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+			return
+		}
+		if size == 0 {
+			break
+		}
+		if content.Len()+int(size) > d.maxLength {
+			_, _ = io.WriteString(conn, "INSTREAM size limit exceeded. ERROR\x00")
+			return
+		}
+		if _, err := io.CopyN(&content, reader, int64(size)); err != nil {
+			return
+		}
+	}
+
+	if strings.Contains(content.String(), eicar) {
+		_, _ = io.WriteString(conn, "stream: Eicar-Test-Signature FOUND\x00")
+		return
+	}
+	_, _ = io.WriteString(conn, "stream: OK\x00")
+}
+
+func newTestScanner(address string) *clamavAdapter.Scanner {
+	cfg := &configs.Config{}
+	cfg.ClamAV.Address = address
+	cfg.ClamAV.Timeout = 5 * time.Second
+	return clamavAdapter.NewScanner(cfg).(*clamavAdapter.Scanner)
+}
+
+func TestClamAVScanner(t *testing.T) {
+	daemon := startFakeClamd(t, 1024*1024)
+	scanner := newTestScanner(daemon.Address())
+	ctx := context.Background()
+
+	t.Run("clean content", func(t *testing.T) {
+		result, err := scanner.Scan(ctx, strings.NewReader("%PDF-1.7 harmless"))
+		require.NoError(t, err)
+		assert.False(t, result.Infected)
+		assert.Empty(t, result.Signature)
+	})
+
+	t.Run("infected content", func(t *testing.T) {
+		result, err := scanner.Scan(ctx, strings.NewReader(eicar))
+		require.NoError(t, err)
+		assert.True(t, result.Infected)
+		assert.Equal(t, "Eicar-Test-Signature", result.Signature)
+	})
+
+	t.Run("content spanning several chunks", func(t *testing.T) {
+		content := append(bytes.Repeat([]byte("a"), 200*1024), eicar...)
+		result, err := scanner.Scan(ctx, bytes.NewReader(content))
+		require.NoError(t, err)
+		assert.True(t, result.Infected)
+	})
+
+	t.Run("content over the daemon limit", func(t *testing.T) {
+		small := newTestScanner(startFakeClamd(t, 1024).Address())
+		result, err := small.Scan(ctx, bytes.NewReader(bytes.Repeat([]byte("a"), 200*1024)))
+		require.NoError(t, err)
+		assert.False(t, result.Infected)
+		assert.Contains(t, result.Refused, "size limit exceeded")
+	})
+
+	t.Run("daemon unavailable", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		_, err = newTestScanner(address).Scan(ctx, strings.NewReader("content"))
+		require.Error(t, err)
+		var opErr *net.OpError
+		assert.True(t, errors.As(err, &opErr))
+	})
+}
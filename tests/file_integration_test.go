@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gruzdev-dev/codex-files/core/domain"
+	"github.com/gruzdev-dev/codex-files/core/services"
 	"github.com/gruzdev-dev/codex-files/proto"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -29,6 +30,12 @@ const (
 	s3Basic         = "http://s3.test.local/test-bucket/"
 )
 
+type nopSeekCloser struct {
+	*strings.Reader
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
 func createTestJWTToken(secret string, userID string, scopes []string) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":   userID,
@@ -64,9 +71,10 @@ func TestFileIntegration(t *testing.T) {
 				gomock.Any(),
 				testContentType,
 				int64(104857600),
+				domain.Checksum{},
 				gomock.Any(),
 			).
-			DoAndReturn(func(ctx context.Context, s3Path, contentType string, maxSize int64, ttl time.Duration) (string, error) {
+			DoAndReturn(func(ctx context.Context, s3Path, contentType string, maxSize int64, checksum domain.Checksum, ttl time.Duration) (string, error) {
 				assert.Contains(t, s3Path, testUserID)
 				return s3Basic + s3Path, nil
 			}).
@@ -153,6 +161,19 @@ func TestFileIntegration(t *testing.T) {
 			},
 		}
 
+		env.S3Mock.EXPECT().
+			StatObject(gomock.Any(), s3Path).
+			Return(&domain.ObjectInfo{
+				Size:        testFileSize,
+				ContentType: testContentType,
+				ETag:        "test-etag",
+			}, nil).
+			Times(1)
+		env.S3Mock.EXPECT().
+			OpenObject(gomock.Any(), s3Path).
+			Return(nopSeekCloser{strings.NewReader("%PDF-1.7 integration test content")}, nil).
+			Times(1)
+
 		payloadBytes, err := json.Marshal(webhookPayload)
 		require.NoError(t, err)
 
@@ -167,7 +188,18 @@ func TestFileIntegration(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
+		// The webhook only queues the confirmation; a job worker settles it.
 		ctx := context.Background()
+		err = env.Container.Invoke(func(runner *services.JobRunner) error {
+			result, err := runner.RunBatch(ctx)
+			if err != nil {
+				return err
+			}
+			assert.Equal(t, 1, result.Done)
+			return nil
+		})
+		require.NoError(t, err)
+
 		file, err := getFileFromDB(ctx, env.DB, fileID)
 		require.NoError(t, err)
 		require.NotNil(t, file)
@@ -244,6 +276,22 @@ func TestFileIntegration(t *testing.T) {
 
 		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 	})
+
+	t.Run("Step 8: Restore file via gRPC", func(t *testing.T) {
+		md := metadata.Pairs("x-internal-token", "test-internal-secret")
+		ctx := metadata.NewOutgoingContext(context.Background(), md)
+
+		resp, err := env.GRPCClient.RestoreFile(ctx, &proto.RestoreFileRequest{
+			FileId: fileID,
+			UserId: testUserID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, fileID, resp.FileId)
+
+		file, err := getFileFromDB(context.Background(), env.DB, fileID)
+		require.NoError(t, err)
+		assert.False(t, file.IsDeleted)
+	})
 }
 
 func getFileFromDB(ctx context.Context, pool *pgxpool.Pool, fileID string) (*domain.File, error) {
@@ -0,0 +1,330 @@
+//go:build integration
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	imagingAdapter "github.com/gruzdev-dev/codex-files/adapters/imaging"
+	"github.com/gruzdev-dev/codex-files/configs"
+	"github.com/gruzdev-dev/codex-files/core/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestThumbnailer(maxPixels int64) *imagingAdapter.Thumbnailer {
+	cfg := &configs.Config{}
+	cfg.Thumbnail.MaxPixels = maxPixels
+	return imagingAdapter.NewThumbnailer(cfg).(*imagingAdapter.Thumbnailer)
+}
+
+// testImage is half opaque red and half transparent, split vertically.
+func testImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width / 2 {
+			img.Set(x, y, color.NRGBA{R: 255, A: 255})
+		}
+	}
+	return img
+}
+
+func encodeTestImage(t *testing.T, format string, img image.Image) []byte {
+	var buf bytes.Buffer
+	switch format {
+	case "jpeg":
+		require.NoError(t, jpeg.Encode(&buf, img, nil))
+	case "png":
+		require.NoError(t, png.Encode(&buf, img))
+	case "gif":
+		require.NoError(t, gif.Encode(&buf, img, nil))
+	}
+	return buf.Bytes()
+}
+
+func TestThumbnailer(t *testing.T) {
+	thumbnailer := newTestThumbnailer(50_000_000)
+	ctx := context.Background()
+
+	t.Run("formats", func(t *testing.T) {
+		tests := []struct {
+			format              string
+			expectedContentType string
+		}{
+			{format: "jpeg", expectedContentType: "image/jpeg"},
+			{format: "png", expectedContentType: "image/png"},
+			{format: "gif", expectedContentType: "image/png"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.format, func(t *testing.T) {
+				content := encodeTestImage(t, tt.format, testImage(800, 600))
+
+				thumbnails, err := thumbnailer.Thumbnail(ctx, bytes.NewReader(content), []int{128, 256})
+				require.NoError(t, err)
+				require.Len(t, thumbnails, 2)
+
+				for i, expected := range [][2]int{{128, 96}, {256, 192}} {
+					thumbnail := thumbnails[i]
+					assert.Equal(t, tt.expectedContentType, thumbnail.ContentType)
+					assert.Equal(t, expected[0], thumbnail.Width)
+					assert.Equal(t, expected[1], thumbnail.Height)
+
+					decoded, _, err := image.Decode(bytes.NewReader(thumbnail.Data))
+					require.NoError(t, err)
+					assert.Equal(t, expected[0], decoded.Bounds().Dx())
+					assert.Equal(t, expected[1], decoded.Bounds().Dy())
+				}
+			})
+		}
+	})
+
+	t.Run("jpeg exif orientation applied", func(t *testing.T) {
+		plain := encodeTestImage(t, "jpeg", testImage(800, 600))
+
+		// The left half of the test image is red, so it ends up on top when
+		// turned clockwise and at the bottom when turned counterclockwise.
+		for orientation, redOnTop := range map[uint16]bool{6: true, 8: false} {
+			content := append(append(append([]byte{}, plain[:2]...), jpegSegment(0xE1, exifSegment(orientation))...), plain[2:]...)
+
+			thumbnails, err := thumbnailer.Thumbnail(ctx, bytes.NewReader(content), []int{128})
+			require.NoError(t, err)
+			assert.Equal(t, 96, thumbnails[0].Width)
+			assert.Equal(t, 128, thumbnails[0].Height)
+
+			decoded, err := jpeg.Decode(bytes.NewReader(thumbnails[0].Data))
+			require.NoError(t, err)
+			topRed, _, _, _ := decoded.At(48, 10).RGBA()
+			bottomRed, _, _, _ := decoded.At(48, 118).RGBA()
+			assert.Equal(t, redOnTop, topRed > bottomRed, "orientation %d", orientation)
+		}
+	})
+
+	t.Run("portrait image fitted by height", func(t *testing.T) {
+		content := encodeTestImage(t, "png", testImage(300, 1200))
+
+		thumbnails, err := thumbnailer.Thumbnail(ctx, bytes.NewReader(content), []int{128})
+		require.NoError(t, err)
+		assert.Equal(t, 32, thumbnails[0].Width)
+		assert.Equal(t, 128, thumbnails[0].Height)
+	})
+
+	t.Run("small image not scaled up", func(t *testing.T) {
+		content := encodeTestImage(t, "png", testImage(40, 30))
+
+		thumbnails, err := thumbnailer.Thumbnail(ctx, bytes.NewReader(content), []int{128})
+		require.NoError(t, err)
+		assert.Equal(t, 128, thumbnails[0].Size)
+		assert.Equal(t, 40, thumbnails[0].Width)
+		assert.Equal(t, 30, thumbnails[0].Height)
+	})
+
+	t.Run("transparency kept", func(t *testing.T) {
+		content := encodeTestImage(t, "png", testImage(800, 600))
+
+		thumbnails, err := thumbnailer.Thumbnail(ctx, bytes.NewReader(content), []int{128})
+		require.NoError(t, err)
+
+		decoded, err := png.Decode(bytes.NewReader(thumbnails[0].Data))
+		require.NoError(t, err)
+		_, _, _, leftAlpha := decoded.At(10, 10).RGBA()
+		r, _, _, _ := decoded.At(10, 10).RGBA()
+		_, _, _, rightAlpha := decoded.At(120, 10).RGBA()
+		assert.Equal(t, uint32(0xffff), leftAlpha)
+		assert.Equal(t, uint32(0xffff), r)
+		assert.Equal(t, uint32(0), rightAlpha)
+	})
+
+	t.Run("image over the pixel limit", func(t *testing.T) {
+		content := encodeTestImage(t, "png", testImage(200, 200))
+
+		_, err := newTestThumbnailer(10_000).Thumbnail(ctx, bytes.NewReader(content), []int{128})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		_, err := thumbnailer.Thumbnail(ctx, strings.NewReader("%PDF-1.7 not an image"), []int{128})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+
+	t.Run("truncated image", func(t *testing.T) {
+		content := encodeTestImage(t, "png", testImage(800, 600))
+
+		_, err := thumbnailer.Thumbnail(ctx, bytes.NewReader(content[:len(content)/2]), []int{128})
+		assert.ErrorIs(t, err, domain.ErrInvalidInput)
+	})
+}
+
+// jpegSegment encodes a JPEG marker segment with its length.
+func jpegSegment(marker byte, data string) []byte {
+	size := len(data) + 2
+	return append([]byte{0xFF, marker, byte(size >> 8), byte(size)}, data...)
+}
+
+// exifSegment encodes a little-endian EXIF APP1 payload with a camera make
+// and the given orientation in its first IFD.
+func exifSegment(orientation uint16) string {
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 2)
+	tiff = append(tiff, 0x0F, 0x01, 0x02, 0x00, 0x04, 0x00, 0x00, 0x00, 'A', 'c', 'm', 0)
+	tiff = append(tiff, 0x12, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	return "Exif\x00\x00" + string(tiff)
+}
+
+// pngChunk encodes a PNG chunk with its length and CRC.
+func pngChunk(chunkType, data string) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE([]byte(chunkType+data)))
+}
+
+func TestMetadataStripper(t *testing.T) {
+	stripper := imagingAdapter.NewMetadataStripper()
+	ctx := context.Background()
+
+	t.Run("jpeg", func(t *testing.T) {
+		plain := encodeTestImage(t, "jpeg", testImage(64, 48))
+		icc := jpegSegment(0xE2, "ICC_PROFILE\x00\x01\x01profile")
+
+		var tagged []byte
+		tagged = append(tagged, plain[:2]...)
+		tagged = append(tagged, jpegSegment(0xE1, "Exif\x00\x00GPSLatitude 55.75")...)
+		tagged = append(tagged, jpegSegment(0xE1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")...)
+		tagged = append(tagged, icc...)
+		tagged = append(tagged, jpegSegment(0xFE, "shot on a phone")...)
+		tagged = append(tagged, plain[2:]...)
+		tagged = append(tagged, "appended preview"...)
+
+		var out bytes.Buffer
+		removed, err := stripper.Strip(ctx, &out, bytes.NewReader(tagged))
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, removed)
+		expected := append(append(append([]byte{}, plain[:2]...), icc...), plain[2:]...)
+		assert.Equal(t, expected, out.Bytes())
+
+		img, err := jpeg.Decode(&out)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 64, 48), img.Bounds())
+	})
+
+	t.Run("jpeg orientation kept", func(t *testing.T) {
+		plain := encodeTestImage(t, "jpeg", testImage(64, 48))
+
+		var tagged []byte
+		tagged = append(tagged, plain[:2]...)
+		tagged = append(tagged, jpegSegment(0xE1, exifSegment(6))...)
+		tagged = append(tagged, jpegSegment(0xE1, "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>")...)
+		tagged = append(tagged, plain[2:]...)
+
+		var out bytes.Buffer
+		removed, err := stripper.Strip(ctx, &out, bytes.NewReader(tagged))
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, removed)
+		orientation := jpegSegment(0xE1, "Exif\x00\x00MM\x00*\x00\x00\x00\x08\x00\x01"+
+			"\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+		expected := append(append(append([]byte{}, plain[:2]...), orientation...), plain[2:]...)
+		assert.Equal(t, expected, out.Bytes())
+
+		var again bytes.Buffer
+		removed, err = stripper.Strip(ctx, &again, bytes.NewReader(expected))
+		require.NoError(t, err)
+		assert.Zero(t, removed)
+		assert.Equal(t, expected, again.Bytes())
+	})
+
+	t.Run("jpeg normal orientation dropped", func(t *testing.T) {
+		plain := encodeTestImage(t, "jpeg", testImage(64, 48))
+		tagged := append(append(append([]byte{}, plain[:2]...), jpegSegment(0xE1, exifSegment(1))...), plain[2:]...)
+
+		var out bytes.Buffer
+		removed, err := stripper.Strip(ctx, &out, bytes.NewReader(tagged))
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, removed)
+		assert.Equal(t, plain, out.Bytes())
+	})
+
+	t.Run("png", func(t *testing.T) {
+		plain := encodeTestImage(t, "png", testImage(64, 48))
+		// The signature and the IHDR chunk come first, the IEND chunk last.
+		header, body, trailer := plain[:33], plain[33:len(plain)-12], plain[len(plain)-12:]
+
+		var tagged []byte
+		tagged = append(tagged, header...)
+		tagged = append(tagged, pngChunk("tEXt", "Author\x00someone")...)
+		tagged = append(tagged, pngChunk("eXIf", "MM\x00*GPS")...)
+		tagged = append(tagged, body...)
+		tagged = append(tagged, pngChunk("tIME", "\x07\xea\x0a\x10\x0c\x00\x00")...)
+		tagged = append(tagged, trailer...)
+
+		var out bytes.Buffer
+		removed, err := stripper.Strip(ctx, &out, bytes.NewReader(tagged))
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, removed)
+		assert.Equal(t, plain, out.Bytes())
+
+		img, err := png.Decode(&out)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 64, 48), img.Bounds())
+	})
+
+	t.Run("trailing data counted as removed", func(t *testing.T) {
+		for _, format := range []string{"jpeg", "png"} {
+			plain := encodeTestImage(t, format, testImage(2, 2))
+			tagged := append(append([]byte{}, plain...), "0123456789abcdef"...)
+
+			var out bytes.Buffer
+			removed, err := stripper.Strip(ctx, &out, bytes.NewReader(tagged))
+			require.NoError(t, err, format)
+
+			assert.Equal(t, 1, removed, format)
+			assert.Equal(t, plain, out.Bytes(), format)
+		}
+	})
+
+	t.Run("nothing to strip - content unchanged", func(t *testing.T) {
+		for _, format := range []string{"jpeg", "png"} {
+			plain := encodeTestImage(t, format, testImage(32, 32))
+
+			var out bytes.Buffer
+			removed, err := stripper.Strip(ctx, &out, bytes.NewReader(plain))
+			require.NoError(t, err, format)
+
+			assert.Zero(t, removed, format)
+			assert.Equal(t, plain, out.Bytes(), format)
+		}
+	})
+
+	t.Run("malformed content rejected", func(t *testing.T) {
+		jpegImage := encodeTestImage(t, "jpeg", testImage(32, 32))
+		pngImage := encodeTestImage(t, "png", testImage(32, 32))
+
+		for name, content := range map[string][]byte{
+			"not an image":   []byte("plain text content"),
+			"gif":            encodeTestImage(t, "gif", testImage(32, 32)),
+			"truncated jpeg": jpegImage[:len(jpegImage)/2],
+			"truncated png":  pngImage[:len(pngImage)-12],
+		} {
+			_, err := stripper.Strip(ctx, io.Discard, bytes.NewReader(content))
+			assert.ErrorIs(t, err, domain.ErrInvalidInput, name)
+		}
+	})
+}
@@ -5,18 +5,24 @@ package tests
 import (
 	"context"
 	"io"
+	"io/fs"
 	"log"
 	"net"
 	"net/http/httptest"
+	"sort"
 	"testing"
 	"time"
 
+	clamavAdapter "github.com/gruzdev-dev/codex-files/adapters/clamav"
 	grpcAdapter "github.com/gruzdev-dev/codex-files/adapters/grpc"
 	httpAdapter "github.com/gruzdev-dev/codex-files/adapters/http"
+	imagingAdapter "github.com/gruzdev-dev/codex-files/adapters/imaging"
 	postgresAdapter "github.com/gruzdev-dev/codex-files/adapters/storage/postgres"
 	"github.com/gruzdev-dev/codex-files/configs"
+	"github.com/gruzdev-dev/codex-files/core/domain"
 	"github.com/gruzdev-dev/codex-files/core/ports"
 	"github.com/gruzdev-dev/codex-files/core/services"
+	"github.com/gruzdev-dev/codex-files/internal/wiring"
 	"github.com/gruzdev-dev/codex-files/migrations"
 	"github.com/gruzdev-dev/codex-files/proto"
 	grpcServer "github.com/gruzdev-dev/codex-files/servers/grpc"
@@ -138,14 +144,74 @@ func newContainer(t *testing.T, config *configs.Config, pool *pgxpool.Pool, s3Mo
 		t.Fatalf("failed to provide file repo: %v", err)
 	}
 
+	if err := container.Provide(postgresAdapter.NewFileGrantRepo, dig.As(new(ports.FileGrantRepository))); err != nil {
+		t.Fatalf("failed to provide file grant repo: %v", err)
+	}
+
+	if err := container.Provide(postgresAdapter.NewQuotaRepo, dig.As(new(ports.QuotaRepository))); err != nil {
+		t.Fatalf("failed to provide quota repo: %v", err)
+	}
+
+	if err := container.Provide(postgresAdapter.NewJobRepo, dig.As(new(ports.JobRepository))); err != nil {
+		t.Fatalf("failed to provide job repository: %v", err)
+	}
+
+	if err := container.Provide(postgresAdapter.NewThumbnailRepo, dig.As(new(ports.ThumbnailRepository))); err != nil {
+		t.Fatalf("failed to provide thumbnail repository: %v", err)
+	}
+
+	if err := container.Provide(postgresAdapter.NewShareLinkRepo, dig.As(new(ports.ShareLinkRepository))); err != nil {
+		t.Fatalf("failed to provide share link repo: %v", err)
+	}
+
+	if err := container.Provide(postgresAdapter.NewMultipartUploadRepo, dig.As(new(ports.MultipartUploadRepository))); err != nil {
+		t.Fatalf("failed to provide multipart upload repo: %v", err)
+	}
+
+	if err := container.Provide(postgresAdapter.NewResumableUploadRepo, dig.As(new(ports.ResumableUploadRepository))); err != nil {
+		t.Fatalf("failed to provide resumable upload repo: %v", err)
+	}
+
 	if err := container.Provide(func() ports.FileProvider { return s3Mock }); err != nil {
 		t.Fatalf("failed to provide s3 mock: %v", err)
 	}
 
-	if err := container.Provide(newFileService); err != nil {
+	if err := container.Provide(clamavAdapter.NewScanner); err != nil {
+		t.Fatalf("failed to provide scanner: %v", err)
+	}
+
+	if err := container.Provide(imagingAdapter.NewThumbnailer); err != nil {
+		t.Fatalf("failed to provide thumbnailer: %v", err)
+	}
+
+	if err := container.Provide(imagingAdapter.NewMetadataStripper); err != nil {
+		t.Fatalf("failed to provide metadata stripper: %v", err)
+	}
+
+	if err := container.Provide(wiring.NewFileService); err != nil {
 		t.Fatalf("failed to provide file service: %v", err)
 	}
 
+	if err := container.Provide(newJobRunner); err != nil {
+		t.Fatalf("failed to provide job runner: %v", err)
+	}
+
+	if err := container.Provide(newResumableUploadService); err != nil {
+		t.Fatalf("failed to provide resumable upload service: %v", err)
+	}
+
+	if err := container.Provide(newThumbnailService); err != nil {
+		t.Fatalf("failed to provide thumbnail service: %v", err)
+	}
+
+	if err := container.Provide(newMetadataService); err != nil {
+		t.Fatalf("failed to provide metadata service: %v", err)
+	}
+
+	if err := container.Provide(newShareLinkService); err != nil {
+		t.Fatalf("failed to provide share link service: %v", err)
+	}
+
 	if err := container.Provide(grpcAdapter.NewFilesHandler); err != nil {
 		t.Fatalf("failed to provide grpc handler: %v", err)
 	}
@@ -194,14 +260,22 @@ func newPool(t *testing.T, ctx context.Context, logger *log.Logger) (*pgxpool.Po
 		t.Fatalf("failed to ping db: %v", err)
 	}
 
-	migrationSQL, err := migrations.FS.ReadFile("001_init.up.sql")
+	migrationFiles, err := fs.Glob(migrations.FS, "*.up.sql")
 	if err != nil {
-		t.Fatalf("failed to read migration file: %s", err)
+		t.Fatalf("failed to list migration files: %s", err)
 	}
+	sort.Strings(migrationFiles)
 
-	_, err = pool.Exec(ctx, string(migrationSQL))
-	if err != nil {
-		t.Fatalf("failed to apply migration: %s", err)
+	for _, name := range migrationFiles {
+		migrationSQL, err := migrations.FS.ReadFile(name)
+		if err != nil {
+			t.Fatalf("failed to read migration file %s: %s", name, err)
+		}
+
+		_, err = pool.Exec(ctx, string(migrationSQL))
+		if err != nil {
+			t.Fatalf("failed to apply migration %s: %s", name, err)
+		}
 	}
 
 	return pool, pgContainer
@@ -241,22 +315,84 @@ func newConfig(t *testing.T, ctx context.Context, pgContainer *postgres.Postgres
 	cfg.S3.UseSSL = false
 	cfg.S3.WebhookSecret = "test-webhook-secret"
 	cfg.Upload.MaxSize = 100 * 1024 * 1024
+	cfg.Upload.MultipartMaxSize = 50 * 1024 * 1024 * 1024
+	cfg.Upload.PartSize = 64 * 1024 * 1024
 	cfg.Upload.TTL = 5 * time.Minute
+	cfg.Upload.ResumableTTL = 24 * time.Hour
 	cfg.Download.TTL = 15 * time.Minute
+	cfg.Download.Mode = configs.DownloadModeRedirect
+	cfg.Purge.Retention = 30 * 24 * time.Hour
+	cfg.Share.DefaultTTL = 7 * 24 * time.Hour
+	cfg.Share.MaxTTL = 30 * 24 * time.Hour
+	cfg.Thumbnail.Sizes = []int{128, 256}
+	cfg.Thumbnail.MaxPixels = 50_000_000
+	cfg.Jobs.BatchSize = 10
+	cfg.Jobs.Lease = time.Minute
+	cfg.Jobs.MaxAttempts = 3
+	cfg.Jobs.BackoffBase = time.Second
+	cfg.Jobs.BackoffMax = time.Minute
 
 	return cfg
 }
 
-func newFileService(
-	repo ports.FileRepository,
+func newJobRunner(
+	jobs ports.JobRepository,
+	fileService *services.FileService,
+	thumbnailService *services.ThumbnailService,
+	metadataService *services.MetadataService,
+	cfg *configs.Config,
+) *services.JobRunner {
+	runner := services.NewJobRunner(jobs, services.JobRunnerConfig{
+		BatchSize:   cfg.Jobs.BatchSize,
+		Lease:       cfg.Jobs.Lease,
+		MaxAttempts: cfg.Jobs.MaxAttempts,
+		Backoff:     domain.JobBackoff{Base: cfg.Jobs.BackoffBase, Max: cfg.Jobs.BackoffMax},
+	})
+	runner.Register(domain.JobKindConfirmUpload, fileService.ProcessConfirmUpload)
+	runner.Register(domain.JobKindScanFile, fileService.ProcessScanFile)
+	runner.Register(domain.JobKindStripMetadata, metadataService.ProcessStripMetadata)
+	runner.Register(domain.JobKindThumbnails, thumbnailService.ProcessThumbnails)
+	return runner
+}
+
+func newThumbnailService(
+	fileService *services.FileService,
+	thumbnails ports.ThumbnailRepository,
+	thumbnailer ports.Thumbnailer,
 	fileProvider ports.FileProvider,
 	cfg *configs.Config,
-) *services.FileService {
-	return services.NewFileService(
-		repo,
-		fileProvider,
-		cfg.Upload.MaxSize,
-		cfg.Upload.TTL,
-		cfg.Download.TTL,
-	)
+) *services.ThumbnailService {
+	return services.NewThumbnailService(fileService, thumbnails, thumbnailer, fileProvider, services.ThumbnailConfig{
+		Sizes: cfg.Thumbnail.Sizes,
+	})
+}
+
+func newMetadataService(
+	fileService *services.FileService,
+	stripper ports.MetadataStripper,
+	fileProvider ports.FileProvider,
+) *services.MetadataService {
+	return services.NewMetadataService(fileService, stripper, fileProvider)
+}
+
+func newShareLinkService(
+	fileService *services.FileService,
+	shares ports.ShareLinkRepository,
+	fileProvider ports.FileProvider,
+	cfg *configs.Config,
+) *services.ShareLinkService {
+	return services.NewShareLinkService(fileService, shares, fileProvider, services.ShareLinkConfig{
+		DefaultTTL: cfg.Share.DefaultTTL,
+		MaxTTL:     cfg.Share.MaxTTL,
+	})
+}
+
+func newResumableUploadService(
+	fileService *services.FileService,
+	uploads ports.MultipartUploadRepository,
+	resumable ports.ResumableUploadRepository,
+	fileProvider ports.FileProvider,
+	cfg *configs.Config,
+) *services.ResumableUploadService {
+	return services.NewResumableUploadService(fileService, uploads, resumable, fileProvider, cfg.Upload.ResumableTTL)
 }